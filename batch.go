@@ -0,0 +1,114 @@
+package flow
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+type batchConfig struct {
+	concurrency int
+	failFast    bool
+}
+
+type BatchOption func(*batchConfig)
+
+func WithBatchConcurrency(n int) BatchOption {
+	return func(c *batchConfig) {
+		if n > 0 {
+			c.concurrency = n
+		}
+	}
+}
+
+func WithBatchFailFast() BatchOption {
+	return func(c *batchConfig) {
+		c.failFast = true
+	}
+}
+
+type BatchError struct {
+	Errors []error
+}
+
+func (e *BatchError) Error() string {
+	var messages []string
+	for i, err := range e.Errors {
+		if err != nil {
+			messages = append(messages, fmt.Sprintf("run %d: %v", i, err))
+		}
+	}
+	return fmt.Sprintf("%d of %d runs failed: %s", len(messages), len(e.Errors), strings.Join(messages, "; "))
+}
+
+func (e *BatchError) Unwrap() []error {
+	var errs []error
+	for _, err := range e.Errors {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+func (g *Graph) RunBatch(ctx context.Context, inputsList [][]any, opts ...BatchOption) ([]map[string][]any, error) {
+	if g.err != nil {
+		return nil, g.err
+	}
+
+	config := batchConfig{concurrency: defaultWorkerCount}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]map[string][]any, len(inputsList))
+	errs := make([]error, len(inputsList))
+	var firstErr error
+	var firstOnce sync.Once
+
+	sem := make(chan struct{}, config.concurrency)
+	var wg sync.WaitGroup
+	for i, inputs := range inputsList {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			errs[i] = canceledError(ctx)
+			continue
+		}
+
+		run := g.Clone()
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			run.startInputs = inputs
+			if err := run.RunWithContext(ctx); err != nil {
+				errs[i] = err
+				firstOnce.Do(func() { firstErr = err })
+				if config.failFast {
+					cancel()
+				}
+				return
+			}
+			results[i] = run.TerminalResults()
+		}()
+	}
+	wg.Wait()
+
+	if config.failFast && firstErr != nil {
+		return results, firstErr
+	}
+	for _, err := range errs {
+		if err != nil {
+			return results, &BatchError{Errors: errs}
+		}
+	}
+	return results, nil
+}
@@ -88,3 +88,77 @@ func BenchmarkC8x8(b *testing.B) {
 		_ = graph.RunWithContext(context.Background())
 	}
 }
+
+var chain32Names = func() []string {
+	names := make([]string, 32)
+	for i := range names {
+		names[i] = fmt.Sprintf("N%d", i)
+	}
+	return names
+}()
+
+func BenchmarkBuildS32(b *testing.B) {
+	for b.Loop() {
+		graph := flow.NewGraph()
+		graph.AddNode(chain32Names[0], func() int { return 0 })
+		for i := 1; i < len(chain32Names); i++ {
+			graph.AddNode(chain32Names[i], func(n int) int { return n + 1 })
+			graph.AddEdge(chain32Names[i-1], chain32Names[i])
+		}
+		if _, err := graph.TopologicalOrder(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTemplateS32(b *testing.B) {
+	builder := flow.NewGraphBuilder()
+	builder.AddNode(chain32Names[0], func() int { return 0 })
+	for i := 1; i < len(chain32Names); i++ {
+		builder.AddNode(chain32Names[i], func(n int) int { return n + 1 })
+		builder.AddEdge(chain32Names[i-1], chain32Names[i])
+	}
+	template, err := builder.Build()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for b.Loop() {
+		_ = template.Instance()
+	}
+}
+
+func conditionChain32(cond func() any) *flow.Graph {
+	graph := flow.NewGraph()
+	graph.AddNode(chain32Names[0], func() int { return 0 })
+	for i := 1; i < len(chain32Names); i++ {
+		graph.AddNode(chain32Names[i], func(n int) int { return n + 1 })
+		graph.AddEdgeWithCondition(chain32Names[i-1], chain32Names[i], cond())
+	}
+	return graph
+}
+
+func BenchmarkCondS32(b *testing.B) {
+	graph := conditionChain32(func() any {
+		return func(n int) bool { return n >= 0 }
+	})
+
+	b.ResetTimer()
+	for b.Loop() {
+		graph.ClearStatus()
+		_ = graph.RunWithContext(context.Background())
+	}
+}
+
+func BenchmarkTypedCondS32(b *testing.B) {
+	graph := conditionChain32(func() any {
+		return flow.TypedCond(func(n int) bool { return n >= 0 })
+	})
+
+	b.ResetTimer()
+	for b.Loop() {
+		graph.ClearStatus()
+		_ = graph.RunWithContext(context.Background())
+	}
+}
@@ -0,0 +1,153 @@
+package flow
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+type nodeCache struct {
+	mu      sync.Mutex
+	entries map[string][]any
+}
+
+func (g *Graph) AddCachedNode(name string, fn any, opts ...NodeOption) *Graph {
+	if !g.tryAddNode(name, fn, opts...) {
+		return g
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	node := g.nodes[name]
+	node.cache = &nodeCache{entries: make(map[string][]any)}
+	node.callFn = node.cache.wrap(node.callFn)
+	return g
+}
+
+func (c *nodeCache) wrap(call func(context.Context, []any) ([]any, error)) func(context.Context, []any) ([]any, error) {
+	return func(ctx context.Context, inputs []any) ([]any, error) {
+		key, ok := cacheKey(inputs)
+		if !ok {
+			return call(ctx, inputs)
+		}
+
+		c.mu.Lock()
+		cached, ok := c.entries[key]
+		c.mu.Unlock()
+		if ok {
+			return append([]any{}, cached...), nil
+		}
+
+		results, err := call(ctx, inputs)
+		if err != nil {
+			return nil, err
+		}
+
+		c.mu.Lock()
+		c.entries[key] = append([]any{}, results...)
+		c.mu.Unlock()
+		return results, nil
+	}
+}
+
+// Inputs that JSON would encode lossily get no key, so distinct inputs never share one.
+func cacheKey(inputs []any) (string, bool) {
+	var key strings.Builder
+	for _, input := range inputs {
+		if !losslessJSON(reflect.ValueOf(input), make(map[uintptr]bool)) {
+			return "", false
+		}
+		data, err := json.Marshal(input)
+		if err != nil {
+			return "", false
+		}
+		if t := reflect.TypeOf(input); t != nil {
+			key.WriteString(t.PkgPath())
+			key.WriteString(t.String())
+		}
+		key.WriteByte(0)
+		key.Write(data)
+		key.WriteByte(0)
+	}
+	return key.String(), true
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+func losslessJSON(v reflect.Value, seen map[uintptr]bool) bool {
+	if !v.IsValid() {
+		return true
+	}
+	t := v.Type()
+	if t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Pointer:
+		if v.IsNil() {
+			return true
+		}
+		if seen[v.Pointer()] {
+			return false
+		}
+		seen[v.Pointer()] = true
+		return losslessJSON(v.Elem(), seen)
+	case reflect.Interface:
+		return v.IsNil() || losslessJSON(v.Elem(), seen)
+	case reflect.Slice, reflect.Array:
+		for i := range v.Len() {
+			if !losslessJSON(v.Index(i), seen) {
+				return false
+			}
+		}
+		return true
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if !losslessJSON(iter.Key(), seen) || !losslessJSON(iter.Value(), seen) {
+				return false
+			}
+		}
+		return true
+	case reflect.Struct:
+		for i := range t.NumField() {
+			field := t.Field(i)
+			if !field.IsExported() || field.Tag.Get("json") == "-" {
+				return false
+			}
+			if !losslessJSON(v.Field(i), seen) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *nodeCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+}
+
+func (g *Graph) ClearNodeCache() {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	for _, node := range g.nodes {
+		if node.cache != nil {
+			node.cache.clear()
+		}
+	}
+}
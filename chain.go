@@ -3,7 +3,10 @@ package flow
 import (
 	"context"
 	"fmt"
+	"maps"
 	"reflect"
+	"slices"
+	"sync"
 )
 
 const (
@@ -12,23 +15,39 @@ const (
 	ErrNotFunction       = "argument is not a function"
 	ErrFunctionPanicked  = "function panicked"
 	ErrStepNotFound      = "step not found"
+	ErrMissingContext    = "first parameter must be context.Context"
+	ErrSeedAfterRun      = "chain cannot be seeded after it has run"
+	ErrSeedNotFunction   = "first step must be a function to receive seed values"
 	defaultChainCapacity = 8
 )
 
+type RecoverFunc func(err error) ([]any, bool)
+
+type CatchFunc func(err error) (any, error)
+
 type (
 	task struct {
-		name     string
-		values   []reflect.Value
-		fnValue  reflect.Value
-		argTypes []reflect.Type
-		do       bool
+		name        string
+		values      []reflect.Value
+		fnValue     reflect.Value
+		argTypes    []reflect.Type
+		do          bool
+		recoverFn   RecoverFunc
+		catchFn     CatchFunc
+		withContext bool
+		sources     []string
+		group       []*task
 	}
 
 	Chain struct {
-		err       error
-		values    []reflect.Value
-		stepNames map[string]int
-		handlers  []*task
+		err         error
+		values      []reflect.Value
+		stepNames   map[string]int
+		handlers    []*task
+		pauseSignal PauseSignal
+		pausedAt    int
+		seed        []reflect.Value
+		restoredErr error
 	}
 )
 
@@ -37,9 +56,35 @@ func NewChain() *Chain {
 		values:    make([]reflect.Value, 0, defaultChainCapacity),
 		stepNames: make(map[string]int, defaultChainCapacity),
 		handlers:  make([]*task, 0, defaultChainCapacity),
+		pausedAt:  -1,
 	}
 }
 
+func (c *Chain) Seed(values ...any) *Chain {
+	if c.err != nil {
+		return c
+	}
+	for _, t := range c.handlers {
+		if t.do {
+			c.err = &FlowError{Kind: ErrSeedAfterRunErr, Message: ErrSeedAfterRun}
+			return c
+		}
+	}
+	c.seed = make([]reflect.Value, len(values))
+	for i, v := range values {
+		c.seed[i] = reflect.ValueOf(v)
+	}
+	c.err = c.seedError()
+	return c
+}
+
+func (c *Chain) seedError() error {
+	if c.seed == nil || len(c.handlers) == 0 || c.handlers[0].fnValue.Kind() == reflect.Func || c.handlers[0].group != nil {
+		return nil
+	}
+	return &FlowError{Kind: ErrSeedNotFunctionErr, Message: fmt.Sprintf("%s: %s", ErrSeedNotFunction, c.handlers[0].name)}
+}
+
 func (c *Chain) Add(name string, fn any) *Chain {
 	if c.err != nil {
 		return c
@@ -65,6 +110,172 @@ func (c *Chain) Add(name string, fn any) *Chain {
 	return c
 }
 
+func (c *Chain) AddCtx(name string, fn any) *Chain {
+	if c.err != nil {
+		return c
+	}
+	fnType := reflect.TypeOf(fn)
+	if fnType == nil || fnType.Kind() != reflect.Func || fnType.NumIn() == 0 || fnType.In(0) != contextType {
+		c.err = &FlowError{Kind: ErrMissingContextErr, Message: fmt.Sprintf("%s: %s", ErrMissingContext, name)}
+		return c
+	}
+	c.Add(name, fn)
+	t := c.handlers[len(c.handlers)-1]
+	t.argTypes = t.argTypes[1:]
+	t.withContext = true
+	return c
+}
+
+func (c *Chain) AddFrom(name string, sources []string, fn any) *Chain {
+	if c.err != nil {
+		return c
+	}
+	for _, source := range sources {
+		if _, ok := c.stepNames[source]; !ok {
+			c.err = &FlowError{Kind: ErrStepNotFoundErr, Message: fmt.Sprintf("%s: %s (source of %s)", ErrStepNotFound, source, name)}
+			return c
+		}
+	}
+	c.Add(name, fn)
+	c.handlers[len(c.handlers)-1].sources = append([]string(nil), sources...)
+	return c
+}
+
+func (c *Chain) Parallel(name string, fns map[string]any) *Chain {
+	if c.err != nil {
+		return c
+	}
+	group := make([]*task, 0, len(fns))
+	for _, key := range slices.Sorted(maps.Keys(fns)) {
+		fnValue := reflect.ValueOf(fns[key])
+		if fnValue.Kind() != reflect.Func {
+			c.err = &FlowError{Kind: ErrNotFunctionErr, Message: fmt.Sprintf("%s: %s.%s", ErrNotFunction, name, key)}
+			return c
+		}
+		fnType := fnValue.Type()
+		t := &task{name: key, fnValue: fnValue}
+		for i := range fnType.NumIn() {
+			t.argTypes = append(t.argTypes, fnType.In(i))
+		}
+		if len(t.argTypes) > 0 && t.argTypes[0] == contextType {
+			t.argTypes = t.argTypes[1:]
+			t.withContext = true
+		}
+		group = append(group, t)
+	}
+	c.stepNames[name] = len(c.handlers)
+	c.handlers = append(c.handlers, &task{name: name, group: group})
+	return c
+}
+
+func (c *Chain) parallelStep(ctx context.Context, group []*task) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	results := make(map[string]any, len(group))
+	for _, t := range group {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			branch := &Chain{}
+			var values []reflect.Value
+			if t.withContext {
+				values = branch.invoke(t.fnValue, t.argTypes, c.values, reflect.ValueOf(ctx))
+			} else {
+				values = branch.invoke(t.fnValue, t.argTypes, c.values)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if branch.err != nil {
+				if firstErr == nil {
+					firstErr = branch.err
+					cancel()
+				}
+				return
+			}
+			fnType := t.fnValue.Type()
+			outCount := fnType.NumOut()
+			if outCount > 0 && fnType.Out(outCount-1).Implements(errorType) {
+				outCount--
+			}
+			switch outCount {
+			case 0:
+				results[t.name] = nil
+			case 1:
+				results[t.name] = values[0].Interface()
+			default:
+				outputs := make([]any, len(values))
+				for i, v := range values {
+					outputs[i] = v.Interface()
+				}
+				results[t.name] = outputs
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		c.err = firstErr
+		return
+	}
+	c.values = []reflect.Value{reflect.ValueOf(results)}
+}
+
+func (c *Chain) sourceValues(sources []string) []reflect.Value {
+	values := make([]reflect.Value, 0, len(sources))
+	for _, source := range sources {
+		values = append(values, c.handlers[c.stepNames[source]].values...)
+	}
+	return values
+}
+
+func (c *Chain) AddRecoverable(name string, fn any, recoverFn RecoverFunc) *Chain {
+	c.Add(name, fn)
+	if c.err != nil {
+		return c
+	}
+	c.handlers[len(c.handlers)-1].recoverFn = recoverFn
+	return c
+}
+
+func (c *Chain) Catch(name string, handler CatchFunc) *Chain {
+	if c.err != nil {
+		return c
+	}
+	c.stepNames[name] = len(c.handlers)
+	c.handlers = append(c.handlers, &task{name: name, catchFn: handler})
+	return c
+}
+
+func (c *Chain) nextCatch(after int) int {
+	for i := after + 1; i < len(c.handlers); i++ {
+		if c.handlers[i].catchFn != nil {
+			return i
+		}
+	}
+	return -1
+}
+
+func (c *Chain) catchStep(t *task) {
+	value, err := t.catchFn(c.err)
+	if err != nil {
+		c.err = err
+		return
+	}
+	c.err = nil
+	c.values = nil
+	if value != nil {
+		rv := reflect.ValueOf(value)
+		c.handleNonFunctionType(rv, rv.Type())
+	}
+}
+
 func (c *Chain) Run() error {
 	if c.err != nil {
 		return c.err
@@ -76,26 +287,65 @@ func (c *Chain) RunWithContext(ctx context.Context) error {
 	if c.err != nil {
 		return c.err
 	}
-	for i := range c.handlers {
-		if !c.handlers[i].do {
-			select {
-			case <-ctx.Done():
-				c.err = &FlowError{Message: fmt.Sprintf("execution canceled: %v", ctx.Err())}
-				return c.err
-			default:
-			}
+	if err := c.seedError(); err != nil {
+		c.err = err
+		return err
+	}
+	if c.seed != nil && len(c.handlers) > 0 && !c.handlers[0].do {
+		c.values = c.seed
+	}
+	for i := 0; i < len(c.handlers); i++ {
+		if c.handlers[i].do {
+			c.values = c.handlers[i].values
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			c.err = canceledError(ctx)
+			return c.err
+		default:
+		}
+		if c.pauseSignal != nil && c.pauseSignal.ShouldPause() {
+			c.pausedAt = i
+			return ErrFlowPaused
+		}
+		if c.handlers[i].catchFn != nil {
+			c.handlers[i].do = true
+			c.handlers[i].values = c.values
+			continue
+		}
+		if c.handlers[i].sources != nil {
+			c.values = c.sourceValues(c.handlers[i].sources)
+		}
+		if c.handlers[i].group != nil {
+			c.parallelStep(ctx, c.handlers[i].group)
+		} else if c.handlers[i].withContext {
+			c.values = c.invoke(c.handlers[i].fnValue, c.handlers[i].argTypes, c.values, reflect.ValueOf(ctx))
+		} else {
 			c.values = c.call(c.handlers[i].fnValue, c.handlers[i].argTypes, c.values)
-			if c.err != nil {
+		}
+		if c.err != nil && c.handlers[i].recoverFn != nil {
+			c.recoverStep(c.handlers[i])
+		}
+		for c.err != nil {
+			next := c.nextCatch(i)
+			if next < 0 {
 				return c.err
 			}
-			c.handlers[i].do = true
+			i = next
+			c.catchStep(c.handlers[i])
 		}
+		c.handlers[i].do = true
 		c.handlers[i].values = c.values
 	}
 	return c.err
 }
 
 func (c *Chain) call(fnValue reflect.Value, argTypes []reflect.Type, values []reflect.Value) []reflect.Value {
+	return c.invoke(fnValue, argTypes, values)
+}
+
+func (c *Chain) invoke(fnValue reflect.Value, argTypes []reflect.Type, values []reflect.Value, prefix ...reflect.Value) []reflect.Value {
 	if c.err != nil {
 		return values
 	}
@@ -111,12 +361,15 @@ func (c *Chain) call(fnValue reflect.Value, argTypes []reflect.Type, values []re
 		c.err = err
 		return values
 	}
+	if len(prefix) > 0 {
+		args = append(prefix, args...)
+	}
 
 	var results []reflect.Value
 	func() {
 		defer func() {
 			if r := recover(); r != nil {
-				c.err = &FlowError{Message: fmt.Sprintf("%v: %v (fnType: %v, args: %v)", ErrFunctionPanicked, r, fnType, args)}
+				c.err = &FlowError{Kind: ErrFunctionPanickedErr, Message: fmt.Sprintf("%v: %v (fnType: %v, args: %v)", ErrFunctionPanicked, r, fnType, args)}
 			}
 		}()
 		results = fnValue.Call(args)
@@ -130,7 +383,7 @@ func (c *Chain) call(fnValue reflect.Value, argTypes []reflect.Type, values []re
 
 	outCount := fnType.NumOut()
 	if len(results) > outCount {
-		c.err = &FlowError{Message: ErrFunctionPanicked}
+		c.err = &FlowError{Kind: ErrFunctionPanickedErr, Message: ErrFunctionPanicked}
 		return values
 	}
 
@@ -165,6 +418,18 @@ func (c *Chain) call(fnValue reflect.Value, argTypes []reflect.Type, values []re
 	return newValues
 }
 
+func (c *Chain) recoverStep(t *task) {
+	continueVals, ok := t.recoverFn(c.err)
+	if !ok {
+		return
+	}
+	c.err = nil
+	c.values = make([]reflect.Value, len(continueVals))
+	for i, v := range continueVals {
+		c.values[i] = reflect.ValueOf(v)
+	}
+}
+
 func (c *Chain) handleNonFunctionType(value reflect.Value, valueType reflect.Type) {
 	if valueType.Kind() == reflect.Slice || valueType.Kind() == reflect.Array {
 		c.values = make([]reflect.Value, value.Len())
@@ -190,7 +455,25 @@ func (c *Chain) Values(name string) ([]any, error) {
 			return values, nil
 		}
 	}
-	return nil, &FlowError{Message: ErrStepNotFound}
+	return nil, &FlowError{Kind: ErrStepNotFoundErr, Message: ErrStepNotFound}
+}
+
+func (c *Chain) AllValues() map[string][]any {
+	all := make(map[string][]any, len(c.stepNames))
+	for _, name := range c.StepOrder() {
+		all[name], _ = c.Values(name)
+	}
+	return all
+}
+
+func (c *Chain) StepOrder() []string {
+	names := make([]string, 0, len(c.stepNames))
+	for i, t := range c.handlers {
+		if c.stepNames[t.name] == i {
+			names = append(names, t.name)
+		}
+	}
+	return names
 }
 
 func (c *Chain) Value(name string) (any, error) {
@@ -201,13 +484,70 @@ func (c *Chain) Value(name string) (any, error) {
 			}
 		}
 	}
-	return nil, &FlowError{Message: ErrStepNotFound}
+	return nil, &FlowError{Kind: ErrStepNotFoundErr, Message: ErrStepNotFound}
+}
+
+func ChainValueAs[T any](c *Chain, name string) (T, error) {
+	var zero T
+
+	idx, ok := c.stepNames[name]
+	if !ok || idx >= len(c.handlers) {
+		return zero, &FlowError{Kind: ErrStepNotFoundErr, Message: fmt.Sprintf("%s: %s", ErrStepNotFound, name)}
+	}
+	if len(c.handlers[idx].values) == 0 {
+		return zero, &FlowError{Kind: ErrNoResultErr, Message: fmt.Sprintf("%s: %s", ErrNoResult, name)}
+	}
+	raw := c.handlers[idx].values[0].Interface()
+	value, ok := raw.(T)
+	if !ok {
+		return zero, &FlowError{Kind: ErrResultTypeErr, Message: fmt.Sprintf("%s: %s is %T, not %T", ErrResultType, name, raw, zero)}
+	}
+	return value, nil
 }
 
 func (c *Chain) Error() error {
 	return c.err
 }
 
+func (c *Chain) CheckpointError() error {
+	return c.restoredErr
+}
+
+func (c *Chain) Reset() {
+	c.err = nil
+	c.restoredErr = nil
+	c.pausedAt = -1
+	c.values = c.values[:0]
+	for _, t := range c.handlers {
+		t.do = false
+		t.values = nil
+	}
+}
+
+func (c *Chain) SetPauseSignal(signal PauseSignal) {
+	c.pauseSignal = signal
+}
+
+func (c *Chain) GetPausedAtStep() string {
+	if c.pausedAt < 0 || c.pausedAt >= len(c.handlers) {
+		return ""
+	}
+	return c.handlers[c.pausedAt].name
+}
+
+func (c *Chain) Resume() error {
+	return c.ResumeWithContext(context.Background())
+}
+
+func (c *Chain) ResumeWithContext(ctx context.Context) error {
+	c.pausedAt = -1
+	c.err = nil
+	if c.pauseSignal != nil {
+		c.pauseSignal.Reset()
+	}
+	return c.RunWithContext(ctx)
+}
+
 func (c *Chain) Use(names ...string) *Chain {
 	if c.err != nil {
 		return c
@@ -218,11 +558,12 @@ func (c *Chain) Use(names ...string) *Chain {
 		values:    make([]reflect.Value, 0),
 		stepNames: make(map[string]int),
 		handlers:  make([]*task, 0),
+		pausedAt:  -1,
 	}
 
 	for _, name := range names {
 		if idx, ok := c.stepNames[name]; !ok {
-			c.err = &FlowError{Message: ErrStepNotFound}
+			c.err = &FlowError{Kind: ErrStepNotFoundErr, Message: ErrStepNotFound}
 			return c
 		} else {
 			newChain.values = append(newChain.values, c.handlers[idx].values...)
@@ -0,0 +1,155 @@
+package flow
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+func (c *Chain) SaveCheckpoint() (*Checkpoint, error) {
+	checkpoint := NewCheckpoint(CheckpointTypeChain)
+
+	steps := make([]StepState, 0, len(c.handlers))
+	executed := make([]string, 0)
+	pending := make([]string, 0)
+
+	for _, t := range c.handlers {
+		step := StepState{
+			Name:   t.name,
+			Status: int(NodeStatusPending),
+		}
+
+		if t.do {
+			result := make([]any, len(t.values))
+			for i, value := range t.values {
+				result[i] = value.Interface()
+			}
+			if _, err := json.Marshal(result); err == nil {
+				step.Status = int(NodeStatusCompleted)
+				step.Executed = true
+				step.Result = result
+			}
+		}
+
+		if step.Executed {
+			executed = append(executed, t.name)
+		} else {
+			pending = append(pending, t.name)
+		}
+		steps = append(steps, step)
+	}
+
+	checkpoint.Data.Steps = steps
+	checkpoint.Data.Current = len(executed) - 1
+	checkpoint.Data.Extra = map[string]any{
+		"executed":       executed,
+		"pending":        pending,
+		"paused_at_step": c.GetPausedAtStep(),
+	}
+
+	switch {
+	case c.err != nil:
+		checkpoint.Data.Error = c.err.Error()
+		checkpoint.State = FlowStateFailed
+	case len(pending) == 0:
+		checkpoint.State = FlowStateCompleted
+	case len(executed) > 0:
+		checkpoint.State = FlowStatePaused
+	}
+
+	return checkpoint, nil
+}
+
+func (c *Chain) LoadCheckpoint(checkpoint *Checkpoint) error {
+	if checkpoint.Type != CheckpointTypeChain {
+		return ErrCheckpointInvalidType
+	}
+
+	data := checkpoint.Data
+
+	for _, step := range data.Steps {
+		idx, ok := c.stepNames[step.Name]
+		if !ok {
+			continue
+		}
+		t := c.handlers[idx]
+		t.do = false
+		if !step.Executed {
+			continue
+		}
+		if values, ok := decodeStepValues(t, step.Result); ok {
+			t.values = values
+			t.do = true
+		}
+	}
+
+	c.pausedAt = -1
+	if data.Extra != nil {
+		if name, ok := data.Extra["paused_at_step"].(string); ok {
+			if idx, ok := c.stepNames[name]; ok {
+				c.pausedAt = idx
+			}
+		}
+	}
+
+	c.err = nil
+	c.restoredErr = nil
+	if data.Error != "" {
+		c.restoredErr = &FlowError{Kind: ErrExecutionFailedErr, Message: data.Error}
+	}
+
+	return nil
+}
+
+func decodeStepValues(t *task, results []any) ([]reflect.Value, bool) {
+	var outTypes []reflect.Type
+	if t.fnValue.Kind() == reflect.Func {
+		fnType := t.fnValue.Type()
+		for i := range fnType.NumOut() {
+			if out := fnType.Out(i); !out.Implements(errorType) {
+				outTypes = append(outTypes, out)
+			}
+		}
+	}
+
+	values := make([]reflect.Value, len(results))
+	for i, result := range results {
+		if len(outTypes) != len(results) || result == nil {
+			values[i] = reflect.ValueOf(result)
+			continue
+		}
+
+		targetType := outTypes[i]
+		resultVal := reflect.ValueOf(result)
+		if resultVal.Type().AssignableTo(targetType) {
+			values[i] = resultVal
+			continue
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return nil, false
+		}
+		target := reflect.New(targetType)
+		if err := json.Unmarshal(data, target.Interface()); err != nil {
+			return nil, false
+		}
+		values[i] = target.Elem()
+	}
+	return values, true
+}
+
+func (c *Chain) SaveToStore(store CheckpointStore, key string) error {
+	checkpoint, err := c.SaveCheckpoint()
+	if err != nil {
+		return err
+	}
+	return store.Save(key, checkpoint)
+}
+
+func (c *Chain) LoadFromStore(store CheckpointStore, key string) error {
+	checkpoint, err := store.Load(key)
+	if err != nil {
+		return err
+	}
+	return c.LoadCheckpoint(checkpoint)
+}
@@ -1,10 +1,15 @@
 package flow
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 const (
@@ -228,7 +233,7 @@ func TestChainStepNotFound(t *testing.T) {
 		t.Fatalf("Expected error for non-existent step")
 	}
 
-	if err.Error() != ErrStepNotFound {
+	if !errors.Is(err, ErrStepNotFoundErr) {
 		t.Errorf("Expected '%s', got '%v'", ErrStepNotFound, err.Error())
 	}
 }
@@ -278,7 +283,7 @@ func TestChainArgCountMismatch(t *testing.T) {
 		t.Fatalf("Expected error for argument count mismatch")
 	}
 
-	if err.Error() != ErrArgCountMismatch {
+	if !errors.Is(err, ErrArgCountMismatchErr) {
 		t.Errorf("Expected '%s', got '%v'", ErrArgCountMismatch, err.Error())
 	}
 }
@@ -299,7 +304,7 @@ func TestChainArgTypeMismatch(t *testing.T) {
 		t.Fatalf("Expected error for argument type mismatch")
 	}
 
-	if err.Error() != ErrArgTypeMismatch {
+	if !errors.Is(err, ErrArgTypeMismatchErr) {
 		t.Errorf("Expected '%s', got '%v'", ErrArgTypeMismatch, err.Error())
 	}
 }
@@ -433,7 +438,7 @@ func TestChainUseWithNonExistentSteps(t *testing.T) {
 		t.Fatalf("Expected error for nonexistent step")
 	}
 
-	if newChain.err.Error() != ErrStepNotFound {
+	if !errors.Is(newChain.err, ErrStepNotFoundErr) {
 		t.Errorf("Expected '%s', got '%v'", ErrStepNotFound, newChain.err.Error())
 	}
 }
@@ -1701,3 +1706,709 @@ func TestChainWithBoolReturn(t *testing.T) {
 		t.Errorf("Expected true, got %v", value)
 	}
 }
+
+func TestChainValueAs(t *testing.T) {
+	chain := NewChain()
+	chain.Add("step1", func() int { return 10 })
+	chain.Add("step2", func(x int) string { return fmt.Sprintf("%d", x*2) })
+	chain.Add("step3", func(s string) {})
+
+	if err := chain.Run(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	value, err := ChainValueAs[string](chain, "step2")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if value != "20" {
+		t.Errorf("Expected \"20\", got %q", value)
+	}
+
+	if _, err := ChainValueAs[int](chain, "step2"); err == nil || !strings.Contains(err.Error(), ErrResultType) {
+		t.Errorf("Expected type mismatch error, got %v", err)
+	}
+
+	if _, err := ChainValueAs[int](chain, "nonexistent"); err == nil || !strings.Contains(err.Error(), ErrStepNotFound) {
+		t.Errorf("Expected step not found error, got %v", err)
+	}
+}
+
+func TestChainAddRecoverable(t *testing.T) {
+	parse := func(s string) (int, error) {
+		if s == "bad" {
+			return 0, fmt.Errorf("invalid record %q", s)
+		}
+		return len(s), nil
+	}
+
+	t.Run("RecoverAndContinue", func(t *testing.T) {
+		chain := NewChain()
+		chain.Add("input", func() string { return "bad" })
+		chain.AddRecoverable("parse", parse, func(err error) ([]any, bool) {
+			return []any{-1}, true
+		})
+		chain.Add("double", func(n int) int { return n * 2 })
+
+		if err := chain.Run(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		value, _ := chain.Value("parse")
+		if value.(int) != -1 {
+			t.Errorf("Expected -1, got %v", value)
+		}
+		value, _ = chain.Value("double")
+		if value.(int) != -2 {
+			t.Errorf("Expected -2, got %v", value)
+		}
+	})
+
+	t.Run("RecoverDeclined", func(t *testing.T) {
+		called := false
+		chain := NewChain()
+		chain.Add("input", func() string { return "bad" })
+		chain.AddRecoverable("parse", parse, func(err error) ([]any, bool) {
+			return nil, false
+		})
+		chain.Add("double", func(n int) int {
+			called = true
+			return n * 2
+		})
+
+		err := chain.Run()
+		if err == nil || !strings.Contains(err.Error(), "invalid record") {
+			t.Fatalf("Expected invalid record error, got %v", err)
+		}
+		if called {
+			t.Error("Expected double not to run")
+		}
+	})
+
+	t.Run("NoError", func(t *testing.T) {
+		chain := NewChain()
+		chain.Add("input", func() string { return "good" })
+		chain.AddRecoverable("parse", parse, func(err error) ([]any, bool) {
+			t.Error("Recover should not be called")
+			return nil, false
+		})
+
+		if err := chain.Run(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		value, _ := chain.Value("parse")
+		if value.(int) != 4 {
+			t.Errorf("Expected 4, got %v", value)
+		}
+	})
+}
+
+func TestChainAddCtx(t *testing.T) {
+	t.Run("PassesRunContext", func(t *testing.T) {
+		type key struct{}
+		ctx := context.WithValue(context.Background(), key{}, "req-1")
+
+		chain := NewChain()
+		chain.Add("url", func() string { return "https://example.com" })
+		chain.AddCtx("fetch", func(ctx context.Context, url string) ([]byte, error) {
+			return []byte(fmt.Sprintf("%v %s", ctx.Value(key{}), url)), nil
+		})
+		chain.Add("size", func(b []byte) int { return len(b) })
+
+		if err := chain.RunWithContext(ctx); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		value, _ := chain.Value("fetch")
+		if string(value.([]byte)) != "req-1 https://example.com" {
+			t.Errorf("Expected context value in result, got %s", value)
+		}
+		value, _ = chain.Value("size")
+		if value.(int) != 25 {
+			t.Errorf("Expected 25, got %v", value)
+		}
+	})
+
+	t.Run("CanceledMidStep", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		chain := NewChain()
+		chain.AddCtx("slow", func(ctx context.Context) error {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Second):
+				return nil
+			}
+		})
+
+		err := chain.RunWithContext(ctx)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Expected deadline exceeded, got %v", err)
+		}
+	})
+
+	t.Run("MissingContextParam", func(t *testing.T) {
+		chain := NewChain()
+		chain.AddCtx("bad", func(n int) int { return n })
+		if err := chain.Error(); err == nil || !strings.Contains(err.Error(), ErrMissingContext) {
+			t.Fatalf("Expected missing context error, got %v", err)
+		}
+	})
+}
+
+func TestTypedChain(t *testing.T) {
+	chain := NewTypedChain(10).
+		Add("double", func(x int) int { return x * 2 }).
+		Add("add", func(y int) int { return y + 5 })
+
+	if _, err := chain.Value("add"); err == nil {
+		t.Error("Expected error before run")
+	}
+
+	result, err := chain.Run()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result != 25 {
+		t.Errorf("Expected 25, got %d", result)
+	}
+
+	value, err := chain.Value("double")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if value != 20 {
+		t.Errorf("Expected 20, got %d", value)
+	}
+
+	if _, err := chain.Value("nonexistent"); err == nil {
+		t.Error("Expected step not found error")
+	}
+
+	chain.Add("double", func(x int) int { return x })
+	if chain.Error() == nil {
+		t.Error("Expected duplicate step error")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	canceled := NewTypedChain("a").Add("upper", strings.ToUpper)
+	if _, err := canceled.RunWithContext(ctx); err == nil {
+		t.Error("Expected canceled error")
+	}
+}
+
+func TestChainAddFrom(t *testing.T) {
+	t.Run("ExplicitSources", func(t *testing.T) {
+		chain := NewChain()
+		chain.Add("name", func() string { return "alice" })
+		chain.Add("age", func(name string) int { return len(name) * 6 })
+		chain.Add("score", func(age int) float64 { return float64(age) / 2 })
+		chain.AddFrom("summary", []string{"name", "score"}, func(name string, score float64) string {
+			return fmt.Sprintf("%s:%.1f", name, score)
+		})
+		chain.Add("upper", strings.ToUpper)
+
+		if err := chain.Run(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		value, _ := chain.Value("summary")
+		if value.(string) != "alice:15.0" {
+			t.Errorf("Expected alice:15.0, got %v", value)
+		}
+		value, _ = chain.Value("upper")
+		if value.(string) != "ALICE:15.0" {
+			t.Errorf("Expected ALICE:15.0, got %v", value)
+		}
+	})
+
+	t.Run("MultipleValues", func(t *testing.T) {
+		chain := NewChain()
+		chain.Add("pair", func() (int, int) { return 2, 3 })
+		chain.Add("sum", func(a, b int) int { return a + b })
+		chain.AddFrom("combine", []string{"pair", "sum"}, func(a, b, sum int) int { return a * b * sum })
+
+		if err := chain.Run(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		value, _ := chain.Value("combine")
+		if value.(int) != 30 {
+			t.Errorf("Expected 30, got %v", value)
+		}
+	})
+
+	t.Run("UnknownSource", func(t *testing.T) {
+		chain := NewChain()
+		chain.Add("first", func() int { return 1 })
+		chain.AddFrom("second", []string{"later"}, func(n int) int { return n })
+		chain.Add("later", func(n int) int { return n })
+
+		err := chain.Run()
+		if err == nil {
+			t.Fatal("Expected error for source declared after the step")
+		}
+		if !strings.Contains(err.Error(), "later") {
+			t.Errorf("Expected error to mention the missing source, got: %v", err)
+		}
+	})
+}
+
+func TestChainReset(t *testing.T) {
+	calls := 0
+	base := 1
+
+	chain := NewChain()
+	chain.Add("load", func() int {
+		calls++
+		return base
+	})
+	chain.Add("double", func(n int) int { return n * 2 })
+
+	if err := chain.Run(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := chain.Run(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected cached run to skip steps, got %d calls", calls)
+	}
+
+	base = 5
+	chain.Reset()
+	if _, err := chain.Value("double"); err == nil {
+		t.Error("Expected no value after reset")
+	}
+	if err := chain.Run(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected steps to run again after reset, got %d calls", calls)
+	}
+	value, _ := chain.Value("double")
+	if value.(int) != 10 {
+		t.Errorf("Expected 10, got %v", value)
+	}
+
+	t.Run("ClearsError", func(t *testing.T) {
+		fail := true
+		chain := NewChain()
+		chain.Add("step", func() (int, error) {
+			if fail {
+				return 0, errors.New(testErrorMsg)
+			}
+			return 1, nil
+		})
+
+		if err := chain.Run(); err == nil {
+			t.Fatal("Expected error")
+		}
+		fail = false
+		chain.Reset()
+		if err := chain.Run(); err != nil {
+			t.Fatalf("Unexpected error after reset: %v", err)
+		}
+	})
+}
+
+func TestStreamChain(t *testing.T) {
+	t.Run("Pipeline", func(t *testing.T) {
+		items := make([]int, 100)
+		for i := range items {
+			items[i] = i + 1
+		}
+
+		chain := NewStreamChain(items)
+		chain.Add("evens", func(in <-chan int) <-chan int {
+			out := make(chan int)
+			go func() {
+				defer close(out)
+				for n := range in {
+					if n%2 == 0 {
+						out <- n
+					}
+				}
+			}()
+			return out
+		})
+		chain.Add("square", func(n int) int { return n * n })
+		chain.Add("format", func(n int) (string, error) { return strconv.Itoa(n), nil })
+
+		if err := chain.Run(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		results := chain.Results()
+		if len(results) != 50 {
+			t.Fatalf("Expected 50 results, got %d", len(results))
+		}
+		if results[0] != "4" || results[49] != "10000" {
+			t.Errorf("Expected ordered results, got first=%v last=%v", results[0], results[49])
+		}
+	})
+
+	t.Run("ErrorCancelsStages", func(t *testing.T) {
+		source := make(chan int)
+		go func() {
+			defer close(source)
+			for i := range 1000 {
+				source <- i
+			}
+		}()
+
+		chain := NewStreamChain(source).SetBufferSize(1)
+		chain.Add("check", func(n int) (int, error) {
+			if n == 5 {
+				return 0, errors.New("bad item")
+			}
+			return n, nil
+		})
+		chain.Add("sink", func(n int) {})
+
+		err := chain.Run()
+		if err == nil || !strings.Contains(err.Error(), "stage check failed: bad item") {
+			t.Fatalf("Expected stage error, got %v", err)
+		}
+	})
+
+	t.Run("Backpressure", func(t *testing.T) {
+		var produced, consumed, maxLead int32
+		chain := NewStreamChain(make([]int, 50)).SetBufferSize(1)
+		chain.Add("produce", func(n int) int {
+			p := atomic.AddInt32(&produced, 1)
+			if lead := p - atomic.LoadInt32(&consumed); lead > atomic.LoadInt32(&maxLead) {
+				atomic.StoreInt32(&maxLead, lead)
+			}
+			return n
+		})
+		chain.Add("consume", func(n int) {
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&consumed, 1)
+		})
+
+		if err := chain.Run(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if consumed != 50 {
+			t.Errorf("Expected 50 consumed items, got %d", consumed)
+		}
+		if maxLead > 6 {
+			t.Errorf("Expected bounded lead between stages, got %d", maxLead)
+		}
+	})
+
+	t.Run("ContextCanceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		chain := NewStreamChain(make([]int, 1000)).SetBufferSize(0)
+		chain.Add("slow", func(n int) int {
+			cancel()
+			return n
+		})
+
+		if err := chain.RunWithContext(ctx); err == nil {
+			t.Fatal("Expected cancellation error")
+		}
+	})
+
+	t.Run("InvalidStages", func(t *testing.T) {
+		if err := NewStreamChain(42).Run(); err == nil {
+			t.Error("Expected invalid source error")
+		}
+		if err := NewStreamChain([]int{1}).Add("bad", func(a, b int) int { return a }).Run(); err == nil {
+			t.Error("Expected invalid stage error")
+		}
+		if err := NewStreamChain([]int{1}).Add("str", func(s []string) int { return 0 }).Run(); err == nil {
+			t.Error("Expected type mismatch error")
+		}
+		if err := NewStreamChain([]int{1}).Add("sink", func(int) {}).Add("after", func(n int) int { return n }).Run(); err == nil {
+			t.Error("Expected error for stage after sink")
+		}
+	})
+}
+
+func TestChainMapFilter(t *testing.T) {
+	chain := NewChain()
+	chain.Add("load", func() []int { return []int{1, 2, 3, 4, 5, 6} })
+	chain.Filter("even", func(n int) bool { return n%2 == 0 })
+	chain.Map("label", func(n int) string { return "#" + strconv.Itoa(n) })
+	assertNoError(t, chain.Run())
+
+	even, err := ChainValueAs[[]int](chain, "even")
+	assertNoError(t, err)
+	assertEqual(t, []int{2, 4, 6}, even)
+
+	labels, err := ChainValueAs[[]string](chain, "label")
+	assertNoError(t, err)
+	assertEqual(t, []string{"#2", "#4", "#6"}, labels)
+
+	errDivision := errors.New("division by zero")
+	failing := NewChain()
+	failing.Add("load", func() []int { return []int{1, 0} })
+	failing.Map("invert", func(n int) (int, error) {
+		if n == 0 {
+			return 0, errDivision
+		}
+		return 1 / n, nil
+	})
+	err = failing.Run()
+	assertError(t, err)
+	assertContains(t, err.Error(), "element 1")
+	if !errors.Is(err, errDivision) {
+		t.Errorf("Expected element error to match its cause, got %v", err)
+	}
+
+	scalar := NewChain()
+	scalar.Add("load", func() int { return 1 })
+	scalar.Map("double", func(n int) int { return n * 2 })
+	assertError(t, scalar.Run())
+
+	invalid := NewChain().Filter("bad", func(n int) int { return n })
+	assertError(t, invalid.Error())
+	assertContains(t, invalid.Error().Error(), ErrInvalidFilterFunc)
+
+	for _, pred := range []any{nil, true, (func(int) bool)(nil)} {
+		invalid := NewChain().Filter("bad", pred)
+		if !errors.Is(invalid.Error(), ErrInvalidFilterFuncErr) {
+			t.Errorf("Expected invalid filter func error for %T, got %v", pred, invalid.Error())
+		}
+	}
+
+	for _, fn := range []any{nil, 1, (func(int) int)(nil)} {
+		invalid := NewChain().Map("bad", fn)
+		if !errors.Is(invalid.Error(), ErrInvalidMapFuncErr) {
+			t.Errorf("Expected invalid map func error for %T, got %v", fn, invalid.Error())
+		}
+	}
+}
+
+func TestChainPauseResume(t *testing.T) {
+	signal := NewSimplePauseSignal()
+	var shipped atomic.Int32
+
+	chain := NewChain()
+	chain.SetPauseSignal(signal)
+	chain.Add("build", func() int { return 20 })
+	chain.Add("approve", func(n int) int {
+		signal.SetPaused(true)
+		return n + 1
+	})
+	chain.Add("ship", func(n int) int {
+		shipped.Add(1)
+		return n * 2
+	})
+
+	err := chain.Run()
+	if !errors.Is(err, ErrFlowPaused) {
+		t.Fatalf("expected ErrFlowPaused, got %v", err)
+	}
+	assertEqual(t, "ship", chain.GetPausedAtStep())
+	assertEqual(t, int32(0), shipped.Load())
+
+	assertNoError(t, chain.Resume())
+	assertEqual(t, "", chain.GetPausedAtStep())
+	assertEqual(t, int32(1), shipped.Load())
+
+	result, err := ChainValueAs[int](chain, "ship")
+	assertNoError(t, err)
+	assertEqual(t, 42, result)
+
+	approved, err := ChainValueAs[int](chain, "approve")
+	assertNoError(t, err)
+	assertEqual(t, 21, approved)
+}
+
+func TestChainCatch(t *testing.T) {
+	parse := func(s string) (int, error) {
+		if s == "bad" {
+			return 0, fmt.Errorf("invalid record %q", s)
+		}
+		return len(s), nil
+	}
+
+	t.Run("Recover", func(t *testing.T) {
+		skipped := false
+		chain := NewChain()
+		chain.Add("input", func() string { return "bad" })
+		chain.Add("parse", parse)
+		chain.Add("square", func(n int) int {
+			skipped = true
+			return n * n
+		})
+		chain.Catch("fallback", func(err error) (any, error) {
+			return 0, nil
+		})
+		chain.Add("increment", func(n int) int { return n + 1 })
+
+		if err := chain.Run(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if skipped {
+			t.Error("Expected square to be skipped")
+		}
+		value, _ := chain.Value("increment")
+		if value.(int) != 1 {
+			t.Errorf("Expected 1, got %v", value)
+		}
+	})
+
+	t.Run("Reraise", func(t *testing.T) {
+		var seen []string
+		chain := NewChain()
+		chain.Add("input", func() string { return "bad" })
+		chain.Add("parse", parse)
+		chain.Catch("wrap", func(err error) (any, error) {
+			seen = append(seen, "wrap")
+			return nil, fmt.Errorf("parse step: %w", err)
+		})
+		chain.Add("double", func(n int) int { return n * 2 })
+		chain.Catch("last", func(err error) (any, error) {
+			seen = append(seen, "last")
+			return nil, err
+		})
+
+		err := chain.Run()
+		if err == nil || !strings.Contains(err.Error(), "parse step: invalid record") {
+			t.Fatalf("Expected wrapped error, got %v", err)
+		}
+		if strings.Join(seen, ",") != "wrap,last" {
+			t.Errorf("Expected both handlers to run, got %v", seen)
+		}
+	})
+
+	t.Run("NoError", func(t *testing.T) {
+		called := false
+		chain := NewChain()
+		chain.Add("input", func() string { return "good" })
+		chain.Add("parse", parse)
+		chain.Catch("fallback", func(err error) (any, error) {
+			called = true
+			return 0, nil
+		})
+		chain.Add("double", func(n int) int { return n * 2 })
+
+		if err := chain.Run(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if called {
+			t.Error("Expected Catch handler not to run")
+		}
+		value, _ := chain.Value("double")
+		if value.(int) != 8 {
+			t.Errorf("Expected 8, got %v", value)
+		}
+	})
+}
+
+func TestChainSeed(t *testing.T) {
+	chain := NewChain()
+	chain.Add("sum", func(a, b int) int { return a + b })
+	chain.Add("double", func(n int) int { return n * 2 })
+
+	if err := chain.Seed(2, 3).Run(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	value, _ := chain.Value("double")
+	if value.(int) != 10 {
+		t.Errorf("Expected 10, got %v", value)
+	}
+
+	if err := chain.Seed(1, 1).Error(); !errors.Is(err, ErrSeedAfterRunErr) {
+		t.Fatalf("Expected seed after run error, got %v", err)
+	}
+
+	chain.Reset()
+	if err := chain.Seed(4, 5).Run(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	value, _ = chain.Value("double")
+	if value.(int) != 18 {
+		t.Errorf("Expected 18, got %v", value)
+	}
+
+	constant := NewChain().Add("value", 42).Seed(1)
+	if err := constant.Run(); !errors.Is(err, ErrSeedNotFunctionErr) {
+		t.Fatalf("Expected seed on non-function error, got %v", err)
+	}
+
+	late := NewChain().Seed(1)
+	late.Add("value", 42)
+	if err := late.Run(); !errors.Is(err, ErrSeedNotFunctionErr) {
+		t.Fatalf("Expected seed on non-function error, got %v", err)
+	}
+}
+
+func TestChainParallel(t *testing.T) {
+	chain := NewChain()
+	chain.Add("id", func() int { return 7 })
+	chain.Parallel("fetch", map[string]any{
+		"user":   func(id int) string { return fmt.Sprintf("user-%d", id) },
+		"orders": func(id int) (int, error) { return id * 2, nil },
+		"audit":  func(id int) {},
+	})
+	chain.Add("merge", func(results map[string]any) string {
+		return fmt.Sprintf("%v:%v:%v", results["user"], results["orders"], results["audit"])
+	})
+
+	if err := chain.Run(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	value, _ := chain.Value("merge")
+	if value != "user-7:14:<nil>" {
+		t.Errorf("Expected merged results, got %v", value)
+	}
+
+	failing := NewChain()
+	failing.Add("id", func() int { return 1 })
+	failing.Parallel("fetch", map[string]any{
+		"slow": func(ctx context.Context, id int) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+		"broken": func(id int) error { return errors.New("broken") },
+	})
+	failing.Add("merge", func(results map[string]any) {})
+	if err := failing.Run(); err == nil || err.Error() != "broken" {
+		t.Fatalf("Expected first error to propagate, got %v", err)
+	}
+
+	if err := NewChain().Parallel("bad", map[string]any{"x": 1}).Run(); !errors.Is(err, ErrNotFunctionErr) {
+		t.Fatalf("Expected not function error, got %v", err)
+	}
+}
+
+func TestChainAllValues(t *testing.T) {
+	chain := NewChain()
+	chain.Add("numbers", func() (int, int) { return 2, 3 })
+	chain.Add("sum", func(a, b int) int { return a + b })
+	chain.Add("label", func(n int) string { return fmt.Sprintf("total-%d", n) })
+
+	if err := chain.Run(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if order := chain.StepOrder(); !reflect.DeepEqual(order, []string{"numbers", "sum", "label"}) {
+		t.Errorf("Expected steps in order, got %v", order)
+	}
+	all := chain.AllValues()
+	expected := map[string][]any{
+		"numbers": {2, 3},
+		"sum":     {5},
+		"label":   {"total-5"},
+	}
+	if !reflect.DeepEqual(all, expected) {
+		t.Errorf("Expected %v, got %v", expected, all)
+	}
+
+	all["sum"][0] = 100
+	chain.Reset()
+	if err := chain.Run(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if value, _ := chain.Value("sum"); value != 5 {
+		t.Errorf("Expected snapshot changes not to affect the chain, got %v", value)
+	}
+	if all["label"][0] != "total-5" {
+		t.Errorf("Expected snapshot to survive another run, got %v", all["label"])
+	}
+}
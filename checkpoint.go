@@ -29,6 +29,8 @@ type CheckpointStore interface {
 	Load(key string) (*Checkpoint, error)
 	Delete(key string) error
 	List() ([]string, error)
+	Exists(key string) (bool, error)
+	Peek(key string) (map[string]string, error)
 }
 
 type Checkpoint struct {
@@ -37,8 +39,8 @@ type Checkpoint struct {
 	CreatedAt time.Time          `json:"created_at"`
 	Version   int                `json:"version"`
 	State     FlowState          `json:"state"`
-	Data      FlowCheckpointData `json:"data"`
 	Metadata  map[string]string  `json:"metadata,omitempty"`
+	Data      FlowCheckpointData `json:"data"`
 }
 
 type FlowState int
@@ -49,6 +51,7 @@ const (
 	FlowStatePaused
 	FlowStateCompleted
 	FlowStateFailed
+	FlowStateCanceled
 )
 
 const (
@@ -70,6 +73,7 @@ type StepState struct {
 	Name     string `json:"name"`
 	Status   int    `json:"status"`
 	Executed bool   `json:"executed"`
+	Result   []any  `json:"result,omitempty"`
 }
 
 func NewCheckpoint(flowType string) *Checkpoint {
@@ -179,6 +183,60 @@ func (s *FileCheckpointStore) List() ([]string, error) {
 	return keys, nil
 }
 
+func (s *FileCheckpointStore) Exists(key string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, err := os.Stat(s.filePath(key))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *FileCheckpointStore) Peek(key string) (map[string]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	f, err := os.Open(filepath.Clean(s.filePath(key)))
+	if os.IsNotExist(err) {
+		return nil, ErrCheckpointNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return peekMetadata(json.NewDecoder(f))
+}
+
+func peekMetadata(dec *json.Decoder) (map[string]string, error) {
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, ErrInvalidCheckpoint
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, ErrInvalidCheckpoint
+		}
+		if tok == "metadata" {
+			var metadata map[string]string
+			if err := dec.Decode(&metadata); err != nil {
+				return nil, ErrInvalidCheckpoint
+			}
+			return metadata, nil
+		}
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return nil, ErrInvalidCheckpoint
+		}
+	}
+	return nil, nil
+}
+
 func (s *FileCheckpointStore) filePath(key string) string {
 	return filepath.Join(s.dir, key+".json")
 }
@@ -236,3 +294,29 @@ func (s *MemoryCheckpointStore) List() ([]string, error) {
 	}
 	return keys, nil
 }
+
+func (s *MemoryCheckpointStore) Exists(key string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.data[key]
+	return ok, nil
+}
+
+func (s *MemoryCheckpointStore) Peek(key string) (map[string]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	checkpoint, ok := s.data[key]
+	if !ok {
+		return nil, ErrCheckpointNotFound
+	}
+	if checkpoint.Metadata == nil {
+		return nil, nil
+	}
+	metadata := make(map[string]string, len(checkpoint.Metadata))
+	for k, v := range checkpoint.Metadata {
+		metadata[k] = v
+	}
+	return metadata, nil
+}
@@ -0,0 +1,96 @@
+package flow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+const defaultRedisKeyPrefix = "flow:checkpoint:"
+
+type RedisClient interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte) error
+	Del(ctx context.Context, key string) (bool, error)
+	Keys(ctx context.Context, pattern string) ([]string, error)
+}
+
+type RedisCheckpointStore struct {
+	client RedisClient
+	prefix string
+}
+
+func NewRedisCheckpointStore(client RedisClient, prefix string) *RedisCheckpointStore {
+	if prefix == "" {
+		prefix = defaultRedisKeyPrefix
+	}
+	return &RedisCheckpointStore{client: client, prefix: prefix}
+}
+
+func (s *RedisCheckpointStore) Save(key string, checkpoint *Checkpoint) error {
+	checkpoint.ID = key
+	checkpoint.CreatedAt = time.Now()
+
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(context.Background(), s.prefix+key, data)
+}
+
+func (s *RedisCheckpointStore) Load(key string) (*Checkpoint, error) {
+	data, ok, err := s.client.Get(context.Background(), s.prefix+key)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrCheckpointNotFound
+	}
+
+	var checkpoint Checkpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, err
+	}
+	return &checkpoint, nil
+}
+
+func (s *RedisCheckpointStore) Delete(key string) error {
+	deleted, err := s.client.Del(context.Background(), s.prefix+key)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrCheckpointNotFound
+	}
+	return nil
+}
+
+func (s *RedisCheckpointStore) List() ([]string, error) {
+	keys, err := s.client.Keys(context.Background(), s.prefix+"*")
+	if err != nil {
+		return nil, err
+	}
+	result := make([]string, 0, len(keys))
+	for _, k := range keys {
+		result = append(result, strings.TrimPrefix(k, s.prefix))
+	}
+	return result, nil
+}
+
+func (s *RedisCheckpointStore) Exists(key string) (bool, error) {
+	_, ok, err := s.client.Get(context.Background(), s.prefix+key)
+	return ok, err
+}
+
+func (s *RedisCheckpointStore) Peek(key string) (map[string]string, error) {
+	data, ok, err := s.client.Get(context.Background(), s.prefix+key)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrCheckpointNotFound
+	}
+	return peekMetadata(json.NewDecoder(bytes.NewReader(data)))
+}
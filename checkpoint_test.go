@@ -5,6 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -309,6 +313,7 @@ func TestCheckpointMetadata(t *testing.T) {
 
 func TestUnifiedInterface(t *testing.T) {
 	var _ FlowCheckpointable = (*Graph)(nil)
+	var _ FlowCheckpointable = (*Chain)(nil)
 	var _ PausableFlow = (*Graph)(nil)
 }
 
@@ -1448,3 +1453,721 @@ func TestScenario_PauseOnError(t *testing.T) {
 		t.Errorf("expected [25], got %v", result)
 	}
 }
+
+func TestGraphCheckpointNodeMeta(t *testing.T) {
+	graph1 := NewGraph()
+	graph1.AddNode("start", func() int { return 10 }, WithMeta(map[string]string{"owner": "ops"}))
+	graph1.AddNode("double", func(n int) int { return n * 2 })
+	graph1.AddEdge("start", "double")
+
+	store, err := NewFileCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	if err := graph1.SaveToStore(store, "meta"); err != nil {
+		t.Fatalf("failed to save checkpoint: %v", err)
+	}
+
+	graph2 := NewGraph()
+	graph2.AddNode("start", func() int { return 10 })
+	graph2.AddNode("double", func(n int) int { return n * 2 })
+	graph2.AddEdge("start", "double")
+
+	if err := graph2.LoadFromStore(store, "meta"); err != nil {
+		t.Fatalf("failed to load checkpoint: %v", err)
+	}
+
+	meta, _ := graph2.NodeMeta("start")
+	if meta["owner"] != "ops" {
+		t.Errorf("expected owner 'ops', got %v", meta)
+	}
+	meta, _ = graph2.NodeMeta("double")
+	if meta != nil {
+		t.Errorf("expected no meta on double, got %v", meta)
+	}
+}
+
+type checkpointOrder struct {
+	ID    string
+	Total float64
+}
+
+func TestGraphCheckpointNodeResults(t *testing.T) {
+	store, err := NewFileCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+
+	build := func(loadCalls *int) *Graph {
+		graph := NewGraph()
+		graph.AddNode("load", func() checkpointOrder {
+			*loadCalls++
+			return checkpointOrder{ID: "o-1", Total: 12.5}
+		})
+		graph.AddNode("notify", func() chan int {
+			return make(chan int)
+		})
+		graph.AddNode("charge", func(o checkpointOrder) string {
+			return fmt.Sprintf("%s:%.1f", o.ID, o.Total)
+		})
+		graph.AddEdge("load", "charge")
+		return graph
+	}
+
+	var calls1 int
+	graph1 := build(&calls1)
+	graph1.SetPauseConfig(NewPauseConfig().SetPauseAtNodes("charge"))
+	if err := graph1.RunSequential(); err != ErrFlowPaused {
+		t.Fatalf("expected pause, got %v", err)
+	}
+	if err := graph1.SaveToStore(store, "results"); err != nil {
+		t.Fatalf("failed to save checkpoint: %v", err)
+	}
+
+	var calls2 int
+	graph2 := build(&calls2)
+	if err := graph2.LoadFromStore(store, "results"); err != nil {
+		t.Fatalf("failed to load checkpoint: %v", err)
+	}
+
+	result, _ := graph2.NodeResult("load")
+	if len(result) != 1 || result[0] != (checkpointOrder{ID: "o-1", Total: 12.5}) {
+		t.Fatalf("expected restored order, got %v", result)
+	}
+	if status, _ := graph2.NodeStatus("notify"); status != NodeStatusPending {
+		t.Errorf("expected non-serializable node to be pending, got %v", status)
+	}
+
+	if err := graph2.Resume(context.Background()); err != nil {
+		t.Fatalf("failed to resume: %v", err)
+	}
+	if calls2 != 0 {
+		t.Errorf("expected load not to re-run, ran %d times", calls2)
+	}
+	result, _ = graph2.NodeResult("charge")
+	if len(result) != 1 || result[0] != "o-1:12.5" {
+		t.Errorf("expected charge result 'o-1:12.5', got %v", result)
+	}
+	if status, _ := graph2.NodeStatus("notify"); status != NodeStatusCompleted {
+		t.Errorf("expected notify to re-execute, got %v", status)
+	}
+}
+
+func TestGraphAutoCheckpoint(t *testing.T) {
+	store := NewMemoryCheckpointStore()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	build := func(runs map[string]int, onStep3 func()) *Graph {
+		graph := NewGraph()
+		step := func(name string, hook func()) func(int) int {
+			return func(n int) int {
+				runs[name]++
+				if hook != nil {
+					hook()
+				}
+				return n + 1
+			}
+		}
+		graph.AddNode("step1", func() int { runs["step1"]++; return 0 })
+		graph.AddNode("step2", step("step2", nil))
+		graph.AddNode("step3", step("step3", onStep3))
+		graph.AddNode("step4", step("step4", nil))
+		graph.AddNode("step5", step("step5", nil))
+		graph.AddEdge("step1", "step2")
+		graph.AddEdge("step2", "step3")
+		graph.AddEdge("step3", "step4")
+		graph.AddEdge("step4", "step5")
+		return graph
+	}
+
+	runs1 := make(map[string]int)
+	graph1 := build(runs1, cancel)
+	graph1.EnableAutoCheckpoint(store, "auto", 1)
+	if err := graph1.RunSequentialWithContext(ctx); err == nil {
+		t.Fatal("expected canceled run")
+	}
+	if err := graph1.AutoCheckpointError(); err != nil {
+		t.Fatalf("unexpected auto checkpoint error: %v", err)
+	}
+
+	checkpoint, err := store.Load("auto")
+	if err != nil {
+		t.Fatalf("expected auto checkpoint, got %v", err)
+	}
+	executed, _ := checkpoint.Data.Extra["executed"].([]string)
+	if len(executed) != 3 {
+		t.Fatalf("expected 3 executed nodes, got %v", executed)
+	}
+
+	runs2 := make(map[string]int)
+	graph2 := build(runs2, nil)
+	if err := graph2.LoadFromStore(store, "auto"); err != nil {
+		t.Fatalf("failed to load checkpoint: %v", err)
+	}
+	if err := graph2.Resume(context.Background()); err != nil {
+		t.Fatalf("failed to resume: %v", err)
+	}
+	if runs2["step1"] != 0 || runs2["step3"] != 0 || runs2["step4"] != 1 {
+		t.Errorf("expected only remaining nodes to run, got %v", runs2)
+	}
+	result, _ := graph2.NodeResult("step5")
+	if len(result) != 1 || result[0] != 4 {
+		t.Errorf("expected step5 result 4, got %v", result)
+	}
+}
+
+func TestGraphAutoCheckpointParallel(t *testing.T) {
+	store := NewMemoryCheckpointStore()
+	graph := NewGraph()
+	graph.AddNode("start", func() int { return 1 })
+	for i := 0; i < 8; i++ {
+		name := fmt.Sprintf("worker%d", i)
+		graph.AddNode(name, func(n int) int { return n + i })
+		graph.AddEdge("start", name)
+	}
+	graph.EnableAutoCheckpoint(store, "parallel", 3)
+
+	if err := graph.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkpoint, err := store.Load("parallel")
+	if err != nil {
+		t.Fatalf("expected auto checkpoint, got %v", err)
+	}
+	executed, _ := checkpoint.Data.Extra["executed"].([]string)
+	if len(executed) < 9 {
+		t.Errorf("expected at least 9 executed nodes in final checkpoint, got %d", len(executed))
+	}
+}
+
+type countingCheckpointStore struct {
+	*MemoryCheckpointStore
+	saves atomic.Int32
+}
+
+func (s *countingCheckpointStore) Save(key string, checkpoint *Checkpoint) error {
+	s.saves.Add(1)
+	return s.MemoryCheckpointStore.Save(key, checkpoint)
+}
+
+func TestGraphAutoCheckpointCadencePerRun(t *testing.T) {
+	store := &countingCheckpointStore{MemoryCheckpointStore: NewMemoryCheckpointStore()}
+	graph := NewGraph()
+	graph.AddNode("a", func() int { return 1 })
+	graph.AddNode("b", func(n int) int { return n })
+	graph.AddNode("c", func(n int) int { return n })
+	graph.AddEdge("a", "b")
+	graph.AddEdge("b", "c")
+	graph.EnableAutoCheckpoint(store, "cadence", 2)
+
+	for run := 1; run <= 3; run++ {
+		graph.Reset()
+		assertNoError(t, graph.Run())
+		if got := store.saves.Load(); got != int32(run) {
+			t.Fatalf("run %d: expected %d saves, got %d", run, run, got)
+		}
+	}
+}
+
+type fakeRedisClient struct {
+	data map[string][]byte
+	mu   sync.Mutex
+}
+
+func newFakeRedisClient() *fakeRedisClient {
+	return &fakeRedisClient{data: make(map[string][]byte)}
+}
+
+func (c *fakeRedisClient) Get(_ context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.data[key]
+	return v, ok, nil
+}
+
+func (c *fakeRedisClient) Set(_ context.Context, key string, value []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data[key] = value
+	return nil
+}
+
+func (c *fakeRedisClient) Del(_ context.Context, key string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.data[key]
+	delete(c.data, key)
+	return ok, nil
+}
+
+func (c *fakeRedisClient) Keys(_ context.Context, pattern string) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	prefix := strings.TrimSuffix(pattern, "*")
+	var keys []string
+	for k := range c.data {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+func TestRedisCheckpointStore(t *testing.T) {
+	client := newFakeRedisClient()
+	client.data["other:key"] = []byte("{}")
+	store := NewRedisCheckpointStore(client, "")
+
+	var _ CheckpointStore = store
+
+	checkpoint := NewCheckpoint(CheckpointTypeGraph)
+	checkpoint.SetMetadata("applicant", "alice")
+	if err := store.Save("test-key", checkpoint); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+	if _, ok := client.data[defaultRedisKeyPrefix+"test-key"]; !ok {
+		t.Fatalf("expected key under prefix, got %v", client.data)
+	}
+
+	loaded, err := store.Load("test-key")
+	if err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+	if loaded.ID != "test-key" || loaded.Type != CheckpointTypeGraph {
+		t.Errorf("unexpected checkpoint: %+v", loaded)
+	}
+	if v, _ := loaded.GetMetadata("applicant"); v != "alice" {
+		t.Errorf("expected applicant 'alice', got %q", v)
+	}
+
+	keys, err := store.List()
+	if err != nil {
+		t.Fatalf("failed to list: %v", err)
+	}
+	if len(keys) != 1 || keys[0] != "test-key" {
+		t.Errorf("expected [test-key], got %v", keys)
+	}
+
+	if err := store.Delete("test-key"); err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+	if _, err := store.Load("test-key"); err != ErrCheckpointNotFound {
+		t.Errorf("expected ErrCheckpointNotFound, got %v", err)
+	}
+	if err := store.Delete("test-key"); err != ErrCheckpointNotFound {
+		t.Errorf("expected ErrCheckpointNotFound, got %v", err)
+	}
+}
+
+func TestCheckpointStoreExistsAndPeek(t *testing.T) {
+	fileStore, err := NewFileCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+
+	stores := map[string]CheckpointStore{
+		"file":   fileStore,
+		"memory": NewMemoryCheckpointStore(),
+		"redis":  NewRedisCheckpointStore(newFakeRedisClient(), "test:"),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			exists, err := store.Exists("cp")
+			if err != nil || exists {
+				t.Fatalf("expected missing checkpoint, got %v, %v", exists, err)
+			}
+			if _, err := store.Peek("cp"); err != ErrCheckpointNotFound {
+				t.Fatalf("expected ErrCheckpointNotFound, got %v", err)
+			}
+
+			checkpoint := NewCheckpoint(CheckpointTypeGraph)
+			checkpoint.Data.Steps = []StepState{{Name: "start", Status: int(NodeStatusCompleted), Result: []any{"large"}}}
+			checkpoint.SetMetadata("currentNode", "review")
+			checkpoint.SetMetadata("applicant", "bob")
+			if err := store.Save("cp", checkpoint); err != nil {
+				t.Fatalf("failed to save: %v", err)
+			}
+
+			exists, err = store.Exists("cp")
+			if err != nil || !exists {
+				t.Fatalf("expected checkpoint to exist, got %v, %v", exists, err)
+			}
+
+			metadata, err := store.Peek("cp")
+			if err != nil {
+				t.Fatalf("failed to peek: %v", err)
+			}
+			if metadata["currentNode"] != "review" || metadata["applicant"] != "bob" {
+				t.Errorf("unexpected metadata: %v", metadata)
+			}
+
+			if err := store.Save("bare", NewCheckpoint(CheckpointTypeChain)); err != nil {
+				t.Fatalf("failed to save: %v", err)
+			}
+			metadata, err = store.Peek("bare")
+			if err != nil || metadata != nil {
+				t.Errorf("expected nil metadata, got %v, %v", metadata, err)
+			}
+		})
+	}
+}
+
+func TestGraphCancel(t *testing.T) {
+	for _, mode := range []string{"Parallel", "Sequential"} {
+		t.Run(mode, func(t *testing.T) {
+			started := make(chan struct{})
+			var afterRan atomic.Bool
+
+			graph := NewGraph()
+			graph.AddNode("slow", func(ctx context.Context) (int, error) {
+				close(started)
+				select {
+				case <-ctx.Done():
+					return 0, ctx.Err()
+				case <-time.After(5 * time.Second):
+					return 1, nil
+				}
+			})
+			graph.AddNode("after", func(n int) int {
+				afterRan.Store(true)
+				return n
+			})
+			graph.AddEdge("slow", "after")
+
+			go func() {
+				<-started
+				graph.Cancel()
+			}()
+
+			var err error
+			if mode == "Parallel" {
+				err = graph.Run()
+			} else {
+				err = graph.RunSequential()
+			}
+			if err == nil {
+				t.Fatal("expected cancellation error")
+			}
+			if afterRan.Load() {
+				t.Error("expected pending node not to start after cancel")
+			}
+			if graph.State() != FlowStateCanceled {
+				t.Errorf("expected canceled state, got %v", graph.State())
+			}
+
+			checkpoint, err := graph.SaveCheckpoint()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if checkpoint.State != FlowStateCanceled {
+				t.Errorf("expected canceled checkpoint state, got %v", checkpoint.State)
+			}
+
+			graph.Reset()
+			if graph.State() != FlowStateIdle {
+				t.Errorf("expected idle state after reset, got %v", graph.State())
+			}
+		})
+	}
+
+	t.Run("NotRunning", func(t *testing.T) {
+		graph := NewGraph()
+		graph.AddNode("node1", func() int { return 1 })
+		graph.Cancel()
+
+		if err := graph.Run(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if graph.State() != FlowStateCompleted {
+			t.Errorf("expected completed state, got %v", graph.State())
+		}
+	})
+}
+
+func TestGraphStateRunning(t *testing.T) {
+	for _, mode := range []string{"Parallel", "Sequential"} {
+		t.Run(mode, func(t *testing.T) {
+			started := make(chan struct{})
+			release := make(chan struct{})
+
+			graph := NewGraph()
+			graph.AddNode("node1", func() int { return 1 })
+			graph.AddNode("node2", func(n int) int {
+				close(started)
+				<-release
+				return n + 1
+			})
+			graph.AddEdge("node1", "node2")
+
+			errCh := make(chan error, 1)
+			go func() {
+				if mode == "Parallel" {
+					errCh <- graph.Run()
+				} else {
+					errCh <- graph.RunSequential()
+				}
+			}()
+
+			<-started
+			if graph.State() != FlowStateRunning {
+				t.Errorf("expected running state, got %v", graph.State())
+			}
+			if running := graph.GetNodesByStatus(NodeStatusRunning); len(running) != 1 || running[0] != "node2" {
+				t.Errorf("expected node2 to be running, got %v", running)
+			}
+			close(release)
+
+			if err := <-errCh; err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if graph.State() != FlowStateCompleted {
+				t.Errorf("expected completed state, got %v", graph.State())
+			}
+		})
+	}
+}
+
+func TestChainCheckpoint(t *testing.T) {
+	type order struct {
+		ID    string
+		Total float64
+	}
+
+	var loads, totals atomic.Int32
+	build := func(failTotal bool) *Chain {
+		chain := NewChain()
+		chain.Add("load", func() order {
+			loads.Add(1)
+			return order{ID: "A-1", Total: 12.5}
+		})
+		chain.Add("total", func(o order) (float64, error) {
+			totals.Add(1)
+			if failTotal {
+				return 0, errors.New("pricing service down")
+			}
+			return o.Total * 2, nil
+		})
+		chain.Add("done", func(total float64) chan int { return make(chan int) })
+		return chain
+	}
+
+	store, err := NewFileCheckpointStore(t.TempDir())
+	assertNoError(t, err)
+
+	crashed := build(true)
+	assertError(t, crashed.Run())
+	assertNoError(t, crashed.SaveToStore(store, "orders"))
+
+	checkpoint, err := store.Load("orders")
+	assertNoError(t, err)
+	assertEqual(t, CheckpointTypeChain, checkpoint.Type)
+	assertEqual(t, FlowStateFailed, checkpoint.State)
+
+	resumed := build(false)
+	assertNoError(t, resumed.LoadCheckpoint(checkpoint))
+	assertContains(t, resumed.CheckpointError().Error(), "pricing service down")
+	if !errors.Is(resumed.CheckpointError(), ErrExecutionFailedErr) {
+		t.Errorf("expected restored error to match ErrExecutionFailedErr, got %v", resumed.CheckpointError())
+	}
+	assertNoError(t, resumed.Run())
+	assertEqual(t, int32(1), loads.Load())
+	assertEqual(t, int32(2), totals.Load())
+
+	retried := build(false)
+	assertNoError(t, retried.LoadCheckpoint(checkpoint))
+	assertNoError(t, retried.Resume())
+	assertEqual(t, int32(1), loads.Load())
+	assertEqual(t, int32(3), totals.Load())
+
+	loaded, err := ChainValueAs[order](resumed, "load")
+	assertNoError(t, err)
+	assertEqual(t, order{ID: "A-1", Total: 12.5}, loaded)
+
+	completed, err := resumed.SaveCheckpoint()
+	assertNoError(t, err)
+	assertEqual(t, FlowStatePaused, completed.State)
+	assertEqual(t, false, completed.Data.Steps[2].Executed)
+
+	fresh := build(false)
+	assertNoError(t, fresh.LoadCheckpoint(completed))
+	assertEqual(t, nil, fresh.CheckpointError())
+	assertNoError(t, fresh.Run())
+	assertEqual(t, int32(1), loads.Load())
+	assertEqual(t, int32(3), totals.Load())
+
+	assertEqual(t, ErrCheckpointInvalidType, fresh.LoadCheckpoint(NewCheckpoint(CheckpointTypeGraph)))
+}
+
+func TestGraphPauseAndFatalErrors(t *testing.T) {
+	var approved atomic.Bool
+	graph := NewGraph()
+	graph.AddNode("load", func() int { return 7 })
+	graph.AddNode("approve", func(n int) (int, error) {
+		if !approved.Load() {
+			return 0, &PauseError{Err: errors.New("awaiting approval")}
+		}
+		return n, nil
+	})
+	graph.AddEdge("load", "approve")
+
+	err := graph.Run()
+	assertError(t, err)
+	assertContains(t, err.Error(), "awaiting approval")
+	assertEqual(t, "approve", graph.GetPausedAtNode())
+	assertEqual(t, FlowStatePaused, graph.State())
+
+	approved.Store(true)
+	assertNoError(t, graph.ResumeWithConfig(context.Background(), NewResumeConfig().SetRetryFailed()))
+	assertNodeResult(t, graph, "approve", 7)
+
+	var attempts atomic.Int32
+	fatal := NewGraph()
+	fatal.SetPauseConfig(NewPauseConfig().SetPauseOnError())
+	fatal.SetErrorMode(ErrorModeCollect)
+	fatal.AddNodeWithRetry("charge", func() (int, error) {
+		attempts.Add(1)
+		return 0, &FatalError{Err: errors.New("card revoked")}
+	}, RetryPolicy{MaxAttempts: 3})
+	fatal.AddNode("other", func() int { return 1 })
+
+	err = fatal.RunSequential()
+	assertError(t, err)
+	assertContains(t, err.Error(), "card revoked")
+	var multi *MultiError
+	if errors.As(err, &multi) {
+		t.Errorf("expected fatal error to abort, got %v", err)
+	}
+	assertEqual(t, "", fatal.GetPausedAtNode())
+	assertEqual(t, int32(1), attempts.Load())
+}
+
+func TestGraphNodeResources(t *testing.T) {
+	checker := NewSimpleResourceChecker(1, 1)
+	checker.SetResourceAvailable("gpu", 1)
+
+	graph := NewGraph()
+	graph.AddNode("prepare", func() int { return 4 })
+	graph.AddNode("gpu_task", func(n int) int { return n * n })
+	graph.AddNode("summarize", func(n int) string { return strconv.Itoa(n) })
+	graph.AddEdge("prepare", "gpu_task")
+	graph.AddEdge("gpu_task", "summarize")
+	graph.SetResourceChecker(checker)
+	assertNoError(t, graph.SetNodeResource("gpu_task", "gpu", 2))
+
+	err := graph.RunSequential()
+	if !errors.Is(err, ErrResourceNotAvailable) {
+		t.Fatalf("expected ErrResourceNotAvailable, got %v", err)
+	}
+	assertEqual(t, "gpu_task", graph.GetPausedAtNode())
+	assertNodeStatus(t, graph, "prepare", NodeStatusCompleted)
+
+	checker.SetResourceAvailable("gpu", 2)
+	assertNoError(t, graph.Resume(context.Background()))
+	assertNodeResult(t, graph, "summarize", "16")
+
+	err = graph.SetNodeResource("missing", "gpu", 1)
+	assertError(t, err)
+	assertContains(t, err.Error(), ErrNodeNotFound)
+
+	t.Run("UndeclaredNodesStillChecked", func(t *testing.T) {
+		graph := NewGraph()
+		graph.AddNode("prepare", func() int { return 4 })
+		graph.AddNode("gpu_task", func(n int) int { return n * n })
+		graph.AddEdge("prepare", "gpu_task")
+		graph.SetResourceChecker(NewSimpleResourceChecker(0, 1))
+		assertNoError(t, graph.SetNodeResource("gpu_task", "gpu", 1))
+
+		assertEqual(t, true, errors.Is(graph.Run(), ErrResourceNotAvailable))
+		assertEqual(t, "prepare", graph.GetPausedAtNode())
+	})
+
+	t.Run("ConcurrentNodesReserve", func(t *testing.T) {
+		checker := NewSimpleResourceChecker(1, 1)
+		checker.SetResourceAvailable("gpu", 2)
+
+		var running, peak int32
+		train := func(n int) int {
+			now := atomic.AddInt32(&running, 1)
+			for {
+				old := atomic.LoadInt32(&peak)
+				if now <= old || atomic.CompareAndSwapInt32(&peak, old, now) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			return n
+		}
+		graph := NewGraph()
+		graph.AddNode("prepare", func() int { return 1 })
+		for _, name := range []string{"train_a", "train_b", "train_c"} {
+			graph.AddNode(name, train)
+			graph.AddEdge("prepare", name)
+			assertNoError(t, graph.SetNodeResource(name, "gpu", 2))
+		}
+		graph.SetResourceChecker(checker)
+
+		err := graph.Run()
+		for errors.Is(err, ErrResourceNotAvailable) {
+			err = graph.Resume(context.Background())
+		}
+		assertNoError(t, err)
+		assertEqual(t, int32(1), atomic.LoadInt32(&peak))
+		assertEqual(t, true, checker.CheckResource("gpu", 2))
+	})
+}
+
+func TestGraphPausedChAndResumeFrom(t *testing.T) {
+	graph := NewGraph()
+	graph.AddNode("submit", func() int { return 10 })
+	graph.AddNode("approve", func(n int) int { return n * 2 })
+	graph.AddNode("notify", func(n int) int { return n + 1 })
+	graph.AddEdge("submit", "approve")
+	graph.AddEdge("approve", "notify")
+	graph.SetPauseConfig(NewPauseConfig().SetPauseAtNodes("approve"))
+
+	if err := graph.ResumeFrom(context.Background()); !errors.Is(err, ErrNoPausePoint) {
+		t.Fatalf("expected ErrNoPausePoint before any pause, got %v", err)
+	}
+
+	paused := graph.PausedCh()
+	errCh := make(chan error, 1)
+	go func() { errCh <- graph.Run() }()
+
+	select {
+	case name := <-paused:
+		if name != "approve" {
+			t.Fatalf("expected pause at approve, got %s", name)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for pause notification")
+	}
+	if err := <-errCh; !errors.Is(err, ErrFlowPaused) {
+		t.Fatalf("expected ErrFlowPaused, got %v", err)
+	}
+
+	if err := graph.ResumeFrom(context.Background()); err != nil {
+		t.Fatalf("resume failed: %v", err)
+	}
+	if result, _ := graph.NodeResult("notify"); len(result) != 1 || result[0] != 21 {
+		t.Fatalf("expected notify to finish with 21, got %v", result)
+	}
+
+	unread := NewGraph()
+	unread.AddNode("a", func() int { return 1 })
+	unread.AddNode("b", func(n int) int { return n })
+	unread.AddEdge("a", "b")
+	unread.SetPauseConfig(NewPauseConfig().SetPauseAtNodes("a", "b"))
+	latest := unread.PausedCh()
+	if err := unread.Run(); !errors.Is(err, ErrFlowPaused) {
+		t.Fatalf("expected pause at a, got %v", err)
+	}
+	if err := unread.ResumeFrom(context.Background()); !errors.Is(err, ErrFlowPaused) {
+		t.Fatalf("expected pause at b without a reader, got %v", err)
+	}
+	if name := <-latest; name != "b" {
+		t.Fatalf("expected the unread notification to be replaced by b, got %s", name)
+	}
+}
@@ -0,0 +1,280 @@
+package flow
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+const (
+	ErrInvalidMapFunc    = "map function must have signature func(T) R or func(T) (R, error)"
+	ErrInvalidReduceFunc = "reduce function must have signature func(R, T) R or func(R, T) (R, error)"
+	ErrInvalidFilterFunc = "filter function must have signature func(T) bool"
+)
+
+func (g *Graph) AddMapNode(name string, fn any) *Graph {
+	if g.err != nil {
+		return g
+	}
+
+	fnValue := reflect.ValueOf(fn)
+	if !isMapFunc(fnValue) || fnValue.IsNil() {
+		g.err = &FlowError{Kind: ErrInvalidMapFuncErr, Message: fmt.Sprintf("%s: %s", ErrInvalidMapFunc, name)}
+		return g
+	}
+
+	if !g.tryAddNode(name, nil) {
+		return g
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	node := g.nodes[name]
+	node.numOut = 1
+	node.callFn = compileMapCall(fnValue)
+	return g
+}
+
+func compileMapCall(fnValue reflect.Value) func(context.Context, []any) ([]any, error) {
+	fnType := fnValue.Type()
+	elemType := fnType.In(0)
+	outType := fnType.Out(0)
+	hasError := fnType.NumOut() == 2
+
+	return func(ctx context.Context, inputs []any) ([]any, error) {
+		items, err := collectionItems(inputs, elemType)
+		if err != nil {
+			return nil, err
+		}
+
+		out := reflect.MakeSlice(reflect.SliceOf(outType), len(items), len(items))
+		errs := make([]error, len(items))
+
+		sem := make(chan struct{}, defaultWorkerCount)
+		var wg sync.WaitGroup
+		for i, item := range items {
+			select {
+			case <-ctx.Done():
+				wg.Wait()
+				return nil, canceledError(ctx)
+			case sem <- struct{}{}:
+			}
+			wg.Add(1)
+			go func(i int, item reflect.Value) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				defer func() {
+					if r := recover(); r != nil {
+						errs[i] = &FlowError{Kind: ErrFunctionPanickedErr, Message: fmt.Sprintf("%s: %v", ErrFunctionPanicked, r)}
+					}
+				}()
+				results := fnValue.Call([]reflect.Value{item})
+				if hasError && !results[1].IsNil() {
+					errs[i] = results[1].Interface().(error)
+					return
+				}
+				out.Index(i).Set(results[0])
+			}(i, item)
+		}
+		wg.Wait()
+
+		for i, err := range errs {
+			if err != nil {
+				return nil, &FlowError{Message: fmt.Sprintf("element %d: %v", i, err), Cause: err}
+			}
+		}
+		return []any{out.Interface()}, nil
+	}
+}
+
+func (g *Graph) AddReduceNode(name string, initial any, fn any) *Graph {
+	if g.err != nil {
+		return g
+	}
+
+	fnValue := reflect.ValueOf(fn)
+	if fnValue.Kind() != reflect.Func || fnValue.IsNil() {
+		g.err = &FlowError{Kind: ErrInvalidReduceFuncErr, Message: fmt.Sprintf("%s: %s", ErrInvalidReduceFunc, name)}
+		return g
+	}
+	fnType := fnValue.Type()
+	if fnType.NumIn() != 2 || fnType.NumOut() < 1 || fnType.NumOut() > 2 ||
+		fnType.Out(0) != fnType.In(0) || (fnType.NumOut() == 2 && !fnType.Out(1).Implements(errorType)) {
+		g.err = &FlowError{Kind: ErrInvalidReduceFuncErr, Message: fmt.Sprintf("%s: %s", ErrInvalidReduceFunc, name)}
+		return g
+	}
+
+	accType := fnType.In(0)
+	initialValue := reflect.Zero(accType)
+	if initial != nil {
+		initialValue = reflect.ValueOf(initial)
+		if !initialValue.Type().AssignableTo(accType) {
+			if !initialValue.CanConvert(accType) {
+				g.err = &FlowError{Kind: ErrArgTypeMismatchErr, Message: fmt.Sprintf("%s: %s", ErrArgTypeMismatch, name)}
+				return g
+			}
+			initialValue = initialValue.Convert(accType)
+		}
+	}
+
+	if !g.tryAddNode(name, nil) {
+		return g
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	node := g.nodes[name]
+	node.numOut = 1
+	node.callFn = compileReduceCall(fnValue, initialValue)
+	return g
+}
+
+func compileReduceCall(fnValue reflect.Value, initial reflect.Value) func(context.Context, []any) ([]any, error) {
+	fnType := fnValue.Type()
+	elemType := fnType.In(1)
+	hasError := fnType.NumOut() == 2
+
+	return func(ctx context.Context, inputs []any) (out []any, err error) {
+		items, err := collectionItems(inputs, elemType)
+		if err != nil {
+			return nil, err
+		}
+
+		defer func() {
+			if r := recover(); r != nil {
+				out, err = nil, &FlowError{Kind: ErrFunctionPanickedErr, Message: fmt.Sprintf("%s: %v", ErrFunctionPanicked, r)}
+			}
+		}()
+
+		acc := initial
+		for i, item := range items {
+			select {
+			case <-ctx.Done():
+				return nil, canceledError(ctx)
+			default:
+			}
+			results := fnValue.Call([]reflect.Value{acc, item})
+			if hasError && !results[1].IsNil() {
+				elemErr := results[1].Interface().(error)
+				return nil, &FlowError{Message: fmt.Sprintf("element %d: %v", i, elemErr), Cause: elemErr}
+			}
+			acc = results[0]
+		}
+		return []any{acc.Interface()}, nil
+	}
+}
+
+func (c *Chain) Map(name string, fn any) *Chain {
+	if c.err != nil {
+		return c
+	}
+
+	fnValue := reflect.ValueOf(fn)
+	if !isMapFunc(fnValue) || fnValue.IsNil() {
+		c.err = &FlowError{Kind: ErrInvalidMapFuncErr, Message: fmt.Sprintf("%s: %s", ErrInvalidMapFunc, name)}
+		return c
+	}
+	fnType := fnValue.Type()
+	hasError := fnType.NumOut() == 2
+
+	stepType := reflect.FuncOf(
+		[]reflect.Type{reflect.SliceOf(fnType.In(0))},
+		[]reflect.Type{reflect.SliceOf(fnType.Out(0)), errorType},
+		false,
+	)
+	step := reflect.MakeFunc(stepType, func(args []reflect.Value) []reflect.Value {
+		items := args[0]
+		out := reflect.MakeSlice(stepType.Out(0), items.Len(), items.Len())
+		for i := range items.Len() {
+			results := fnValue.Call([]reflect.Value{items.Index(i)})
+			if hasError && !results[1].IsNil() {
+				elemErr := results[1].Interface().(error)
+				var err error = &FlowError{Message: fmt.Sprintf("element %d: %v", i, elemErr), Cause: elemErr}
+				return []reflect.Value{reflect.Zero(stepType.Out(0)), reflect.ValueOf(&err).Elem()}
+			}
+			out.Index(i).Set(results[0])
+		}
+		return []reflect.Value{out, reflect.Zero(errorType)}
+	})
+	return c.Add(name, step.Interface())
+}
+
+func (c *Chain) Filter(name string, pred any) *Chain {
+	if c.err != nil {
+		return c
+	}
+
+	predValue := reflect.ValueOf(pred)
+	if predValue.Kind() != reflect.Func || predValue.IsNil() {
+		c.err = &FlowError{Kind: ErrInvalidFilterFuncErr, Message: fmt.Sprintf("%s: %s", ErrInvalidFilterFunc, name)}
+		return c
+	}
+	predType := predValue.Type()
+	if predType.NumIn() != 1 || predType.NumOut() != 1 ||
+		predType.Out(0).Kind() != reflect.Bool {
+		c.err = &FlowError{Kind: ErrInvalidFilterFuncErr, Message: fmt.Sprintf("%s: %s", ErrInvalidFilterFunc, name)}
+		return c
+	}
+
+	sliceType := reflect.SliceOf(predType.In(0))
+	stepType := reflect.FuncOf([]reflect.Type{sliceType}, []reflect.Type{sliceType}, false)
+	step := reflect.MakeFunc(stepType, func(args []reflect.Value) []reflect.Value {
+		items := args[0]
+		out := reflect.MakeSlice(sliceType, 0, items.Len())
+		for i := range items.Len() {
+			if predValue.Call([]reflect.Value{items.Index(i)})[0].Bool() {
+				out = reflect.Append(out, items.Index(i))
+			}
+		}
+		return []reflect.Value{out}
+	})
+	return c.Add(name, step.Interface())
+}
+
+func isMapFunc(fnValue reflect.Value) bool {
+	if fnValue.Kind() != reflect.Func {
+		return false
+	}
+	fnType := fnValue.Type()
+	return fnType.NumIn() == 1 && fnType.NumOut() >= 1 && fnType.NumOut() <= 2 &&
+		(fnType.NumOut() == 1 || fnType.Out(1).Implements(errorType))
+}
+
+func collectionItems(inputs []any, elemType reflect.Type) ([]reflect.Value, error) {
+	var raw []reflect.Value
+	if len(inputs) == 1 && inputs[0] != nil {
+		v := reflect.ValueOf(inputs[0])
+		if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+			raw = make([]reflect.Value, v.Len())
+			for i := range v.Len() {
+				raw[i] = v.Index(i)
+			}
+		}
+	}
+	if raw == nil {
+		raw = make([]reflect.Value, len(inputs))
+		for i, input := range inputs {
+			raw[i] = reflect.ValueOf(input)
+		}
+	}
+
+	items := make([]reflect.Value, len(raw))
+	for i, v := range raw {
+		if v.IsValid() && v.Kind() == reflect.Interface {
+			v = v.Elem()
+		}
+		if !v.IsValid() {
+			items[i] = reflect.Zero(elemType)
+			continue
+		}
+		if !v.Type().AssignableTo(elemType) {
+			if !v.CanConvert(elemType) {
+				return nil, &FlowError{Kind: ErrArgTypeMismatchErr, Message: fmt.Sprintf("element %d: %s", i, ErrArgTypeMismatch)}
+			}
+			v = v.Convert(elemType)
+		}
+		items[i] = v
+	}
+	return items, nil
+}
@@ -0,0 +1,86 @@
+package flow
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+type CompensationFunc func(inputs, results []any) error
+
+func (g *Graph) AddCompensation(name string, fn CompensationFunc) *Graph {
+	if g.err != nil {
+		return g
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.frozen {
+		g.err = &FlowError{Kind: ErrGraphFrozenErr, Message: fmt.Sprintf("%s: cannot add compensation for %s", ErrGraphFrozen, name)}
+		return g
+	}
+	node, ok := g.nodes[name]
+	if !ok {
+		g.err = &FlowError{Kind: ErrNodeNotFoundErr, Message: fmt.Sprintf("%s: %s", ErrNodeNotFound, name)}
+		return g
+	}
+	node.mu.Lock()
+	node.compensate = fn
+	node.mu.Unlock()
+	return g
+}
+
+func (n *Node) recordInputs(inputs []any) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.compensate != nil {
+		n.lastInputs = append([]any(nil), inputs...)
+	}
+}
+
+func (g *Graph) compensate(runErr error) error {
+	if runErr == nil || errors.Is(runErr, ErrFlowPaused) {
+		return runErr
+	}
+
+	g.mu.RLock()
+	plan := slices.Clone(g.execPlan)
+	g.mu.RUnlock()
+
+	var failures []error
+	for _, name := range slices.Backward(plan) {
+		node := g.nodes[name]
+		node.mu.RLock()
+		fn := node.compensate
+		completed := node.status == NodeStatusCompleted
+		inputs, results := node.lastInputs, node.result
+		node.mu.RUnlock()
+		if fn == nil || !completed {
+			continue
+		}
+
+		if err := fn(inputs, results); err != nil {
+			failures = append(failures, &FlowError{
+				Message: fmt.Sprintf("compensation for %s failed: %v", name, err),
+				Node:    name,
+				Cause:   err,
+			})
+			continue
+		}
+		node.mu.Lock()
+		node.status = NodeStatusPending
+		node.result = nil
+		node.lastInputs = nil
+		node.mu.Unlock()
+	}
+
+	if len(failures) == 0 {
+		return runErr
+	}
+	var multi *MultiError
+	if errors.As(runErr, &multi) {
+		return &MultiError{Errors: append(slices.Clone(multi.Errors), failures...)}
+	}
+	return &MultiError{Errors: append([]error{runErr}, failures...)}
+}
@@ -1,6 +1,7 @@
 package flow
 
 import (
+	"context"
 	"reflect"
 )
 
@@ -62,35 +63,57 @@ func (c *condCompiler) eval(results []any) bool {
 }
 
 func (g *Graph) compileCondition(cond any) CondFunc {
-	if cond == nil {
-		return nil
-	}
-
-	if c, ok := cond.(CondFunc); ok {
-		return c
-	}
+	condFunc, _ := compileCond(cond)
+	return condFunc
+}
 
-	if b, ok := cond.(bool); ok {
-		if b {
-			return nil
+func compileCond(cond any) (CondFunc, *condCompiler) {
+	switch c := cond.(type) {
+	case nil:
+		return nil, nil
+	case CondFunc:
+		return c, nil
+	case func([]any) bool:
+		return c, nil
+	case func(any) bool:
+		return func(results []any) bool {
+			if len(results) == 0 {
+				return c(nil)
+			}
+			return c(results[0])
+		}, nil
+	case bool:
+		if c {
+			return nil, nil
 		}
-		return func([]any) bool { return false }
+		return func([]any) bool { return false }, nil
 	}
 
-	fnValue := reflect.ValueOf(cond)
-	fnType := fnValue.Type()
-
-	if fnType.Kind() != reflect.Func {
-		return nil
+	if reflect.TypeOf(cond).Kind() != reflect.Func {
+		return nil, nil
 	}
 
 	comp := newCondCompiler(cond)
-	return comp.eval
+	return comp.eval, comp
 }
 
-func (g *Graph) compileNodeCall(node *Node) func([]any) ([]any, error) {
+func TypedCond[T any](pred func(T) bool) CondFunc {
+	return func(results []any) bool {
+		var value T
+		if len(results) > 0 && results[0] != nil {
+			typed, ok := results[0].(T)
+			if !ok {
+				return false
+			}
+			value = typed
+		}
+		return pred(value)
+	}
+}
+
+func (g *Graph) compileNodeCall(node *Node) func(context.Context, []any) ([]any, error) {
 	if node.fn == nil {
-		return func(inputs []any) ([]any, error) {
+		return func(_ context.Context, inputs []any) ([]any, error) {
 			return inputs, nil
 		}
 	}
@@ -98,15 +121,24 @@ func (g *Graph) compileNodeCall(node *Node) func([]any) ([]any, error) {
 	fnValue := node.fnValue
 	argCount := node.argCount
 	sliceArg := node.sliceArg
+	sliceAsSingle := node.sliceAsSingle
+	variadic := node.variadic
 	sliceElemType := node.sliceElemType
 	hasError := node.hasErrorReturn
 	argTypes := node.argTypes
+	hasContext := node.hasContext
+	hasLoopInfo := node.hasLoopInfo
+	name := node.name
 
-	return func(inputs []any) ([]any, error) {
+	return func(ctx context.Context, inputs []any) (out []any, err error) {
 		args := reflectValueSlicePool.Get(argCount)
 		defer reflectValueSlicePool.Put(args)
 
-		if len(inputs) > 0 {
+		if variadic {
+			if args, err = appendVariadicArgs(args, inputs, argTypes); err != nil {
+				return nil, err
+			}
+		} else if len(inputs) > 0 {
 			if argCount > 0 && len(inputs) == argCount { //nolint:gocritic
 				for i := range len(inputs) {
 					input := inputs[i]
@@ -119,7 +151,7 @@ func (g *Graph) compileNodeCall(node *Node) func([]any) ([]any, error) {
 						if val.CanConvert(argTypes[i]) {
 							val = val.Convert(argTypes[i])
 						} else {
-							return nil, &FlowError{Message: ErrArgTypeMismatch}
+							return nil, &FlowError{Kind: ErrArgTypeMismatchErr, Message: ErrArgTypeMismatch}
 						}
 					}
 					args = append(args, val)
@@ -132,12 +164,14 @@ func (g *Graph) compileNodeCall(node *Node) func([]any) ([]any, error) {
 						if val.CanConvert(sliceElemType) {
 							val = val.Convert(sliceElemType)
 						} else {
-							return nil, &FlowError{Message: ErrArgTypeMismatch}
+							return nil, &FlowError{Kind: ErrArgTypeMismatchErr, Message: ErrArgTypeMismatch}
 						}
 					}
 					sliceValue.Index(i).Set(val)
 				}
 				args = append(args, sliceValue)
+			} else if sliceAsSingle {
+				return nil, &FlowError{Kind: ErrArgCountMismatchErr, Message: ErrArgCountMismatch}
 			} else if len(inputs) > 0 {
 				currentValue := inputs[0]
 				currentValueType := reflect.TypeOf(currentValue)
@@ -151,7 +185,7 @@ func (g *Graph) compileNodeCall(node *Node) func([]any) ([]any, error) {
 				case currentValueType.Kind() == reflect.Slice || currentValueType.Kind() == reflect.Array:
 					elemCount := currentValueValue.Len()
 					if argCount > 0 && elemCount != argCount {
-						return nil, &FlowError{Message: ErrArgCountMismatch}
+						return nil, &FlowError{Kind: ErrArgCountMismatchErr, Message: ErrArgCountMismatch}
 					}
 					for i := range elemCount {
 						elem := currentValueValue.Index(i)
@@ -166,7 +200,7 @@ func (g *Graph) compileNodeCall(node *Node) func([]any) ([]any, error) {
 						if val.CanConvert(argTypes[0]) {
 							val = val.Convert(argTypes[0])
 						} else {
-							return nil, &FlowError{Message: ErrArgTypeMismatch}
+							return nil, &FlowError{Kind: ErrArgTypeMismatchErr, Message: ErrArgTypeMismatch}
 						}
 					}
 					args = append(args, val)
@@ -175,10 +209,28 @@ func (g *Graph) compileNodeCall(node *Node) func([]any) ([]any, error) {
 		}
 
 		if len(args) != argCount {
-			return nil, &FlowError{Message: ErrArgCountMismatch}
+			return nil, &FlowError{Kind: ErrArgCountMismatchErr, Message: ErrArgCountMismatch}
 		}
 
-		results := fnValue.Call(args)
+		if hasContext {
+			args = append([]reflect.Value{reflect.ValueOf(ctx)}, args...)
+		}
+		if hasLoopInfo {
+			args = append(args, reflect.ValueOf(loopInfoFromContext(ctx)))
+		}
+
+		defer func() {
+			if r := recover(); r != nil {
+				out, err = nil, panicFailure(name, r)
+			}
+		}()
+
+		var results []reflect.Value
+		if variadic {
+			results = fnValue.CallSlice(args)
+		} else {
+			results = fnValue.Call(args)
+		}
 
 		if hasError {
 			errValue := results[len(results)-1]
@@ -188,10 +240,40 @@ func (g *Graph) compileNodeCall(node *Node) func([]any) ([]any, error) {
 			results = results[:len(results)-1]
 		}
 
-		out := make([]any, len(results))
+		out = make([]any, len(results))
 		for i, r := range results {
 			out[i] = r.Interface()
 		}
 		return out, nil
 	}
 }
+
+func appendVariadicArgs(args []reflect.Value, inputs []any, argTypes []reflect.Type) ([]reflect.Value, error) {
+	fixed := len(argTypes) - 1
+	if len(inputs) < fixed {
+		return nil, &FlowError{Kind: ErrArgCountMismatchErr, Message: ErrArgCountMismatch}
+	}
+	for i := range fixed {
+		if err := addArg(&args, reflect.ValueOf(inputs[i]), argTypes[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	sliceType := argTypes[fixed]
+	rest := inputs[fixed:]
+	if len(rest) == 1 && rest[0] != nil && reflect.TypeOf(rest[0]).AssignableTo(sliceType) {
+		return append(args, reflect.ValueOf(rest[0])), nil
+	}
+
+	elems := make([]reflect.Value, 0, len(rest))
+	for _, input := range rest {
+		if err := addArg(&elems, reflect.ValueOf(input), sliceType.Elem()); err != nil {
+			return nil, err
+		}
+	}
+	slice := reflect.MakeSlice(sliceType, len(elems), len(elems))
+	for i, elem := range elems {
+		slice.Index(i).Set(elem)
+	}
+	return append(args, slice), nil
+}
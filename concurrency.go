@@ -0,0 +1,51 @@
+package flow
+
+import (
+	"context"
+	"fmt"
+)
+
+func (g *Graph) SetConcurrencyKey(nodeName, key string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, ok := g.nodes[nodeName]; !ok {
+		return &FlowError{Kind: ErrNodeNotFoundErr, Message: fmt.Sprintf("%s: %s", ErrNodeNotFound, nodeName)}
+	}
+	if key == "" {
+		delete(g.concurrencyKeys, nodeName)
+		return nil
+	}
+	if g.concurrencyKeys == nil {
+		g.concurrencyKeys = make(map[string]string)
+	}
+	g.concurrencyKeys[nodeName] = key
+	return nil
+}
+
+func (g *Graph) acquireConcurrencyKey(ctx context.Context, nodeName string) (func(), error) {
+	g.mu.RLock()
+	key, ok := g.concurrencyKeys[nodeName]
+	g.mu.RUnlock()
+	if !ok {
+		return func() {}, nil
+	}
+
+	g.mu.Lock()
+	if g.keyLocks == nil {
+		g.keyLocks = make(map[string]chan struct{})
+	}
+	lock, ok := g.keyLocks[key]
+	if !ok {
+		lock = make(chan struct{}, 1)
+		g.keyLocks[key] = lock
+	}
+	g.mu.Unlock()
+
+	select {
+	case lock <- struct{}{}:
+		return func() { <-lock }, nil
+	case <-ctx.Done():
+		return nil, canceledError(ctx)
+	}
+}
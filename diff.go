@@ -0,0 +1,76 @@
+package flow
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+func (g *Graph) Equal(other *Graph) bool {
+	return g.Diff(other) == ""
+}
+
+func (g *Graph) Diff(other *Graph) string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if other != g {
+		other.mu.RLock()
+		defer other.mu.RUnlock()
+	}
+
+	var lines []string
+	for _, name := range g.nodeOrder {
+		theirs, ok := other.nodes[name]
+		if !ok {
+			lines = append(lines, fmt.Sprintf("- node %s %s", name, nodeArity(g.nodes[name])))
+			continue
+		}
+		if ours, theirsArity := nodeArity(g.nodes[name]), nodeArity(theirs); ours != theirsArity {
+			lines = append(lines, fmt.Sprintf("~ node %s %s -> %s", name, ours, theirsArity))
+		}
+	}
+	for _, name := range other.nodeOrder {
+		if _, ok := g.nodes[name]; !ok {
+			lines = append(lines, fmt.Sprintf("+ node %s %s", name, nodeArity(other.nodes[name])))
+		}
+	}
+
+	ours, theirs := g.edgeKeys(), other.edgeKeys()
+	for _, key := range ours {
+		if i := slices.Index(theirs, key); i >= 0 {
+			theirs = slices.Delete(theirs, i, i+1)
+			continue
+		}
+		lines = append(lines, "- edge "+key)
+	}
+	for _, key := range theirs {
+		lines = append(lines, "+ edge "+key)
+	}
+
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func nodeArity(node *Node) string {
+	if node.variadic {
+		return fmt.Sprintf("(%d+ args)", node.argCount-1)
+	}
+	return fmt.Sprintf("(%d args)", node.argCount)
+}
+
+func (g *Graph) edgeKeys() []string {
+	var keys []string
+	for _, from := range g.nodeOrder {
+		for _, edge := range g.edges[from] {
+			key := fmt.Sprintf("%s -> %s [%s", edge.from, edge.to, edgeTypeNames[edge.edgeType])
+			if edge.cond != nil || edge.stateCond != nil {
+				key += ", cond"
+			}
+			keys = append(keys, key+"]")
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
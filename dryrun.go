@@ -0,0 +1,187 @@
+package flow
+
+import (
+	"fmt"
+	"reflect"
+)
+
+func (g *Graph) DryRun() error {
+	if g.err != nil {
+		return g.err
+	}
+
+	plan, err := g.buildExecutionPlan()
+	if err != nil {
+		return err
+	}
+	g.buildExecInEdges()
+
+	outputs := make(map[string][]reflect.Type, len(plan))
+	unknown := make(map[string]bool)
+	var issues []string
+	for _, name := range plan {
+		node := g.nodes[name]
+		inputSets, known := g.dryRunInputs(name, outputs, unknown)
+		if known && node.fn != nil && !node.skip {
+			for _, inputs := range inputSets {
+				if err := checkNodeArgs(node, inputs); err != nil {
+					issues = append(issues, fmt.Sprintf("node %s: %v", name, err))
+					break
+				}
+			}
+		}
+
+		switch {
+		case node.fn != nil && !node.skip:
+			outputs[name] = nodeOutputTypes(node)
+		case known && len(inputSets) == 1:
+			outputs[name] = inputSets[0]
+		default:
+			unknown[name] = true
+		}
+	}
+
+	if len(issues) > 0 {
+		return &ValidationError{Issues: issues}
+	}
+	return nil
+}
+
+func (g *Graph) dryRunInputs(name string, outputs map[string][]reflect.Type, unknown map[string]bool) ([][]reflect.Type, bool) {
+	if _, overridden := g.inputOverride(name); overridden {
+		return nil, false
+	}
+
+	var edges []*Edge
+	alternatives := 0
+	for _, edge := range g.execInEdges[name] {
+		if edge.edgeType == EdgeTypeLoop {
+			continue
+		}
+		if unknown[edge.from] && !edge.onError {
+			return nil, false
+		}
+		edges = append(edges, edge)
+		if g.branchTargetNodes[edge.from] {
+			alternatives++
+		}
+	}
+	if len(edges) == 0 {
+		return nil, false
+	}
+
+	collect := func(chosen *Edge) []reflect.Type {
+		var types []reflect.Type
+		for _, edge := range edges {
+			if g.branchTargetNodes[edge.from] && edge != chosen {
+				continue
+			}
+			if edge.onError {
+				types = append(types, errorType)
+				continue
+			}
+			types = append(types, outputs[edge.from]...)
+			if edge.carryError {
+				types = append(types, errorType)
+			}
+		}
+		return types
+	}
+
+	if alternatives == 0 {
+		return [][]reflect.Type{collect(nil)}, true
+	}
+	var sets [][]reflect.Type
+	for _, edge := range edges {
+		if g.branchTargetNodes[edge.from] {
+			sets = append(sets, collect(edge))
+		}
+	}
+	return sets, true
+}
+
+func nodeOutputTypes(node *Node) []reflect.Type {
+	count := node.numOut
+	if node.hasErrorReturn {
+		count--
+	}
+	types := make([]reflect.Type, count)
+	for i := range count {
+		types[i] = node.fnType.Out(i)
+	}
+	return types
+}
+
+func checkNodeArgs(node *Node, inputs []reflect.Type) error {
+	argTypes := node.argTypes
+	argCount := node.argCount
+
+	switch {
+	case node.variadic:
+		fixed := argCount - 1
+		if len(inputs) < fixed {
+			return argCountError(argCount, len(inputs))
+		}
+		for i := range fixed {
+			if err := checkArgType(inputs[i], argTypes[i], i); err != nil {
+				return err
+			}
+		}
+		sliceType := argTypes[fixed]
+		rest := inputs[fixed:]
+		if len(rest) == 1 && rest[0].AssignableTo(sliceType) {
+			return nil
+		}
+		for i, input := range rest {
+			if err := checkArgType(input, sliceType.Elem(), fixed+i); err != nil {
+				return err
+			}
+		}
+	case len(inputs) == 0:
+		if argCount > 0 {
+			return argCountError(argCount, 0)
+		}
+	case len(inputs) == argCount:
+		for i, input := range inputs {
+			if err := checkArgType(input, argTypes[i], i); err != nil {
+				return err
+			}
+		}
+	case node.sliceArg:
+		for i, input := range inputs {
+			if err := checkArgType(input, node.sliceElemType, i); err != nil {
+				return err
+			}
+		}
+	case node.sliceAsSingle:
+		return argCountError(argCount, len(inputs))
+	default:
+		first := inputs[0]
+		switch first.Kind() { //nolint:exhaustive
+		case reflect.Interface, reflect.Slice, reflect.Array:
+			// expanded or resolved from the dynamic value at runtime
+			return nil
+		}
+		if argCount == 0 {
+			return nil
+		}
+		if err := checkArgType(first, argTypes[0], 0); err != nil {
+			return err
+		}
+		if argCount != 1 {
+			return argCountError(argCount, 1)
+		}
+	}
+	return nil
+}
+
+func checkArgType(from, to reflect.Type, index int) error {
+	if from.Kind() == reflect.Interface || canConvert(from, to) {
+		return nil
+	}
+	return &FlowError{Kind: ErrArgTypeMismatchErr, Message: fmt.Sprintf("%s: argument %d expects %s, got %s", ErrArgTypeMismatch, index, to, from)}
+}
+
+func argCountError(want, got int) error {
+	return &FlowError{Kind: ErrArgCountMismatchErr, Message: fmt.Sprintf("%s: expects %d inputs, got %d", ErrArgCountMismatch, want, got)}
+}
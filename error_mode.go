@@ -0,0 +1,71 @@
+package flow
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+type ErrorMode int
+
+const (
+	ErrorModeFailFast ErrorMode = iota
+	ErrorModeCollect
+)
+
+type MultiError struct {
+	Errors []error
+}
+
+func (e *MultiError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("%d nodes failed: %s", len(e.Errors), strings.Join(messages, "; "))
+}
+
+func (e *MultiError) Unwrap() []error {
+	return e.Errors
+}
+
+func (g *Graph) SetErrorMode(mode ErrorMode) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.errorMode = mode
+}
+
+func (g *Graph) newFailureCollector() *failureCollector {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if g.errorMode != ErrorModeCollect {
+		return nil
+	}
+	return &failureCollector{}
+}
+
+type failureCollector struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+func (c *failureCollector) add(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.errs = append(c.errs, err)
+}
+
+func (c *failureCollector) err() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.errs) == 0 {
+		return nil
+	}
+	errs := append([]error(nil), c.errs...)
+	slices.SortFunc(errs, func(a, b error) int { return strings.Compare(a.Error(), b.Error()) })
+	return &MultiError{Errors: errs}
+}
@@ -0,0 +1,56 @@
+package flow
+
+import "errors"
+
+var (
+	ErrArgTypeMismatchErr     = errors.New(ErrArgTypeMismatch)
+	ErrArgCountMismatchErr    = errors.New(ErrArgCountMismatch)
+	ErrNotFunctionErr         = errors.New(ErrNotFunction)
+	ErrFunctionPanickedErr    = errors.New(ErrFunctionPanicked)
+	ErrStepNotFoundErr        = errors.New(ErrStepNotFound)
+	ErrMissingContextErr      = errors.New(ErrMissingContext)
+	ErrSeedAfterRunErr        = errors.New(ErrSeedAfterRun)
+	ErrSeedNotFunctionErr     = errors.New(ErrSeedNotFunction)
+	ErrInvalidMapFuncErr      = errors.New(ErrInvalidMapFunc)
+	ErrInvalidReduceFuncErr   = errors.New(ErrInvalidReduceFunc)
+	ErrInvalidFilterFuncErr   = errors.New(ErrInvalidFilterFunc)
+	ErrWorkerPoolClosedErr    = errors.New(ErrWorkerPoolClosed)
+	ErrExecutionStalledErr    = errors.New(ErrExecutionStalled)
+	ErrNodeNotFoundErr        = errors.New(ErrNodeNotFound)
+	ErrEdgeNotFoundErr        = errors.New(ErrEdgeNotFound)
+	ErrDuplicateNodeErr       = errors.New(ErrDuplicateNode)
+	ErrSelfDependencyErr      = errors.New(ErrSelfDependency)
+	ErrCyclicDependencyErr    = errors.New(ErrCyclicDependency)
+	ErrNoStartNodeErr         = errors.New(ErrNoStartNode)
+	ErrExecutionFailedErr     = errors.New(ErrExecutionFailed)
+	ErrNodeNotCompletedErr    = errors.New(ErrNodeNotCompleted)
+	ErrNoResultErr            = errors.New(ErrNoResult)
+	ErrResultTypeErr          = errors.New(ErrResultType)
+	ErrGraphRunningErr        = errors.New(ErrGraphRunning)
+	ErrGraphFrozenErr         = errors.New(ErrGraphFrozen)
+	ErrNotStartNodeErr        = errors.New(ErrNotStartNode)
+	ErrLoopMaxIterationsErr   = errors.New(ErrLoopMaxIterations)
+	ErrInvalidInputErr        = errors.New(ErrInvalidInput)
+	ErrUnsupportedVersionErr  = errors.New(ErrUnsupportedVersion)
+	ErrUnknownEdgeTypeErr     = errors.New(ErrUnknownEdgeType)
+	ErrUnboundNodeErr         = errors.New(ErrUnboundNode)
+	ErrUnboundConditionErr    = errors.New(ErrUnboundCondition)
+	ErrInvalidStreamSourceErr = errors.New(ErrInvalidStreamSource)
+	ErrInvalidStreamStageErr  = errors.New(ErrInvalidStreamStage)
+	ErrStreamAfterSinkErr     = errors.New(ErrStreamAfterSink)
+	ErrValidationFailedErr    = errors.New(ErrValidationFailed)
+	ErrInvalidReplayLogErr    = errors.New(ErrInvalidReplayLog)
+	ErrInvalidBranchWeightErr = errors.New(ErrInvalidBranchWeight)
+	ErrNilSubGraphErr         = errors.New(ErrNilSubGraph)
+	ErrExecutionCanceledErr   = errors.New(ErrExecutionCanceled)
+	ErrInvalidLoopEdgeErr     = errors.New(ErrInvalidLoopEdge)
+	ErrInvalidBranchCondErr   = errors.New(ErrInvalidBranchCond)
+)
+
+func (e *FlowError) Is(target error) bool {
+	return e.Kind != nil && e.Kind == target
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidationFailedErr
+}
@@ -2,9 +2,18 @@ package flow
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
+)
+
+const (
+	ErrWorkerPoolClosed = "worker pool is shut down"
+	ErrExecutionStalled = "execution stalled"
 )
 
 const (
@@ -51,8 +60,9 @@ func (p *localWorkerPool) worker() {
 	}
 }
 
-func (p *localWorkerPool) Submit(task *nodeTask) {
+func (p *localWorkerPool) Submit(task *nodeTask) error {
 	p.taskChan <- task
+	return nil
 }
 
 func (p *localWorkerPool) Shutdown() {
@@ -63,30 +73,36 @@ func (p *localWorkerPool) Shutdown() {
 	localWorkerPoolPool.Put(p)
 }
 
-type globalWorker struct {
+type WorkerPool struct {
+	size     int
 	taskChan chan *nodeTask
 	wg       sync.WaitGroup
+	closed   atomic.Bool
+	mu       sync.Mutex
 }
 
-var gw *globalWorker
-var gwOnce sync.Once
+func NewWorkerPool(size int) *WorkerPool {
+	if size <= 0 {
+		size = defaultWorkerCount
+	}
+	p := &WorkerPool{
+		size:     size,
+		taskChan: make(chan *nodeTask, max(defaultTaskChannelSize, size*taskChannelMultiplier)),
+	}
+	for i := 0; i < size; i++ {
+		p.wg.Add(1)
+		go p.worker()
+	}
+	return p
+}
 
-func getGlobalWorker() *globalWorker {
-	gwOnce.Do(func() {
-		gw = &globalWorker{
-			taskChan: make(chan *nodeTask, defaultTaskChannelSize),
-		}
-		for i := 0; i < defaultWorkerCount; i++ {
-			gw.wg.Add(1)
-			go gw.worker()
-		}
-	})
-	return gw
+func (p *WorkerPool) Size() int {
+	return p.size
 }
 
-func (w *globalWorker) worker() {
-	defer w.wg.Done()
-	for task := range w.taskChan {
+func (p *WorkerPool) worker() {
+	defer p.wg.Done()
+	for task := range p.taskChan {
 		if task == nil {
 			return
 		}
@@ -95,8 +111,48 @@ func (w *globalWorker) worker() {
 	}
 }
 
-func (w *globalWorker) Submit(task *nodeTask) {
-	w.taskChan <- task
+func (p *WorkerPool) Submit(task *nodeTask) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed.Load() {
+		return &FlowError{Kind: ErrWorkerPoolClosedErr, Message: ErrWorkerPoolClosed}
+	}
+	p.taskChan <- task
+	return nil
+}
+
+func (p *WorkerPool) Shutdown() {
+	p.mu.Lock()
+	if !p.closed.Swap(true) {
+		for i := 0; i < p.size; i++ {
+			p.taskChan <- nil
+		}
+	}
+	p.mu.Unlock()
+	p.wg.Wait()
+}
+
+func (p *WorkerPool) Closed() bool {
+	return p.closed.Load()
+}
+
+var gw *WorkerPool
+var gwOnce sync.Once
+
+func getGlobalWorker() *WorkerPool {
+	gwOnce.Do(func() {
+		gw = NewWorkerPool(defaultWorkerCount)
+	})
+	return gw
+}
+
+func (g *Graph) taskWorker() *WorkerPool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if g.workerPool != nil {
+		return g.workerPool
+	}
+	return getGlobalWorker()
 }
 
 func (g *Graph) executeGraphParallelWithContext(ctx context.Context) error {
@@ -107,7 +163,9 @@ func (g *Graph) executeGraphParallelWithContext(ctx context.Context) error {
 		threshold = g.largeThreshold
 	}
 
-	if nodeCount >= threshold {
+	// A sub-graph runs inside a worker of its parent's run, so it must not
+	// wait on the shared pool; the large executor brings its own workers.
+	if nodeCount >= threshold || g.isSubGraph {
 		return g.executeGraphParallelLarge(ctx)
 	}
 
@@ -122,35 +180,19 @@ func (g *Graph) executeGraphParallelSmall(ctx context.Context) error {
 
 	select {
 	case <-ctx.Done():
-		return &FlowError{Message: fmt.Sprintf("execution canceled: %v", ctx.Err())}
+		return canceledError(ctx)
 	default:
 	}
 
-	allEdges := g.edges
-
 	var incomingEdges map[string][]*Edge
 	if g.execInEdges != nil && g.execPlanValid {
 		incomingEdges = g.execInEdges
 	} else {
-		if g.execInEdges == nil {
-			g.execInEdges = make(map[string][]*Edge, len(allEdges))
-		} else {
-			clear(g.execInEdges)
-		}
-		for _, edges := range allEdges {
-			for _, edge := range edges {
-				g.execInEdges[edge.to] = append(g.execInEdges[edge.to], edge)
-			}
-		}
+		g.buildExecInEdges()
 		incomingEdges = g.execInEdges
 	}
 
-	if g.execStates == nil {
-		g.execStates = make(map[string]*nodeState, len(plan))
-	} else {
-		clear(g.execStates)
-	}
-	states := g.execStates
+	states := g.takeExecStates(len(plan))
 	for _, name := range plan {
 		state := nodeStatePool.Get()
 		state.doneSig = make(chan struct{}, 1)
@@ -169,42 +211,148 @@ func (g *Graph) executeGraphParallelSmall(ctx context.Context) error {
 		branchTargetNodes: g.branchTargetNodes,
 		errChan:           errChan,
 		doneChan:          doneChan,
+		sem:               g.concurrencySemaphore(),
+		failures:          g.newFailureCollector(),
+		branchMode:        g.currentBranchMode(),
 	}
 
-	worker := getGlobalWorker()
+	worker := g.taskWorker()
+	if worker.Closed() {
+		return &FlowError{Kind: ErrWorkerPoolClosedErr, Message: ErrWorkerPoolClosed}
+	}
 
 	go func() {
 		for _, nodeName := range plan {
 			task := taskPool.Get().(*nodeTask)
 			task.ctx = execCtx
 			task.name = nodeName
-			worker.Submit(task)
+			if err := worker.Submit(task); err != nil {
+				taskPool.Put(task)
+				select {
+				case errChan <- err:
+				default:
+				}
+				return
+			}
 		}
 	}()
 
+	stall, stallTimeout := g.stallWatchdog()
+	if stall != nil {
+		defer stall.Stop()
+	}
+
 	var execErr error
 	total := len(plan)
 	completed := 0
 	for completed < total {
 		select {
 		case <-ctx.Done():
-			execErr = &FlowError{Message: fmt.Sprintf("execution canceled: %v", ctx.Err())}
+			execErr = canceledError(ctx)
 			return execErr
 		case err := <-errChan:
 			execErr = err
 			return execErr
+		case <-stallChan(stall):
+			return execCtx.stallError(stallTimeout)
 		case <-doneChan:
 			completed++
+			if stall != nil {
+				stall.Reset(stallTimeout)
+			}
 		}
 	}
+	execErr = execCtx.failures.err()
+	select {
+	case err := <-errChan:
+		execErr = err
+	default:
+	}
 
 	for _, state := range states {
 		nodeStatePool.Put(state)
 	}
+	g.execStates = states
 
 	return execErr
 }
 
+// takeExecStates hands the reusable state map to a run. A run that returns
+// early does not give it back, since its workers may still be reading it.
+func (g *Graph) takeExecStates(size int) map[string]*nodeState {
+	states := g.execStates
+	g.execStates = nil
+	if states == nil {
+		return make(map[string]*nodeState, size)
+	}
+	clear(states)
+	return states
+}
+
+func (g *Graph) concurrencySemaphore() chan struct{} {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if g.maxConcurrency <= 0 {
+		return nil
+	}
+	return make(chan struct{}, g.maxConcurrency)
+}
+
+func (g *Graph) SetStallTimeout(timeout time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.stallTimeout = timeout
+}
+
+func (g *Graph) stallWatchdog() (*time.Timer, time.Duration) {
+	g.mu.RLock()
+	timeout := g.stallTimeout
+	g.mu.RUnlock()
+	if timeout <= 0 {
+		return nil, 0
+	}
+	return time.NewTimer(timeout), timeout
+}
+
+func stallChan(timer *time.Timer) <-chan time.Time {
+	if timer == nil {
+		return nil
+	}
+	return timer.C
+}
+
+func (ctx *execContext) stallError(timeout time.Duration) error {
+	var stuck []string
+	for name, state := range ctx.states {
+		if atomic.LoadUint32(&state.done) != 0 {
+			continue
+		}
+		var blockedOn []string
+		for _, edge := range ctx.incomingEdges[name] {
+			if edge.edgeType == EdgeTypeLoop {
+				continue
+			}
+			if from, ok := ctx.states[edge.from]; ok && atomic.LoadUint32(&from.done) == 0 {
+				blockedOn = append(blockedOn, fmt.Sprintf("%s (%s)", edge.from, ctx.graph.nodeStatusOf(edge.from)))
+			}
+		}
+		if len(blockedOn) > 0 {
+			stuck = append(stuck, fmt.Sprintf("%s waiting on %s", name, strings.Join(blockedOn, ", ")))
+		} else {
+			stuck = append(stuck, fmt.Sprintf("%s (%s)", name, ctx.graph.nodeStatusOf(name)))
+		}
+	}
+	slices.Sort(stuck)
+	return &FlowError{Kind: ErrExecutionStalledErr, Message: fmt.Sprintf("%s: no progress for %v: %s", ErrExecutionStalled, timeout, strings.Join(stuck, "; "))}
+}
+
+func (g *Graph) nodeStatusOf(name string) NodeStatus {
+	node := g.nodes[name]
+	node.mu.RLock()
+	defer node.mu.RUnlock()
+	return node.status
+}
+
 func waitForDone(state *nodeState, ctx context.Context) bool {
 	if atomic.LoadUint32(&state.done) != 0 {
 		return true
@@ -237,6 +385,29 @@ func executeNodeWorkerTask(task *nodeTask) { //nolint:gocyclo
 		}
 	}()
 
+	// An edge condition panicking while the inputs are gathered fails this
+	// node instead of crashing the worker.
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		err := panicFailure(name, r)
+		if node := ctx.graph.nodes[name]; node != nil {
+			failNode(node, err)
+		}
+		state.results = nil
+		state.err = nodeFailure(name, err)
+		if ctx.failures != nil {
+			ctx.failures.add(state.err)
+			return
+		}
+		select {
+		case ctx.errChan <- state.err:
+		default:
+		}
+	}()
+
 	if len(inEdges) == 0 {
 		hasValidInput = true
 	} else {
@@ -247,67 +418,93 @@ func executeNodeWorkerTask(task *nodeTask) { //nolint:gocyclo
 
 		completedCount := 0
 		requiredCount := 0
-		normalEdges := 0
 		for _, edge := range inEdges {
-			if edge.edgeType == EdgeTypeLoop {
-				continue
-			}
-			requiredCount++
-			if branchTargetNodes[edge.from] {
-				requiredCount--
-			} else {
-				normalEdges++
-			}
-		}
-
-		if normalEdges > 0 {
-			for _, edge := range inEdges {
-				if edge.edgeType == EdgeTypeLoop {
-					continue
-				}
-				if branchTargetNodes[edge.from] {
-					continue
-				}
-				fromState := ctx.states[edge.from]
-				if !waitForDone(fromState, ctx.ctx) {
-					return
-				}
-				if fromState.err != nil {
-					select {
-					case ctx.errChan <- fromState.err:
-					default:
-					}
-					return
-				}
-				if edge.condFunc == nil || edge.condFunc(fromState.results) {
-					inputsBuf = append(inputsBuf, fromState.results...)
-					completedCount++
-				}
+			if edge.edgeType != EdgeTypeLoop && !branchTargetNodes[edge.from] && !edge.optional {
+				requiredCount++
 			}
 		}
 
+		merged := false
 		for _, edge := range inEdges {
 			if edge.edgeType == EdgeTypeLoop {
 				continue
 			}
-			if !branchTargetNodes[edge.from] {
+			fromMerge := branchTargetNodes[edge.from]
+			if fromMerge && merged && !edge.optional {
 				continue
 			}
 			fromState := ctx.states[edge.from]
 			if !waitForDone(fromState, ctx.ctx) {
 				return
 			}
+			if fromState.bypassed && !edge.optional {
+				state.bypassed = true
+				return
+			}
+			if edge.onError {
+				if fromState.err == nil || isFatalError(fromState.err) {
+					state.bypassed = true
+					return
+				}
+				inputsBuf = append(inputsBuf, ctx.graph.nodeErr(edge.from))
+				if fromMerge {
+					merged = true
+				} else {
+					completedCount++
+				}
+				continue
+			}
 			if fromState.err != nil {
+				if ctx.graph.hasErrorEdge(edge.from) {
+					state.bypassed = true
+					return
+				}
+				if carried, ok := ctx.graph.carriedFailure(edge); ok {
+					inputsBuf = append(inputsBuf, carried...)
+					if fromMerge {
+						merged = true
+					} else {
+						completedCount++
+					}
+					continue
+				}
+				if ctx.failures != nil {
+					state.err = fromState.err
+					return
+				}
 				select {
 				case ctx.errChan <- fromState.err:
 				default:
 				}
 				return
 			}
-			if len(fromState.results) > 0 {
+			if edge.optional {
+				if ctx.graph.nodeRan(edge.from) && ctx.graph.edgeSelected(edge, fromState.results, ctx.branchMode) {
+					inputsBuf = append(inputsBuf, fromState.results...)
+					if edge.carryError {
+						inputsBuf = append(inputsBuf, nil)
+					}
+				} else {
+					inputsBuf = append(inputsBuf, ctx.graph.missingInputs(edge)...)
+				}
+				continue
+			}
+			if fromMerge {
+				if len(fromState.results) > 0 {
+					inputsBuf = append(inputsBuf, fromState.results...)
+					if edge.carryError {
+						inputsBuf = append(inputsBuf, nil)
+					}
+					merged = true
+				}
+				continue
+			}
+			if ctx.graph.edgeSelected(edge, fromState.results, ctx.branchMode) {
 				inputsBuf = append(inputsBuf, fromState.results...)
+				if edge.carryError {
+					inputsBuf = append(inputsBuf, nil)
+				}
 				completedCount++
-				break
 			}
 		}
 
@@ -322,10 +519,12 @@ func executeNodeWorkerTask(task *nodeTask) { //nolint:gocyclo
 		return
 	}
 
+	if len(inputs) == 0 && ctx.graph.inDegree[name] == 0 {
+		inputs = ctx.graph.startNodeInputs(name)
+	}
+
 	if ctx.graph.shouldPauseForSignal() {
-		ctx.graph.mu.Lock()
-		ctx.graph.pausedAtNode = name
-		ctx.graph.mu.Unlock()
+		ctx.graph.setPausedAt(name)
 		state.err = ErrFlowPaused
 		select {
 		case ctx.errChan <- state.err:
@@ -335,9 +534,7 @@ func executeNodeWorkerTask(task *nodeTask) { //nolint:gocyclo
 	}
 
 	if ctx.graph.shouldPauseAtNode(name) {
-		ctx.graph.mu.Lock()
-		ctx.graph.pausedAtNode = name
-		ctx.graph.mu.Unlock()
+		ctx.graph.setPausedAt(name)
 		state.err = ErrFlowPaused
 		select {
 		case ctx.errChan <- state.err:
@@ -346,10 +543,9 @@ func executeNodeWorkerTask(task *nodeTask) { //nolint:gocyclo
 		return
 	}
 
-	if !ctx.graph.checkResourceAvailable(name) {
-		ctx.graph.mu.Lock()
-		ctx.graph.pausedAtNode = name
-		ctx.graph.mu.Unlock()
+	release, ok := ctx.graph.acquireResources(name)
+	if !ok {
+		ctx.graph.setPausedAt(name)
 		state.err = ErrResourceNotAvailable
 		select {
 		case ctx.errChan <- state.err:
@@ -357,6 +553,7 @@ func executeNodeWorkerTask(task *nodeTask) { //nolint:gocyclo
 		}
 		return
 	}
+	defer release()
 
 	ctx.graph.mu.RLock()
 	node := ctx.graph.nodes[name]
@@ -367,7 +564,7 @@ func executeNodeWorkerTask(task *nodeTask) { //nolint:gocyclo
 	}
 
 	node.mu.RLock()
-	isCompleted := node.status == NodeStatusCompleted
+	isCompleted := node.status == NodeStatusCompleted || node.status == NodeStatusSkipped
 	var existingResult []any
 	if isCompleted && len(node.result) > 0 {
 		existingResult = make([]any, len(node.result))
@@ -380,14 +577,40 @@ func executeNodeWorkerTask(task *nodeTask) { //nolint:gocyclo
 		return
 	}
 
-	results, execErr := ctx.graph.executeNodeWithLoop(name, inputs)
+	if ctx.sem != nil {
+		select {
+		case ctx.sem <- struct{}{}:
+		case <-ctx.ctx.Done():
+			return
+		}
+	}
+	results, execErr := ctx.graph.executeNodeObserved(ctx.ctx, name, inputs)
+	if ctx.sem != nil {
+		<-ctx.sem
+	}
 	if execErr != nil {
-		if ctx.graph.pauseConfig != nil && ctx.graph.pauseConfig.OnErrorPause {
-			ctx.graph.mu.Lock()
-			ctx.graph.pausedAtNode = name
-			ctx.graph.mu.Unlock()
+		if errors.Is(execErr, ErrFlowPaused) {
+			ctx.graph.markNodePaused(name)
+			state.err = ErrFlowPaused
+			select {
+			case ctx.errChan <- state.err:
+			default:
+			}
+			return
+		}
+		fatal := isFatalError(execErr)
+		if !fatal && ctx.graph.hasCarryErrorEdge(name) {
+			state.err = nodeFailure(name, execErr)
+			return
+		}
+		if ctx.graph.pauseOnError(execErr) {
+			ctx.graph.setPausedAt(name)
+		}
+		state.err = nodeFailure(name, execErr)
+		if ctx.failures != nil && !fatal {
+			ctx.failures.add(state.err)
+			return
 		}
-		state.err = &FlowError{Message: fmt.Sprintf("node %s failed: %v", name, execErr)}
 		select {
 		case ctx.errChan <- state.err:
 		default:
@@ -396,9 +619,7 @@ func executeNodeWorkerTask(task *nodeTask) { //nolint:gocyclo
 	}
 
 	state.results = results
-	ctx.graph.mu.Lock()
-	ctx.graph.stepNames[name] = len(ctx.graph.stepNames)
-	ctx.graph.mu.Unlock()
+	ctx.graph.recordCompletion(name)
 }
 
 func (g *Graph) executeGraphParallelLarge(ctx context.Context) error {
@@ -409,36 +630,21 @@ func (g *Graph) executeGraphParallelLarge(ctx context.Context) error {
 
 	select {
 	case <-ctx.Done():
-		return &FlowError{Message: fmt.Sprintf("execution canceled: %v", ctx.Err())}
+		return canceledError(ctx)
 	default:
 	}
 
-	allEdges := g.edges
 	nodeCount := len(g.nodes)
 
 	var incomingEdges map[string][]*Edge
 	if g.execInEdges != nil && g.layersValid {
 		incomingEdges = g.execInEdges
 	} else {
-		if g.execInEdges == nil {
-			g.execInEdges = make(map[string][]*Edge, len(allEdges))
-		} else {
-			clear(g.execInEdges)
-		}
-		for _, edges := range allEdges {
-			for _, edge := range edges {
-				g.execInEdges[edge.to] = append(g.execInEdges[edge.to], edge)
-			}
-		}
+		g.buildExecInEdges()
 		incomingEdges = g.execInEdges
 	}
 
-	if g.execStates == nil {
-		g.execStates = make(map[string]*nodeState, nodeCount)
-	} else {
-		clear(g.execStates)
-	}
-	states := g.execStates
+	states := g.takeExecStates(nodeCount)
 	for _, layer := range layers {
 		for _, name := range layer {
 			state := nodeStatePool.Get()
@@ -450,6 +656,7 @@ func (g *Graph) executeGraphParallelLarge(ctx context.Context) error {
 	errChan := make(chan error, 1)
 	layerDone := make(chan struct{}, nodeCount)
 
+	ctx, cancel := context.WithCancel(ctx)
 	execCtx := &execContext{
 		graph:             g,
 		ctx:               ctx,
@@ -459,21 +666,40 @@ func (g *Graph) executeGraphParallelLarge(ctx context.Context) error {
 		branchTargetNodes: g.branchTargetNodes,
 		errChan:           errChan,
 		doneChan:          layerDone,
+		sem:               g.concurrencySemaphore(),
+		failures:          g.newFailureCollector(),
+		branchMode:        g.currentBranchMode(),
 	}
 
-	workerCount := defaultWorkerCount
-	if nodeCount < workerCount {
-		workerCount = nodeCount
+	var pool interface{ Submit(*nodeTask) error }
+	if g.workerPool != nil {
+		if g.workerPool.Closed() {
+			cancel()
+			return &FlowError{Kind: ErrWorkerPoolClosedErr, Message: ErrWorkerPoolClosed}
+		}
+		pool = g.workerPool
+	} else {
+		workerCount := defaultWorkerCount
+		if nodeCount < workerCount {
+			workerCount = nodeCount
+		}
+		localPool := newLocalWorkerPool(workerCount)
+		defer localPool.Shutdown()
+		pool = localPool
+	}
+	defer cancel()
+
+	stall, stallTimeout := g.stallWatchdog()
+	if stall != nil {
+		defer stall.Stop()
 	}
-	pool := newLocalWorkerPool(workerCount)
-	defer pool.Shutdown()
 
 	var execErr error
 
 	for _, layer := range layers {
 		select {
 		case <-ctx.Done():
-			return &FlowError{Message: fmt.Sprintf("execution canceled: %v", ctx.Err())}
+			return canceledError(ctx)
 		case err := <-errChan:
 			execErr = err
 			return execErr
@@ -484,7 +710,10 @@ func (g *Graph) executeGraphParallelLarge(ctx context.Context) error {
 			task := taskPool.Get().(*nodeTask)
 			task.ctx = execCtx
 			task.name = nodeName
-			pool.Submit(task)
+			if err := pool.Submit(task); err != nil {
+				taskPool.Put(task)
+				return err
+			}
 		}
 
 		layerTotal := len(layer)
@@ -492,19 +721,31 @@ func (g *Graph) executeGraphParallelLarge(ctx context.Context) error {
 		for layerCompleted < layerTotal {
 			select {
 			case <-ctx.Done():
-				return &FlowError{Message: fmt.Sprintf("execution canceled: %v", ctx.Err())}
+				return canceledError(ctx)
 			case err := <-errChan:
 				execErr = err
 				return execErr
+			case <-stallChan(stall):
+				return execCtx.stallError(stallTimeout)
 			case <-layerDone:
 				layerCompleted++
+				if stall != nil {
+					stall.Reset(stallTimeout)
+				}
 			}
 		}
 	}
+	execErr = execCtx.failures.err()
+	select {
+	case err := <-errChan:
+		execErr = err
+	default:
+	}
 
 	for _, state := range states {
 		nodeStatePool.Put(state)
 	}
+	g.execStates = states
 
 	return execErr
 }
@@ -0,0 +1,44 @@
+package flowtest
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zkep/flow"
+)
+
+func MustRun(t testing.TB, g *flow.Graph) {
+	t.Helper()
+	if err := g.Run(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func MustRunSequential(t testing.TB, g *flow.Graph) {
+	t.Helper()
+	if err := g.RunSequential(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func AssertNodeResult[T any](t testing.TB, g *flow.Graph, nodeName string, want T) {
+	t.Helper()
+	got, err := flow.GraphNodeResultAs[T](g, nodeName)
+	if err != nil {
+		t.Fatalf("Expected node %q result %v: %v", nodeName, want, err)
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("Expected node %q result to be %v, got %v", nodeName, want, got)
+	}
+}
+
+func AssertNodeStatus(t testing.TB, g *flow.Graph, nodeName string, want flow.NodeStatus) {
+	t.Helper()
+	got, err := g.NodeStatus(nodeName)
+	if err != nil {
+		t.Fatalf("Expected node %q status %v: %v", nodeName, want, err)
+	}
+	if got != want {
+		t.Fatalf("Expected node %q status to be %v, got %v", nodeName, want, got)
+	}
+}
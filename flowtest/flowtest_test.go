@@ -0,0 +1,28 @@
+package flowtest
+
+import (
+	"testing"
+
+	"github.com/zkep/flow"
+)
+
+func TestHelpers(t *testing.T) {
+	graph := flow.NewGraph()
+	graph.AddNode("load", func() []int { return []int{1, 2, 3} })
+	graph.AddNode("sum", func(values []int) int {
+		total := 0
+		for _, v := range values {
+			total += v
+		}
+		return total
+	})
+	graph.AddNode("skipped", func(n int) int { return n })
+	graph.AddEdge("load", "sum")
+	graph.AddEdgeWithCondition("sum", "skipped", func(n int) bool { return n < 0 })
+
+	MustRun(t, graph)
+	AssertNodeResult(t, graph, "load", []int{1, 2, 3})
+	AssertNodeResult(t, graph, "sum", 6)
+	AssertNodeStatus(t, graph, "sum", flow.NodeStatusCompleted)
+	AssertNodeStatus(t, graph, "skipped", flow.NodeStatusPending)
+}
@@ -2,19 +2,38 @@ package flow
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"math/rand/v2"
 	"reflect"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
+	"time"
+	"unicode"
 )
 
 const (
-	ErrNodeNotFound     = "node not found"
-	ErrDuplicateNode    = "duplicate node name"
-	ErrSelfDependency   = "node cannot depend on itself"
-	ErrCyclicDependency = "cyclic dependency detected"
-	ErrNoStartNode      = "no start node found"
-	ErrExecutionFailed  = "execution failed"
+	ErrNodeNotFound      = "node not found"
+	ErrEdgeNotFound      = "edge not found"
+	ErrDuplicateNode     = "duplicate node name"
+	ErrSelfDependency    = "node cannot depend on itself"
+	ErrCyclicDependency  = "cyclic dependency detected"
+	ErrNoStartNode       = "no start node found"
+	ErrExecutionFailed   = "execution failed"
+	ErrNodeNotCompleted  = "node not completed"
+	ErrNoResult          = "no result"
+	ErrResultType        = "result type mismatch"
+	ErrGraphRunning      = "graph is running"
+	ErrGraphFrozen       = "graph is frozen"
+	ErrNotStartNode      = "not a start node"
+	ErrLoopMaxIterations = "loop exceeded max iterations"
+	ErrInvalidInput      = "invalid node input"
+	ErrExecutionCanceled = "execution canceled"
+	ErrInvalidLoopEdge   = "loop edge must have same from and to node"
+	ErrInvalidBranchCond = "branch condition must be a function"
 )
 
 const (
@@ -28,6 +47,7 @@ const (
 	NodeStatusRunning
 	NodeStatusCompleted
 	NodeStatusFailed
+	NodeStatusSkipped
 )
 
 type EdgeType int
@@ -38,16 +58,39 @@ const (
 	EdgeTypeBranch
 )
 
+type BranchMode int
+
+const (
+	BranchModeAll BranchMode = iota
+	BranchModeFirstMatch
+)
+
+type BranchCase struct {
+	To   string
+	Cond any
+}
+
 type CondFunc func([]any) bool
 
 type Edge struct {
-	from     string
-	to       string
-	cond     any
-	condFunc CondFunc
-	condComp *condCompiler
-	weight   int
-	edgeType EdgeType
+	from       string
+	to         string
+	cond       any
+	condFunc   CondFunc
+	stateCond  StateCondFunc
+	condComp   *condCompiler
+	weight     int
+	edgeType   EdgeType
+	carryError bool
+	onError    bool
+	optional   bool
+	errorOnMax bool
+	priority   int
+	label      string
+	isDefault  bool
+	seq        int
+	stopCtx    context.Context
+	stopChan   <-chan struct{}
 }
 
 type Node struct {
@@ -64,15 +107,48 @@ type Node struct {
 	outputs        []string
 	err            error
 	result         []any
-	callFn         func([]any) ([]any, error)
+	callFn         func(context.Context, []any) ([]any, error)
+	hasContext     bool
+	hasLoopInfo    bool
 	argCount       int
 	sliceArg       bool
+	sliceAsSingle  bool
+	variadic       bool
+	skip           bool
+	skipIf         func(inputs []any) bool
+	validator      func(inputs []any) error
 	sliceElemType  reflect.Type
+	retry          *RetryPolicy
+	meta           map[string]string
+	tags           []string
+	startedAt      time.Time
+	finishedAt     time.Time
+	interrupted    bool
+	pruned         bool
+	compensate     CompensationFunc
+	lastInputs     []any
+	cache          *nodeCache
+	subGraph       *Graph
 	mu             sync.RWMutex
 }
 
+type LoopInfo struct {
+	Iteration int
+	Max       int
+}
+
+type loopInfoKey struct{}
+
+func loopInfoFromContext(ctx context.Context) LoopInfo {
+	if info, ok := ctx.Value(loopInfoKey{}).(LoopInfo); ok {
+		return info
+	}
+	return LoopInfo{Iteration: 1, Max: 1}
+}
+
 type Graph struct {
 	nodes             map[string]*Node
+	nodeOrder         []string
 	edges             map[string][]*Edge
 	inDegree          map[string]int
 	outDegree         map[string]int
@@ -85,15 +161,52 @@ type Graph struct {
 	branchTargetNodes map[string]bool
 	tempInDegree      map[string]int
 	visited           map[string]bool
-	path              map[string]bool
 	execStates        map[string]*nodeState
 	layers            [][]string
 	layersValid       bool
 	largeThreshold    int
+	fairScheduling    bool
 	pauseConfig       *PauseConfig
 	pauseSignal       PauseSignal
 	resourceChecker   ResourceChecker
 	pausedAtNode      string
+	pausedCh          chan string
+	resumePast        string
+	observers         []Observer
+	tracer            Tracer
+	showDurations     bool
+	startInputs       []any
+	autoCheckpoint    *autoCheckpoint
+	maxConcurrency    int
+	workerPool        *WorkerPool
+	cancelRun         context.CancelFunc
+	canceled          bool
+	running           bool
+	progressHandler   ProgressHandler
+	resultInterceptor ResultInterceptor
+	inputInterceptor  InputInterceptor
+	progressMu        sync.Mutex
+	inputOverrides    map[string][]any
+	errorMode         ErrorMode
+	branchMode        BranchMode
+	nodeResources     map[string]map[string]int
+	concurrencyKeys   map[string]string
+	randMu            sync.Mutex
+	rng               *rand.Rand
+	weightedPicks     map[string]string
+	branchMu          sync.Mutex
+	branchPicks       map[string]*Edge
+	subRunMu          sync.Mutex
+	isSubGraph        bool
+	keyLocks          map[string]chan struct{}
+	stallTimeout      time.Duration
+	edgeSeq           int
+	runStartedAt      time.Time
+	runFinishedAt     time.Time
+	frozen            bool
+	inflight          inflightNodes
+	replayLog         *replayLog
+	replaying         map[string]*replayRecord
 }
 
 const (
@@ -122,6 +235,12 @@ func WithLargeGraphThreshold(threshold int) GraphOption {
 	}
 }
 
+func WithWorkerPool(pool *WorkerPool) GraphOption {
+	return func(g *Graph) {
+		g.workerPool = pool
+	}
+}
+
 func NewGraph(opts ...GraphOption) *Graph {
 	g := &Graph{}
 	for _, opt := range opts {
@@ -137,17 +256,97 @@ func NewGraph(opts ...GraphOption) *Graph {
 	return g
 }
 
-func (g *Graph) AddNode(name string, fn any) *Graph {
+type NodeOption func(*Node)
+
+func WithMeta(meta map[string]string) NodeOption {
+	return func(n *Node) {
+		if n.meta == nil {
+			n.meta = make(map[string]string, len(meta))
+		}
+		for k, v := range meta {
+			n.meta[k] = v
+		}
+	}
+}
+
+func WithTags(tags ...string) NodeOption {
+	return func(n *Node) {
+		for _, tag := range tags {
+			if !slices.Contains(n.tags, tag) {
+				n.tags = append(n.tags, tag)
+			}
+		}
+	}
+}
+
+func WithSliceAsSingle() NodeOption {
+	return func(n *Node) {
+		n.sliceAsSingle = true
+	}
+}
+
+func WithValidator(validate func(inputs []any) error) NodeOption {
+	return func(n *Node) {
+		n.validator = validate
+	}
+}
+
+func AddTypedNode[In, Out any](g *Graph, name string, fn func(In) Out, opts ...NodeOption) *Graph {
+	return g.AddNode(name, fn, opts...)
+}
+
+func (g *Graph) AddNodeWithTags(name string, fn any, tags ...string) *Graph {
+	return g.AddNode(name, fn, WithTags(tags...))
+}
+
+func (g *Graph) NodesByTag(tag string) []string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	names := make([]string, 0)
+	for _, name := range g.nodeOrder {
+		if slices.Contains(g.nodes[name].tags, tag) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (g *Graph) NodeTags(nodeName string) ([]string, error) {
+	g.mu.RLock()
+	node, ok := g.nodes[nodeName]
+	g.mu.RUnlock()
+	if !ok {
+		return nil, &FlowError{Kind: ErrNodeNotFoundErr, Message: ErrNodeNotFound}
+	}
+	return slices.Clone(node.tags), nil
+}
+
+func (g *Graph) AddNode(name string, fn any, opts ...NodeOption) *Graph {
+	g.tryAddNode(name, fn, opts...)
+	return g
+}
+
+func (g *Graph) tryAddNode(name string, fn any, opts ...NodeOption) bool {
 	if g.err != nil {
-		return g
+		return false
+	}
+	if err := g.AddNodeE(name, fn, opts...); err != nil {
+		g.err = err
+		return false
 	}
+	return true
+}
 
+func (g *Graph) AddNodeE(name string, fn any, opts ...NodeOption) error {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
+	if g.frozen {
+		return &FlowError{Kind: ErrGraphFrozenErr, Message: fmt.Sprintf("%s: cannot add node %s", ErrGraphFrozen, name)}
+	}
 	if _, exists := g.nodes[name]; exists {
-		g.err = &FlowError{Message: ErrDuplicateNode}
-		return g
+		return &FlowError{Kind: ErrDuplicateNodeErr, Message: ErrDuplicateNode}
 	}
 
 	g.execPlanValid = false
@@ -156,41 +355,338 @@ func (g *Graph) AddNode(name string, fn any) *Graph {
 	*node = Node{
 		name:   name,
 		status: NodeStatusPending,
-		fn:     fn,
 	}
 
-	if fn != nil {
-		node.fnValue = reflect.ValueOf(fn)
-		node.fnType = node.fnValue.Type()
-		if node.fnType.Kind() != reflect.Func {
-			g.err = &FlowError{Message: ErrNotFunction}
-			return g
-		}
-		numIn := node.fnType.NumIn()
-		node.argCount = numIn
-		node.argTypes = make([]reflect.Type, numIn)
-		for i := range numIn {
-			node.argTypes[i] = node.fnType.In(i)
-		}
-		if numIn == 1 && node.argTypes[0].Kind() == reflect.Slice {
-			node.sliceArg = true
-			node.sliceElemType = node.argTypes[0].Elem()
-		}
-		node.numOut = node.fnType.NumOut()
-		if node.numOut > 0 {
-			lastOutType := node.fnType.Out(node.numOut - 1)
-			node.hasErrorReturn = lastOutType.Implements(errorType)
-		}
-		node.callFn = g.compileNodeCall(node)
+	for _, opt := range opts {
+		opt(node)
+	}
+
+	if err := g.bindNodeFunc(node, fn); err != nil {
+		return err
 	}
 
 	g.nodes[name] = node
+	g.nodeOrder = append(g.nodeOrder, name)
 	g.inDegree[name] = 0
 	g.outDegree[name] = 0
 
+	return nil
+}
+
+func (g *Graph) Freeze() *Graph {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.frozen = true
 	return g
 }
 
+func (g *Graph) Frozen() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.frozen
+}
+
+func (g *Graph) ReplaceNode(name string, fn any) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	node, ok := g.nodes[name]
+	if !ok {
+		return &FlowError{Kind: ErrNodeNotFoundErr, Message: fmt.Sprintf("%s: %s", ErrNodeNotFound, name)}
+	}
+	if g.frozen {
+		return &FlowError{Kind: ErrGraphFrozenErr, Message: fmt.Sprintf("%s: cannot replace node %s", ErrGraphFrozen, name)}
+	}
+	if g.running {
+		return &FlowError{Kind: ErrGraphRunningErr, Message: ErrGraphRunning}
+	}
+
+	candidate := &Node{name: name}
+	if err := g.bindNodeFunc(candidate, fn); err != nil {
+		return err
+	}
+
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	arityChanged := candidate.argCount != node.argCount || candidate.variadic != node.variadic
+	if arityChanged && g.inDegree[name] > 0 && !g.runStartedAt.IsZero() {
+		return &FlowError{Kind: ErrArgCountMismatchErr, Message: fmt.Sprintf("%s: %s takes %d arguments, had %d; call ClearStatus first",
+			ErrArgCountMismatch, name, candidate.argCount, node.argCount)}
+	}
+
+	_ = g.bindNodeFunc(node, fn)
+	if node.cache != nil {
+		node.cache = &nodeCache{entries: make(map[string][]any)}
+		node.callFn = node.cache.wrap(node.callFn)
+	}
+	if arityChanged {
+		g.execPlanValid = false
+		g.layersValid = false
+	}
+	return nil
+}
+
+func (g *Graph) RemoveNode(name string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, ok := g.nodes[name]; !ok {
+		return &FlowError{Kind: ErrNodeNotFoundErr, Message: fmt.Sprintf("%s: %s", ErrNodeNotFound, name)}
+	}
+	if g.frozen {
+		return &FlowError{Kind: ErrGraphFrozenErr, Message: fmt.Sprintf("%s: cannot remove node %s", ErrGraphFrozen, name)}
+	}
+	if g.running {
+		return &FlowError{Kind: ErrGraphRunningErr, Message: ErrGraphRunning}
+	}
+
+	for from := range g.edges {
+		g.removeEdges(from, name)
+	}
+	for _, edge := range g.edges[name] {
+		g.removeEdges(name, edge.to)
+	}
+	delete(g.edges, name)
+	delete(g.nodes, name)
+	delete(g.inDegree, name)
+	delete(g.outDegree, name)
+	delete(g.stepNames, name)
+	delete(g.nodeResources, name)
+	delete(g.concurrencyKeys, name)
+	g.nodeOrder = slices.DeleteFunc(g.nodeOrder, func(n string) bool { return n == name })
+	g.invalidatePlan()
+	return nil
+}
+
+func (g *Graph) RemoveEdge(from, to string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.frozen {
+		return &FlowError{Kind: ErrGraphFrozenErr, Message: fmt.Sprintf("%s: cannot remove edge %s -> %s", ErrGraphFrozen, from, to)}
+	}
+	if g.running {
+		return &FlowError{Kind: ErrGraphRunningErr, Message: ErrGraphRunning}
+	}
+	if !g.removeEdges(from, to) {
+		return &FlowError{Kind: ErrEdgeNotFoundErr, Message: fmt.Sprintf("%s: %s -> %s", ErrEdgeNotFound, from, to)}
+	}
+	g.invalidatePlan()
+	return nil
+}
+
+func (g *Graph) removeEdges(from, to string) bool {
+	edges := g.edges[from]
+	kept := make([]*Edge, 0, len(edges))
+	for _, edge := range edges {
+		if edge.to != to {
+			kept = append(kept, edge)
+			continue
+		}
+		if edge.edgeType == EdgeTypeNormal || edge.edgeType == EdgeTypeBranch {
+			g.inDegree[to]--
+			g.outDegree[from]--
+		}
+	}
+	if len(kept) == len(edges) {
+		return false
+	}
+	if len(kept) == 0 {
+		delete(g.edges, from)
+	} else {
+		g.edges[from] = kept
+	}
+	return true
+}
+
+func (g *Graph) invalidatePlan() {
+	g.execPlanValid = false
+	g.layersValid = false
+	g.execInEdges = nil
+}
+
+func (g *Graph) bindNodeFunc(node *Node, fn any) error {
+	node.fn = fn
+	node.fnValue = reflect.Value{}
+	node.fnType = nil
+	node.argTypes = nil
+	node.numOut = 0
+	node.hasErrorReturn = false
+	node.callFn = nil
+	node.hasContext = false
+	node.hasLoopInfo = false
+	node.argCount = 0
+	node.sliceArg = false
+	node.variadic = false
+	node.sliceElemType = nil
+	if fn == nil {
+		return nil
+	}
+
+	node.fnValue = reflect.ValueOf(fn)
+	node.fnType = node.fnValue.Type()
+	if node.fnType.Kind() != reflect.Func {
+		return &FlowError{Kind: ErrNotFunctionErr, Message: ErrNotFunction}
+	}
+	numIn := node.fnType.NumIn()
+	offset := 0
+	if numIn > 0 && node.fnType.In(0) == contextType {
+		node.hasContext = true
+		offset = 1
+	}
+	node.argCount = numIn - offset
+	if node.argCount > 0 && node.fnType.In(numIn-1) == loopInfoType {
+		node.hasLoopInfo = true
+		node.argCount--
+	}
+	node.argTypes = make([]reflect.Type, node.argCount)
+	for i := range node.argCount {
+		node.argTypes[i] = node.fnType.In(i + offset)
+	}
+	node.variadic = node.fnType.IsVariadic()
+	if node.argCount == 1 && node.argTypes[0].Kind() == reflect.Slice && !node.variadic && !node.sliceAsSingle {
+		node.sliceArg = true
+		node.sliceElemType = node.argTypes[0].Elem()
+	}
+	node.numOut = node.fnType.NumOut()
+	if node.numOut > 0 {
+		lastOutType := node.fnType.Out(node.numOut - 1)
+		node.hasErrorReturn = lastOutType.Implements(errorType)
+	}
+	node.callFn = g.compileNodeCall(node)
+	return nil
+}
+
+func (g *Graph) Clone() *Graph {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	clone := g.cloneSettings()
+	for _, name := range g.nodeOrder {
+		node := g.nodes[name]
+		node.mu.RLock()
+		n := node.cloneConfig()
+		if node.retry != nil {
+			retry := *node.retry
+			n.retry = &retry
+		}
+		if node.meta != nil {
+			WithMeta(node.meta)(n)
+		}
+		node.mu.RUnlock()
+		clone.nodes[name] = n
+		clone.nodeOrder = append(clone.nodeOrder, name)
+	}
+
+	for from, edges := range g.edges {
+		cloned := make([]*Edge, 0, len(edges))
+		for _, edge := range edges {
+			e := edgePool.Get()
+			*e = Edge{
+				from:       edge.from,
+				to:         edge.to,
+				cond:       edge.cond,
+				condFunc:   edge.condFunc,
+				condComp:   edge.condComp,
+				stateCond:  edge.stateCond,
+				weight:     edge.weight,
+				priority:   edge.priority,
+				edgeType:   edge.edgeType,
+				carryError: edge.carryError,
+				onError:    edge.onError,
+				optional:   edge.optional,
+				errorOnMax: edge.errorOnMax,
+				label:      edge.label,
+				isDefault:  edge.isDefault,
+				seq:        edge.seq,
+				stopCtx:    edge.stopCtx,
+				stopChan:   edge.stopChan,
+			}
+			cloned = append(cloned, e)
+		}
+		clone.edges[from] = cloned
+	}
+
+	for name, degree := range g.inDegree {
+		clone.inDegree[name] = degree
+	}
+	for name, degree := range g.outDegree {
+		clone.outDegree[name] = degree
+	}
+
+	return clone
+}
+
+func (g *Graph) cloneSettings() *Graph {
+	clone := NewGraph(WithCapacity(len(g.nodes)))
+	clone.err = g.err
+	clone.largeThreshold = g.largeThreshold
+	clone.fairScheduling = g.fairScheduling
+	clone.isSubGraph = g.isSubGraph
+	clone.maxConcurrency = g.maxConcurrency
+	clone.errorMode = g.errorMode
+	clone.branchMode = g.branchMode
+	clone.stallTimeout = g.stallTimeout
+	clone.edgeSeq = g.edgeSeq
+	for name, requirements := range g.nodeResources {
+		if clone.nodeResources == nil {
+			clone.nodeResources = make(map[string]map[string]int, len(g.nodeResources))
+		}
+		clone.nodeResources[name] = maps.Clone(requirements)
+	}
+	clone.concurrencyKeys = maps.Clone(g.concurrencyKeys)
+	g.randMu.Lock()
+	if g.rng != nil {
+		clone.SetRandSeed(g.rng.Int64())
+	}
+	g.randMu.Unlock()
+	clone.workerPool = g.workerPool
+	clone.showDurations = g.showDurations
+	clone.pauseConfig = g.pauseConfig
+	clone.pauseSignal = g.pauseSignal
+	clone.resourceChecker = g.resourceChecker
+	clone.observers = append([]Observer(nil), g.observers...)
+	clone.tracer = g.tracer
+	clone.progressHandler = g.progressHandler
+	clone.resultInterceptor = g.resultInterceptor
+	clone.inputInterceptor = g.inputInterceptor
+	return clone
+}
+
+func (n *Node) cloneConfig() *Node {
+	clone := nodePool.Get()
+	*clone = Node{
+		name:           n.name,
+		status:         NodeStatusPending,
+		fn:             n.fn,
+		fnValue:        n.fnValue,
+		fnType:         n.fnType,
+		argTypes:       n.argTypes,
+		numOut:         n.numOut,
+		hasErrorReturn: n.hasErrorReturn,
+		description:    n.description,
+		callFn:         n.callFn,
+		argCount:       n.argCount,
+		hasContext:     n.hasContext,
+		hasLoopInfo:    n.hasLoopInfo,
+		sliceArg:       n.sliceArg,
+		sliceAsSingle:  n.sliceAsSingle,
+		variadic:       n.variadic,
+		skip:           n.skip,
+		skipIf:         n.skipIf,
+		validator:      n.validator,
+		sliceElemType:  n.sliceElemType,
+		tags:           slices.Clone(n.tags),
+		compensate:     n.compensate,
+		cache:          n.cache,
+	}
+	if n.subGraph != nil {
+		clone.subGraph = n.subGraph.Clone()
+		clone.callFn = clone.subGraph.runAsNode()
+	}
+	return clone
+}
+
 type EdgeOption func(*Edge)
 
 func WithEdgeType(t EdgeType) EdgeOption {
@@ -211,22 +707,102 @@ func WithMaxIterations(max int) EdgeOption {
 	}
 }
 
+func WithLoopErrorOnMax() EdgeOption {
+	return func(e *Edge) {
+		e.errorOnMax = true
+	}
+}
+
+func WithPriority(p int) EdgeOption {
+	return func(e *Edge) {
+		e.priority = p
+	}
+}
+
+func WithLabel(label string) EdgeOption {
+	return func(e *Edge) {
+		e.label = label
+	}
+}
+
+func WithCarryError() EdgeOption {
+	return func(e *Edge) {
+		e.carryError = true
+	}
+}
+
+func WithOptional() EdgeOption {
+	return func(e *Edge) {
+		e.optional = true
+	}
+}
+
+func WithLoopStop(ctx context.Context, stop <-chan struct{}) EdgeOption {
+	return func(e *Edge) {
+		e.stopCtx = ctx
+		e.stopChan = stop
+	}
+}
+
+func (e *Edge) stopRequested() bool {
+	var done <-chan struct{}
+	if e.stopCtx != nil {
+		done = e.stopCtx.Done()
+	}
+	select {
+	case <-done:
+		return true
+	case <-e.stopChan:
+		return true
+	default:
+		return false
+	}
+}
+
 func (g *Graph) AddEdge(from, to string, opts ...EdgeOption) *Graph {
 	if g.err != nil {
 		return g
 	}
 
+	if err := g.AddEdgeE(from, to, opts...); err != nil {
+		g.err = err
+	}
+	return g
+}
+
+func (g *Graph) AddNodes(nodes map[string]any) *Graph {
+	names := make([]string, 0, len(nodes))
+	for name := range nodes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		g.AddNode(name, nodes[name])
+	}
+	return g
+}
+
+func (g *Graph) AddEdges(edges [][2]string) *Graph {
+	for _, edge := range edges {
+		g.AddEdge(edge[0], edge[1])
+	}
+	return g
+}
+
+func (g *Graph) AddEdgeE(from, to string, opts ...EdgeOption) error {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
+	if g.frozen {
+		return &FlowError{Kind: ErrGraphFrozenErr, Message: fmt.Sprintf("%s: cannot add edge %s -> %s", ErrGraphFrozen, from, to)}
+	}
+
 	if _, exists := g.nodes[from]; !exists {
-		g.err = &FlowError{Message: fmt.Sprintf("%s: %s", ErrNodeNotFound, from)}
-		return g
+		return &FlowError{Kind: ErrNodeNotFoundErr, Message: fmt.Sprintf("%s: %s", ErrNodeNotFound, from)}
 	}
 
 	if _, exists := g.nodes[to]; !exists {
-		g.err = &FlowError{Message: fmt.Sprintf("%s: %s", ErrNodeNotFound, to)}
-		return g
+		return &FlowError{Kind: ErrNodeNotFoundErr, Message: fmt.Sprintf("%s: %s", ErrNodeNotFound, to)}
 	}
 
 	edge := edgePool.Get()
@@ -240,30 +816,39 @@ func (g *Graph) AddEdge(from, to string, opts ...EdgeOption) *Graph {
 		opt(edge)
 	}
 
-	if edge.cond != nil {
-		edge.condFunc = g.compileCondition(edge.cond)
+	switch cond := edge.cond.(type) {
+	case nil:
+	case StateCondFunc:
+		edge.stateCond = cond
+	case func(*GraphView) bool:
+		edge.stateCond = cond
+	default:
+		edge.condFunc, edge.condComp = compileCond(cond)
 	}
 
 	switch edge.edgeType {
 	case EdgeTypeLoop:
 		if from != to {
-			g.err = &FlowError{Message: "loop edge must have same from and to node"}
-			return g
+			edgePool.Put(edge)
+			return &FlowError{Kind: ErrInvalidLoopEdgeErr, Message: ErrInvalidLoopEdge}
 		}
 		if edge.weight <= 0 {
 			edge.weight = DefaultMaxIterations
 		}
 	case EdgeTypeNormal, EdgeTypeBranch:
 		if from == to {
-			g.err = &FlowError{Message: ErrSelfDependency}
-			return g
+			edgePool.Put(edge)
+			return &FlowError{Kind: ErrSelfDependencyErr, Message: ErrSelfDependency}
 		}
 		if g.HasCycle(from, to) {
-			g.err = &FlowError{Message: ErrCyclicDependency}
-			return g
+			edgePool.Put(edge)
+			cycle := append([]string{from}, g.findPath(to, from)...)
+			return &FlowError{Kind: ErrCyclicDependencyErr, Message: fmt.Sprintf("%s: %s", ErrCyclicDependency, strings.Join(cycle, " -> "))}
 		}
 	}
 
+	edge.seq = g.edgeSeq
+	g.edgeSeq++
 	g.edges[from] = append(g.edges[from], edge)
 	if edge.edgeType == EdgeTypeNormal || edge.edgeType == EdgeTypeBranch {
 		g.inDegree[to]++
@@ -271,11 +856,21 @@ func (g *Graph) AddEdge(from, to string, opts ...EdgeOption) *Graph {
 	}
 	g.execPlanValid = false
 
-	return g
+	return nil
+}
+
+func (g *Graph) AddEdgeWithCondition(from, to string, cond any, opts ...EdgeOption) *Graph {
+	return g.AddEdge(from, to, append([]EdgeOption{WithCondition(cond)}, opts...)...)
+}
+
+func (g *Graph) AddErrorEdge(from, to string, opts ...EdgeOption) *Graph {
+	return g.AddEdge(from, to, append([]EdgeOption{withOnError(), WithLabel("error")}, opts...)...)
 }
 
-func (g *Graph) AddEdgeWithCondition(from, to string, cond any) *Graph {
-	return g.AddEdge(from, to, WithCondition(cond))
+func withOnError() EdgeOption {
+	return func(e *Edge) {
+		e.onError = true
+	}
 }
 
 func (g *Graph) AddLoopEdge(nodeName string, cond any, maxIterations ...int) *Graph {
@@ -287,8 +882,8 @@ func (g *Graph) AddLoopEdge(nodeName string, cond any, maxIterations ...int) *Gr
 }
 
 func (g *Graph) AddBranchEdge(from string, branches map[string]any) *Graph {
-	for to, cond := range branches {
-		g.AddEdge(from, to, WithEdgeType(EdgeTypeBranch), WithCondition(cond))
+	for _, to := range slices.Sorted(maps.Keys(branches)) {
+		g.AddEdge(from, to, WithEdgeType(EdgeTypeBranch), WithCondition(branches[to]), WithLabel(to))
 		if g.err != nil {
 			return g
 		}
@@ -296,6 +891,114 @@ func (g *Graph) AddBranchEdge(from string, branches map[string]any) *Graph {
 	return g
 }
 
+func (g *Graph) AddOrderedBranchEdge(from string, cases []BranchCase) *Graph {
+	for _, c := range cases {
+		g.AddEdge(from, c.To, WithEdgeType(EdgeTypeBranch), WithCondition(c.Cond), WithLabel(c.To))
+		if g.err != nil {
+			return g
+		}
+	}
+	return g
+}
+
+func (g *Graph) SetBranchMode(mode BranchMode) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.branchMode = mode
+}
+
+func (g *Graph) currentBranchMode() BranchMode {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.branchMode
+}
+
+func (g *Graph) edgeSelected(edge *Edge, results []any, mode BranchMode) bool {
+	if edge.edgeType != EdgeTypeBranch || mode != BranchModeFirstMatch {
+		return g.condMatches(edge, results)
+	}
+	return g.firstMatch(edge.from, results) == edge
+}
+
+func (g *Graph) firstMatch(from string, results []any) *Edge {
+	g.branchMu.Lock()
+	defer g.branchMu.Unlock()
+
+	if edge, ok := g.branchPicks[from]; ok {
+		return edge
+	}
+	var picked *Edge
+	for _, sibling := range g.edges[from] {
+		if sibling.edgeType == EdgeTypeBranch && g.condMatches(sibling, results) {
+			picked = sibling
+			break
+		}
+	}
+	if g.branchPicks == nil {
+		g.branchPicks = make(map[string]*Edge)
+	}
+	g.branchPicks[from] = picked
+	return picked
+}
+
+func (g *Graph) resetBranchPicks() {
+	g.branchMu.Lock()
+	defer g.branchMu.Unlock()
+	clear(g.branchPicks)
+}
+
+func (g *Graph) AddBranchEdgeWithDefault(from string, branches map[string]any, defaultTarget string) *Graph {
+	if g.err != nil {
+		return g
+	}
+	for _, to := range slices.Sorted(maps.Keys(branches)) {
+		cond := reflect.ValueOf(branches[to])
+		if cond.Kind() != reflect.Func || cond.IsNil() {
+			g.err = &FlowError{Kind: ErrInvalidBranchCondErr, Message: fmt.Sprintf("%s: %s -> %s", ErrInvalidBranchCond, from, to)}
+			return g
+		}
+	}
+
+	g.AddBranchEdge(from, branches)
+	if g.err != nil {
+		return g
+	}
+
+	g.mu.RLock()
+	conds := make([]CondFunc, 0, len(branches))
+	for _, edge := range g.edges[from] {
+		if _, ok := branches[edge.to]; !ok || edge.edgeType != EdgeTypeBranch {
+			continue
+		}
+		if stateCond := edge.stateCond; stateCond != nil {
+			conds = append(conds, func([]any) bool { return stateCond(&GraphView{graph: g}) })
+		} else {
+			conds = append(conds, edge.condFunc)
+		}
+	}
+	g.mu.RUnlock()
+
+	return g.AddEdge(from, defaultTarget, WithEdgeType(EdgeTypeBranch), WithCondition(branchFallback(conds)),
+		WithLabel("default"), withDefaultBranch())
+}
+
+func withDefaultBranch() EdgeOption {
+	return func(e *Edge) {
+		e.isDefault = true
+	}
+}
+
+func branchFallback(conds []CondFunc) CondFunc {
+	return func(results []any) bool {
+		for _, cond := range conds {
+			if cond(results) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
 func (g *Graph) HasCycle(from, to string) bool {
 	if g.visited == nil {
 		g.visited = make(map[string]bool, len(g.nodes))
@@ -304,91 +1007,287 @@ func (g *Graph) HasCycle(from, to string) bool {
 	}
 	visited := g.visited
 
-	if g.path == nil {
-		g.path = make(map[string]bool, len(g.nodes))
-	} else {
-		clear(g.path)
-	}
-	path := g.path
-
 	stack := []string{to}
-	index := 0
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
 
-	for index >= 0 {
-		node := stack[index]
-
-		if path[node] {
+		if node == from {
 			return true
 		}
-
 		if visited[node] {
-			index--
 			continue
 		}
-
-		path[node] = true
 		visited[node] = true
 
-		hasUnvisited := false
 		for _, edge := range g.edges[node] {
 			if edge.edgeType == EdgeTypeLoop {
 				continue
 			}
-			nextNode := edge.to
-			if nextNode == from {
-				return true
+			if !visited[edge.to] {
+				stack = append(stack, edge.to)
 			}
-			if !visited[nextNode] {
-				stack = append(stack, nextNode)
-				index++
-				hasUnvisited = true
-				break
+		}
+	}
+
+	return false
+}
+
+func (g *Graph) findPath(start, target string) []string {
+	parent := make(map[string]string, len(g.nodes))
+	parent[start] = ""
+	stack := []string{start}
+
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if node == target {
+			var path []string
+			for n := target; n != start; n = parent[n] {
+				path = append(path, n)
 			}
+			path = append(path, start)
+			slices.Reverse(path)
+			return path
 		}
 
-		if !hasUnvisited {
-			path[node] = false
-			index--
+		for _, edge := range g.edges[node] {
+			if edge.edgeType == EdgeTypeLoop {
+				continue
+			}
+			if _, seen := parent[edge.to]; !seen {
+				parent[edge.to] = node
+				stack = append(stack, edge.to)
+			}
 		}
 	}
 
-	return false
+	return nil
+}
+
+func (g *Graph) FindCycle() []string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	names := make([]string, 0, len(g.nodes))
+	for name := range g.nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	const (
+		unvisited = iota
+		inProgress
+		done
+	)
+	state := make(map[string]int, len(g.nodes))
+	var path []string
+	var cycle []string
+
+	var visit func(name string) bool
+	visit = func(name string) bool {
+		state[name] = inProgress
+		path = append(path, name)
+		for _, edge := range g.edges[name] {
+			if edge.edgeType == EdgeTypeLoop {
+				continue
+			}
+			switch state[edge.to] {
+			case inProgress:
+				start := slices.Index(path, edge.to)
+				cycle = append(append([]string{}, path[start:]...), edge.to)
+				return true
+			case unvisited:
+				if visit(edge.to) {
+					return true
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = done
+		return false
+	}
+
+	for _, name := range names {
+		if state[name] == unvisited && visit(name) {
+			return cycle
+		}
+	}
+	return nil
 }
 
 func (g *Graph) executeNodeWithLoop(
+	ctx context.Context,
 	nodeName string,
 	inputs []any,
-) ([]any, error) {
-	results, err := g.executeNode(nodeName, inputs)
-	if err != nil {
-		return nil, err
+) (_ []any, err error) {
+	defer g.recoverNodePanic(nodeName, &err)
+
+	if node := g.nodes[nodeName]; node != nil {
+		if node.skipWith(inputs) {
+			return inputs, nil
+		}
+		node.recordInputs(inputs)
+		startedAt := time.Now()
+		defer func() {
+			node.mu.Lock()
+			node.startedAt = startedAt
+			node.finishedAt = time.Now()
+			node.mu.Unlock()
+		}()
 	}
 
+	var loopEdge *Edge
+	maxIter := 1
 	for _, edge := range g.edges[nodeName] {
 		if edge.from == nodeName && edge.to == nodeName {
-			maxIter := edge.weight
+			loopEdge = edge
+			maxIter = edge.weight
 			if maxIter <= 0 {
 				maxIter = DefaultMaxIterations
 			}
-			for i := 1; i < maxIter; i++ {
-				if edge.condFunc != nil && !edge.condFunc(results) {
-					break
-				}
-				results, err = g.executeNode(nodeName, results)
-				if err != nil {
-					return nil, err
-				}
-			}
 			break
 		}
 	}
 
+	loopCtx := context.WithValue(ctx, loopInfoKey{}, LoopInfo{Iteration: 1, Max: maxIter})
+	results, err := g.executeNodeWithContext(loopCtx, nodeName, inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	if loopEdge != nil {
+		node := g.nodes[nodeName]
+		node.mu.Lock()
+		node.interrupted = false
+		node.mu.Unlock()
+		exhausted := true
+		for i := 1; i < maxIter; i++ {
+			select {
+			case <-ctx.Done():
+				canceled := canceledError(ctx)
+				canceled.Node = nodeName
+				return nil, failNode(node, canceled)
+			default:
+			}
+			if !g.condMatches(loopEdge, results) {
+				exhausted = false
+				break
+			}
+			if loopEdge.stopRequested() {
+				node.mu.Lock()
+				node.interrupted = true
+				node.mu.Unlock()
+				exhausted = false
+				break
+			}
+			loopCtx = context.WithValue(ctx, loopInfoKey{}, LoopInfo{Iteration: i + 1, Max: maxIter})
+			results, err = g.executeNodeWithContext(loopCtx, nodeName, results)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if exhausted && loopEdge.errorOnMax && g.condMatches(loopEdge, results) {
+			return nil, failNode(node, &FlowError{Kind: ErrLoopMaxIterationsErr, Message: fmt.Sprintf("%s: %d at node %s", ErrLoopMaxIterations, maxIter, nodeName), Node: nodeName})
+		}
+	}
+
 	return results, nil
 }
 
+// recoverNodePanic must be deferred directly for recover to see the panic.
+func (g *Graph) recoverNodePanic(nodeName string, err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	*err = panicFailure(nodeName, r)
+	if node := g.nodes[nodeName]; node != nil {
+		failNode(node, *err)
+	}
+}
+
+func failNode(node *Node, err error) error {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	node.err = err
+	node.status = NodeStatusFailed
+	return err
+}
+
+func (g *Graph) hasCarryErrorEdge(nodeName string) bool {
+	for _, edge := range g.edges[nodeName] {
+		if edge.carryError || edge.onError {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *Graph) hasErrorEdge(nodeName string) bool {
+	for _, edge := range g.edges[nodeName] {
+		if edge.onError {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *Graph) nodeRan(nodeName string) bool {
+	node := g.nodes[nodeName]
+	node.mu.RLock()
+	defer node.mu.RUnlock()
+	return node.status == NodeStatusCompleted || node.status == NodeStatusSkipped
+}
+
+func (g *Graph) missingInputs(edge *Edge) []any {
+	node := g.nodes[edge.from]
+	numOut := node.numOut
+	if node.hasErrorReturn {
+		numOut--
+	}
+	if edge.carryError {
+		numOut++
+	}
+	return make([]any, numOut)
+}
+
+func (g *Graph) nodeErr(nodeName string) error {
+	node := g.nodes[nodeName]
+	node.mu.RLock()
+	defer node.mu.RUnlock()
+	return node.err
+}
+
+func (g *Graph) carriedFailure(edge *Edge) ([]any, bool) {
+	if !edge.carryError {
+		return nil, false
+	}
+	node := g.nodes[edge.from]
+	if node == nil {
+		return nil, false
+	}
+
+	node.mu.RLock()
+	defer node.mu.RUnlock()
+	if node.status != NodeStatusFailed || node.err == nil {
+		return nil, false
+	}
+
+	numOut := node.numOut
+	if node.hasErrorReturn {
+		numOut--
+	}
+	carried := make([]any, numOut+1)
+	carried[numOut] = node.err
+	return carried, true
+}
+
 type nodeState struct {
 	results  []any
 	err      error
+	bypassed bool
 	done     uint32
 	finished uint32
 	doneSig  chan struct{}
@@ -403,6 +1302,9 @@ type execContext struct {
 	branchTargetNodes map[string]bool
 	errChan           chan error
 	doneChan          chan struct{}
+	sem               chan struct{}
+	failures          *failureCollector
+	branchMode        BranchMode
 }
 
 type nodeTask struct {
@@ -436,7 +1338,10 @@ func (g *Graph) GetPausedAtNode() string {
 
 func (g *Graph) shouldPauseAtNode(nodeName string) bool {
 	if g.pauseConfig != nil && g.pauseConfig.ShouldPauseAtNode(nodeName) {
-		return true
+		g.mu.RLock()
+		resumed := g.resumePast == nodeName
+		g.mu.RUnlock()
+		return !resumed
 	}
 	return false
 }
@@ -448,11 +1353,65 @@ func (g *Graph) shouldPauseForSignal() bool {
 	return false
 }
 
-func (g *Graph) checkResourceAvailable(nodeName string) bool {
-	if g.resourceChecker != nil {
-		return g.resourceChecker.CheckAvailable(nodeName)
+func (g *Graph) SetNodeResource(nodeName, resource string, units int) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.frozen {
+		return &FlowError{Kind: ErrGraphFrozenErr, Message: fmt.Sprintf("%s: cannot set resource of %s", ErrGraphFrozen, nodeName)}
 	}
-	return true
+	if _, ok := g.nodes[nodeName]; !ok {
+		return &FlowError{Kind: ErrNodeNotFoundErr, Message: fmt.Sprintf("%s: %s", ErrNodeNotFound, nodeName)}
+	}
+	if g.nodeResources == nil {
+		g.nodeResources = make(map[string]map[string]int)
+	}
+	if g.nodeResources[nodeName] == nil {
+		g.nodeResources[nodeName] = make(map[string]int)
+	}
+	g.nodeResources[nodeName][resource] = units
+	return nil
+}
+
+func (g *Graph) acquireResources(nodeName string) (func(), bool) {
+	release := func() {}
+	if g.resourceChecker == nil {
+		return release, true
+	}
+
+	g.mu.RLock()
+	requirements := maps.Clone(g.nodeResources[nodeName])
+	g.mu.RUnlock()
+
+	if len(requirements) == 0 {
+		return release, g.resourceChecker.CheckAvailable(nodeName)
+	}
+	if reserver, ok := g.resourceChecker.(ResourceReserver); ok {
+		var held []string
+		release = func() {
+			for _, resource := range held {
+				reserver.ReleaseResource(resource, requirements[resource])
+			}
+		}
+		for _, resource := range slices.Sorted(maps.Keys(requirements)) {
+			if !reserver.AcquireResource(resource, requirements[resource]) {
+				release()
+				return func() {}, false
+			}
+			held = append(held, resource)
+		}
+		return release, true
+	}
+	checker, ok := g.resourceChecker.(ResourceRequirementChecker)
+	if !ok {
+		return release, g.resourceChecker.CheckAvailable(nodeName)
+	}
+	for resource, units := range requirements {
+		if !checker.CheckResource(resource, units) {
+			return release, false
+		}
+	}
+	return release, true
 }
 
 func (g *Graph) Run() error {
@@ -467,7 +1426,42 @@ func (g *Graph) RunWithContext(ctx context.Context) error {
 		return g.err
 	}
 
-	return g.executeGraphParallelWithContext(ctx)
+	ctx, done := g.beginRun(ctx)
+	defer done()
+
+	return g.compensate(g.executeGraphParallelWithContext(ctx))
+}
+
+func (g *Graph) RunWithInputs(ctx context.Context, inputs map[string][]any) error {
+	if g.err != nil {
+		return g.err
+	}
+
+	g.mu.Lock()
+	for name, values := range inputs {
+		node, ok := g.nodes[name]
+		if !ok {
+			g.mu.Unlock()
+			return &FlowError{Kind: ErrNodeNotFoundErr, Message: fmt.Sprintf("%s: %s", ErrNodeNotFound, name)}
+		}
+		if g.inDegree[name] != 0 {
+			g.mu.Unlock()
+			return &FlowError{Kind: ErrNotStartNodeErr, Message: fmt.Sprintf("%s: %s", ErrNotStartNode, name)}
+		}
+		if err := node.checkInputCount(values); err != nil {
+			g.mu.Unlock()
+			return err
+		}
+	}
+	g.inputOverrides = maps.Clone(inputs)
+	g.mu.Unlock()
+	defer func() {
+		g.mu.Lock()
+		g.inputOverrides = nil
+		g.mu.Unlock()
+	}()
+
+	return g.RunWithContext(ctx)
 }
 
 func (g *Graph) RunSequential() error {
@@ -489,7 +1483,10 @@ func (g *Graph) RunSequentialWithContext(ctx context.Context) error {
 
 	g.buildExecInEdges()
 
-	return g.executeSequential(ctx, plan)
+	ctx, done := g.beginRun(ctx)
+	defer done()
+
+	return g.compensate(g.executeSequential(ctx, plan))
 }
 
 func (g *Graph) buildExecInEdges() {
@@ -504,46 +1501,41 @@ func (g *Graph) buildExecInEdges() {
 			g.execInEdges[edge.to] = append(g.execInEdges[edge.to], edge)
 		}
 	}
+	for _, inEdges := range g.execInEdges {
+		slices.SortFunc(inEdges, func(a, b *Edge) int { return a.seq - b.seq })
+	}
 }
 
 func (g *Graph) executeSequential(ctx context.Context, plan []string) error {
 	resultsMap := make(map[string][]any, len(plan))
+	failed := make(map[string]error)
+	bypassed := make(map[string]bool)
+	failures := g.newFailureCollector()
 
 	for _, name := range plan {
 		select {
 		case <-ctx.Done():
-			return &FlowError{Message: fmt.Sprintf("execution canceled: %v", ctx.Err())}
+			return canceledError(ctx)
 		default:
 		}
 
 		if g.shouldPauseForSignal() {
-			g.mu.Lock()
-			g.pausedAtNode = name
-			g.mu.Unlock()
+			g.setPausedAt(name)
 			return ErrFlowPaused
 		}
 
 		if g.shouldPauseAtNode(name) {
-			g.mu.Lock()
-			g.pausedAtNode = name
-			g.mu.Unlock()
+			g.setPausedAt(name)
 			return ErrFlowPaused
 		}
 
-		if !g.checkResourceAvailable(name) {
-			g.mu.Lock()
-			g.pausedAtNode = name
-			g.mu.Unlock()
-			return ErrResourceNotAvailable
-		}
-
 		node := g.nodes[name]
 		if node == nil {
-			return &FlowError{Message: ErrNodeNotFound}
+			return &FlowError{Kind: ErrNodeNotFoundErr, Message: ErrNodeNotFound}
 		}
 
 		node.mu.RLock()
-		isCompleted := node.status == NodeStatusCompleted
+		isCompleted := node.status == NodeStatusCompleted || node.status == NodeStatusSkipped
 		var existingResult []any
 		if isCompleted && len(node.result) > 0 {
 			existingResult = make([]any, len(node.result))
@@ -557,38 +1549,141 @@ func (g *Graph) executeSequential(ctx context.Context, plan []string) error {
 		}
 
 		inEdges := g.execInEdges[name]
-		var inputs []any
+		inputs, overridden := g.inputOverride(name)
 
-		if len(inEdges) == 0 {
-			inputs = nil
+		skipped := false
+		if overridden || len(inEdges) == 0 {
 		} else {
 			for _, edge := range inEdges {
 				if edge.edgeType == EdgeTypeLoop {
 					continue
 				}
+				if bypassed[edge.from] && !edge.optional {
+					skipped = true
+					break
+				}
+				if edge.onError {
+					if fromErr, ok := failed[edge.from]; ok {
+						inputs = append(inputs, fromErr)
+						continue
+					}
+					skipped = true
+					break
+				}
 				if fromResults, ok := resultsMap[edge.from]; ok {
 					inputs = append(inputs, fromResults...)
+					if edge.carryError {
+						inputs = append(inputs, nil)
+					}
+					continue
+				}
+				if carried, ok := g.carriedFailure(edge); ok {
+					inputs = append(inputs, carried...)
+					continue
+				}
+				if edge.optional {
+					if _, ok := failed[edge.from]; !ok {
+						inputs = append(inputs, g.missingInputs(edge)...)
+						continue
+					}
+				}
+				if fromErr, ok := failed[edge.from]; ok {
+					if g.hasErrorEdge(edge.from) {
+						skipped = true
+						break
+					}
+					if failures != nil {
+						failed[name] = fromErr
+						skipped = true
+						break
+					}
+					return nodeFailure(edge.from, fromErr)
 				}
 			}
 		}
+		if skipped {
+			if _, ok := failed[name]; !ok {
+				bypassed[name] = true
+			}
+			continue
+		}
+
+		if len(inputs) == 0 && g.inDegree[name] == 0 {
+			inputs = g.startNodeInputs(name)
+		}
+
+		release, ok := g.acquireResources(name)
+		if !ok {
+			g.setPausedAt(name)
+			return ErrResourceNotAvailable
+		}
+		results, err := g.executeNodeObserved(ctx, name, inputs)
+		release()
+		if err != nil {
+			if errors.Is(err, ErrFlowPaused) {
+				g.markNodePaused(name)
+				return ErrFlowPaused
+			}
+			fatal := isFatalError(err)
+			if !fatal && g.hasCarryErrorEdge(name) {
+				failed[name] = err
+				continue
+			}
+			if g.pauseOnError(err) {
+				g.setPausedAt(name)
+			}
+			if failures != nil && !fatal {
+				failed[name] = err
+				failures.add(nodeFailure(name, err))
+				continue
+			}
+			return nodeFailure(name, err)
+		}
+
+		resultsMap[name] = results
+		g.recordCompletion(name)
+	}
+
+	return failures.err()
+}
+
+func (g *Graph) inputOverride(nodeName string) ([]any, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	inputs, ok := g.inputOverrides[nodeName]
+	return inputs, ok
+}
+
+func (g *Graph) startNodeInputs(nodeName string) []any {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if inputs, ok := g.inputOverrides[nodeName]; ok {
+		return slices.Clone(inputs)
+	}
+	if len(g.startInputs) == 0 {
+		return nil
+	}
+	node := g.nodes[nodeName]
+	if node == nil || (node.fn != nil && node.argCount == 0) {
+		return nil
+	}
+	inputs := make([]any, len(g.startInputs))
+	copy(inputs, g.startInputs)
+	return inputs
+}
 
-		results, err := g.executeNodeWithLoop(name, inputs)
-		if err != nil {
-			if g.pauseConfig != nil && g.pauseConfig.OnErrorPause {
-				g.mu.Lock()
-				g.pausedAtNode = name
-				g.mu.Unlock()
-			}
-			return &FlowError{Message: fmt.Sprintf("node %s failed: %v", name, err)}
-		}
+func (g *Graph) markNodePaused(nodeName string) {
+	g.setPausedAt(nodeName)
+	g.mu.RLock()
+	node := g.nodes[nodeName]
+	g.mu.RUnlock()
 
-		resultsMap[name] = results
-		g.mu.Lock()
-		g.stepNames[name] = len(g.stepNames)
-		g.mu.Unlock()
+	if node != nil {
+		node.mu.Lock()
+		node.status = NodeStatusPending
+		node.err = nil
+		node.mu.Unlock()
 	}
-
-	return nil
 }
 
 func (g *Graph) buildExecutionPlan() ([]string, error) {
@@ -627,7 +1722,7 @@ func (g *Graph) buildExecutionPlan() ([]string, error) {
 	startNode := g.findStartNode()
 	if startNode == "" {
 		stringSlicePool.Put(plan)
-		return nil, &FlowError{Message: ErrNoStartNode}
+		return nil, &FlowError{Kind: ErrNoStartNodeErr, Message: ErrNoStartNode}
 	}
 
 	queue := stringSlicePool.Get(nodeCount)
@@ -636,13 +1731,28 @@ func (g *Graph) buildExecutionPlan() ([]string, error) {
 			queue = append(queue, name)
 		}
 	}
+	slices.Sort(queue)
 
 	if len(queue) == 0 {
 		queue = append(queue, startNode)
 	}
 
+	priorities := g.nodePriorities()
+	fair := g.newFairScheduler()
+
 	head := 0
 	for head < len(queue) {
+		if fair != nil {
+			fair.next(queue, head, priorities)
+		} else if priorities != nil {
+			best := head
+			for i := head + 1; i < len(queue); i++ {
+				if priorities[queue[i]] > priorities[queue[best]] {
+					best = i
+				}
+			}
+			queue[head], queue[best] = queue[best], queue[head]
+		}
 		current := queue[head]
 		head++
 
@@ -652,6 +1762,9 @@ func (g *Graph) buildExecutionPlan() ([]string, error) {
 
 		plan = append(plan, current)
 		visited[current] = true
+		if fair != nil {
+			fair.visit(current)
+		}
 
 		for _, edge := range g.edges[current] {
 			if edge.edgeType == EdgeTypeLoop {
@@ -669,7 +1782,7 @@ func (g *Graph) buildExecutionPlan() ([]string, error) {
 
 	if len(plan) != nodeCount {
 		stringSlicePool.Put(plan)
-		return nil, &FlowError{Message: ErrCyclicDependency}
+		return nil, &FlowError{Kind: ErrCyclicDependencyErr, Message: ErrCyclicDependency}
 	}
 
 	g.execPlan = append(g.execPlan[:0], plan...)
@@ -693,14 +1806,50 @@ func (g *Graph) buildExecutionPlan() ([]string, error) {
 	return g.execPlan, nil
 }
 
+func (g *Graph) TopologicalOrder() ([]string, error) {
+	if g.err != nil {
+		return nil, g.err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	plan, err := g.buildExecutionPlan()
+	if err != nil {
+		return nil, err
+	}
+	order := make([]string, len(plan))
+	copy(order, plan)
+	return order, nil
+}
+
+func (g *Graph) nodePriorities() map[string]int {
+	var priorities map[string]int
+	for _, edges := range g.edges {
+		for _, edge := range edges {
+			if edge.priority == 0 || edge.edgeType == EdgeTypeLoop {
+				continue
+			}
+			if priorities == nil {
+				priorities = make(map[string]int, len(g.nodes))
+			}
+			if edge.priority > priorities[edge.to] {
+				priorities[edge.to] = edge.priority
+			}
+		}
+	}
+	return priorities
+}
+
 func (g *Graph) findStartNode() string {
+	start := ""
 	for name := range g.nodes {
-		if g.inDegree[name] == 0 {
-			return name
+		if g.inDegree[name] == 0 && (start == "" || name < start) {
+			start = name
 		}
 	}
 
-	return ""
+	return start
 }
 
 func (g *Graph) buildLayers() ([][]string, error) {
@@ -745,12 +1894,13 @@ func (g *Graph) buildLayers() ([][]string, error) {
 			allNodes = append(allNodes, name)
 		}
 	}
+	slices.Sort(allNodes)
 
 	if len(allNodes) == 0 {
 		startNode := g.findStartNode()
 		if startNode == "" {
 			stringSlicePool.Put(allNodes)
-			return nil, &FlowError{Message: ErrNoStartNode}
+			return nil, &FlowError{Kind: ErrNoStartNodeErr, Message: ErrNoStartNode}
 		}
 		allNodes = append(allNodes, startNode)
 	}
@@ -758,11 +1908,15 @@ func (g *Graph) buildLayers() ([][]string, error) {
 	layerStart := 0
 	layerEnd := len(allNodes)
 	totalProcessed := 0
+	fair := g.newFairScheduler()
 
 	for layerStart < layerEnd {
 		for i := layerStart; i < layerEnd; i++ {
 			node := allNodes[i]
 			visited[node] = true
+			if fair != nil {
+				fair.visit(node)
+			}
 			for _, edge := range g.edges[node] {
 				if edge.edgeType == EdgeTypeLoop {
 					continue
@@ -776,16 +1930,17 @@ func (g *Graph) buildLayers() ([][]string, error) {
 		}
 
 		totalProcessed += layerEnd - layerStart
-		layerBounds = append(layerBounds, len(allNodes))
+		layerBounds = append(layerBounds, layerEnd)
 		layerStart = layerEnd
 		layerEnd = len(allNodes)
 	}
 
 	if totalProcessed != nodeCount {
 		stringSlicePool.Put(allNodes)
-		return nil, &FlowError{Message: ErrCyclicDependency}
+		return nil, &FlowError{Kind: ErrCyclicDependencyErr, Message: ErrCyclicDependency}
 	}
 
+	priorities := g.nodePriorities()
 	layerCount := len(layerBounds) - 1
 	if g.layers == nil {
 		g.layers = make([][]string, 0, layerCount)
@@ -803,6 +1958,15 @@ func (g *Graph) buildLayers() ([][]string, error) {
 		layer := stringSlicePool.Get(layerSize)
 		layer = layer[:0]
 		layer = append(layer, allNodes[start:end]...)
+		if fair != nil {
+			for head := range layer {
+				fair.next(layer, head, priorities)
+			}
+		} else if priorities != nil {
+			sort.SliceStable(layer, func(a, b int) bool {
+				return priorities[layer[a]] > priorities[layer[b]]
+			})
+		}
 		g.layers = append(g.layers, layer)
 	}
 
@@ -853,9 +2017,13 @@ func (g *Graph) convertNodeResultsForInput(node *Node, results []any) []any {
 }
 
 func (g *Graph) executeNode(nodeName string, inputs []any) ([]any, error) {
+	return g.executeNodeWithContext(context.Background(), nodeName, inputs)
+}
+
+func (g *Graph) executeNodeWithContext(ctx context.Context, nodeName string, inputs []any) ([]any, error) {
 	node := g.nodes[nodeName]
 	if node == nil {
-		return nil, &FlowError{Message: ErrNodeNotFound}
+		return nil, &FlowError{Kind: ErrNodeNotFoundErr, Message: ErrNodeNotFound}
 	}
 
 	node.mu.Lock()
@@ -863,8 +2031,22 @@ func (g *Graph) executeNode(nodeName string, inputs []any) ([]any, error) {
 	node.err = nil
 	node.mu.Unlock()
 
+	if node.validator != nil {
+		if err := node.validator(inputs); err != nil {
+			return nil, failNode(node, &FlowError{
+				Kind:    ErrInvalidInputErr,
+				Message: fmt.Sprintf("%s: %v", ErrInvalidInput, err),
+				Node:    nodeName,
+				Cause:   err,
+			})
+		}
+	}
+
 	if node.callFn != nil {
-		results, err := node.callFn(inputs)
+		results, err := g.callNodeWithRetry(ctx, node, inputs)
+		if err == nil {
+			results = g.interceptResults(nodeName, results)
+		}
 		node.mu.Lock()
 		if err != nil {
 			node.err = err
@@ -878,10 +2060,11 @@ func (g *Graph) executeNode(nodeName string, inputs []any) ([]any, error) {
 		return results, nil
 	}
 
+	results := g.interceptResults(nodeName, inputs)
 	node.mu.Lock()
 	node.status = NodeStatusCompleted
 	node.mu.Unlock()
-	return inputs, nil
+	return results, nil
 }
 
 func (g *Graph) Error() error {
@@ -897,18 +2080,15 @@ func (g *Graph) ClearStatus() *Graph {
 		node.status = NodeStatusPending
 		node.err = nil
 		node.result = nil
+		node.startedAt = time.Time{}
+		node.finishedAt = time.Time{}
+		node.interrupted = false
+		node.pruned = false
 		node.mu.Unlock()
 	}
 
-	for _, edges := range g.edges {
-		for _, edge := range edges {
-			if edge.condComp != nil {
-				condCompilerPool.Put(edge.condComp)
-				edge.condComp = nil
-			}
-		}
-	}
-
+	g.runStartedAt = time.Time{}
+	g.runFinishedAt = time.Time{}
 	g.err = nil
 	return g
 }
@@ -918,7 +2098,7 @@ func (g *Graph) NodeStatus(nodeName string) (NodeStatus, error) {
 	node, ok := g.nodes[nodeName]
 	g.mu.RUnlock()
 	if !ok {
-		return NodeStatusPending, &FlowError{Message: ErrNodeNotFound}
+		return NodeStatusPending, &FlowError{Kind: ErrNodeNotFoundErr, Message: ErrNodeNotFound}
 	}
 
 	node.mu.RLock()
@@ -932,7 +2112,7 @@ func (g *Graph) NodeResult(nodeName string) ([]any, error) {
 	node, ok := g.nodes[nodeName]
 	g.mu.RUnlock()
 	if !ok {
-		return nil, &FlowError{Message: ErrNodeNotFound}
+		return nil, &FlowError{Kind: ErrNodeNotFoundErr, Message: ErrNodeNotFound}
 	}
 
 	node.mu.RLock()
@@ -946,12 +2126,86 @@ func (g *Graph) NodeResult(nodeName string) ([]any, error) {
 	return result, nil
 }
 
+func (g *Graph) TerminalResults() map[string][]any {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	results := make(map[string][]any)
+	for name, node := range g.nodes {
+		if g.outDegree[name] != 0 {
+			continue
+		}
+		node.mu.RLock()
+		if node.status == NodeStatusCompleted || node.status == NodeStatusSkipped {
+			results[name] = append([]any{}, node.result...)
+		}
+		node.mu.RUnlock()
+	}
+	return results
+}
+
+func (g *Graph) AllResults() map[string][]any {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	results := make(map[string][]any, len(g.nodes))
+	for name, node := range g.nodes {
+		node.mu.RLock()
+		if node.status == NodeStatusCompleted || node.status == NodeStatusSkipped {
+			results[name] = append([]any{}, node.result...)
+		}
+		node.mu.RUnlock()
+	}
+	return results
+}
+
+func (g *Graph) InputsOf(nodeName string) []string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if _, ok := g.nodes[nodeName]; !ok {
+		return nil
+	}
+	seen := make(map[string]bool)
+	for _, edges := range g.edges {
+		for _, edge := range edges {
+			if edge.to == nodeName {
+				seen[edge.from] = true
+			}
+		}
+	}
+	return sortedNames(seen)
+}
+
+func (g *Graph) OutputsOf(nodeName string) []string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if _, ok := g.nodes[nodeName]; !ok {
+		return nil
+	}
+	seen := make(map[string]bool)
+	for _, edge := range g.edges[nodeName] {
+		seen[edge.to] = true
+	}
+	return sortedNames(seen)
+}
+
+func sortedNames(set map[string]bool) []string {
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
 func (g *Graph) NodeError(nodeName string) error {
 	g.mu.RLock()
 	node, ok := g.nodes[nodeName]
 	g.mu.RUnlock()
 	if !ok {
-		return &FlowError{Message: ErrNodeNotFound}
+		return &FlowError{Kind: ErrNodeNotFoundErr, Message: ErrNodeNotFound}
 	}
 
 	node.mu.RLock()
@@ -960,25 +2214,214 @@ func (g *Graph) NodeError(nodeName string) error {
 	return err
 }
 
+func GraphNodeResultAs[T any](g *Graph, nodeName string) (T, error) {
+	var zero T
+
+	g.mu.RLock()
+	node, ok := g.nodes[nodeName]
+	g.mu.RUnlock()
+	if !ok {
+		return zero, &FlowError{Kind: ErrNodeNotFoundErr, Message: fmt.Sprintf("%s: %s", ErrNodeNotFound, nodeName)}
+	}
+
+	node.mu.RLock()
+	defer node.mu.RUnlock()
+	if node.status != NodeStatusCompleted {
+		return zero, &FlowError{Kind: ErrNodeNotCompletedErr, Message: fmt.Sprintf("%s: %s", ErrNodeNotCompleted, nodeName)}
+	}
+	if len(node.result) == 0 {
+		return zero, &FlowError{Kind: ErrNoResultErr, Message: fmt.Sprintf("%s: %s", ErrNoResult, nodeName)}
+	}
+	value, ok := node.result[0].(T)
+	if !ok {
+		return zero, &FlowError{Kind: ErrResultTypeErr, Message: fmt.Sprintf("%s: %s is %T, not %T", ErrResultType, nodeName, node.result[0], zero)}
+	}
+	return value, nil
+}
+
+func (g *Graph) NodeDuration(nodeName string) (time.Duration, error) {
+	g.mu.RLock()
+	node, ok := g.nodes[nodeName]
+	g.mu.RUnlock()
+	if !ok {
+		return 0, &FlowError{Kind: ErrNodeNotFoundErr, Message: ErrNodeNotFound}
+	}
+
+	node.mu.RLock()
+	defer node.mu.RUnlock()
+	if node.finishedAt.IsZero() {
+		return 0, &FlowError{Kind: ErrNodeNotCompletedErr, Message: fmt.Sprintf("%s: %s", ErrNodeNotCompleted, nodeName)}
+	}
+	return node.finishedAt.Sub(node.startedAt), nil
+}
+
+func (g *Graph) LoopInterrupted(nodeName string) (bool, error) {
+	g.mu.RLock()
+	node, ok := g.nodes[nodeName]
+	g.mu.RUnlock()
+	if !ok {
+		return false, &FlowError{Kind: ErrNodeNotFoundErr, Message: ErrNodeNotFound}
+	}
+
+	node.mu.RLock()
+	defer node.mu.RUnlock()
+	return node.interrupted, nil
+}
+
+func (g *Graph) SetMaxConcurrency(n int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if n < 0 {
+		n = 0
+	}
+	g.maxConcurrency = n
+}
+
+func (g *Graph) SetShowDurations(show bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.showDurations = show
+}
+
+func (g *Graph) nodeLabel(node *Node) string {
+	if !g.showDurations {
+		return node.name
+	}
+	node.mu.RLock()
+	defer node.mu.RUnlock()
+	if node.finishedAt.IsZero() {
+		return node.name
+	}
+	return fmt.Sprintf("%s (%s)", node.name, node.finishedAt.Sub(node.startedAt))
+}
+
+type NodeInfo struct {
+	Name   string
+	Status NodeStatus
+	Err    error
+	Meta   map[string]string
+}
+
+func (g *Graph) NodeInfo(nodeName string) (NodeInfo, error) {
+	g.mu.RLock()
+	node, ok := g.nodes[nodeName]
+	g.mu.RUnlock()
+	if !ok {
+		return NodeInfo{}, &FlowError{Kind: ErrNodeNotFoundErr, Message: ErrNodeNotFound}
+	}
+
+	node.mu.RLock()
+	defer node.mu.RUnlock()
+	info := NodeInfo{
+		Name:   node.name,
+		Status: node.status,
+		Err:    node.err,
+	}
+	if len(node.meta) > 0 {
+		info.Meta = make(map[string]string, len(node.meta))
+		for k, v := range node.meta {
+			info.Meta[k] = v
+		}
+	}
+	return info, nil
+}
+
+func (g *Graph) NodeSignature(nodeName string) ([]reflect.Type, []reflect.Type, error) {
+	g.mu.RLock()
+	node, ok := g.nodes[nodeName]
+	g.mu.RUnlock()
+	if !ok {
+		return nil, nil, &FlowError{Kind: ErrNodeNotFoundErr, Message: fmt.Sprintf("%s: %s", ErrNodeNotFound, nodeName)}
+	}
+
+	node.mu.RLock()
+	defer node.mu.RUnlock()
+	return slices.Clone(node.argTypes), nodeOutputTypes(node), nil
+}
+
+func (g *Graph) NodeMeta(nodeName string) (map[string]string, error) {
+	info, err := g.NodeInfo(nodeName)
+	if err != nil {
+		return nil, err
+	}
+	return info.Meta, nil
+}
+
+func formatMeta(meta map[string]string) string {
+	keys := make([]string, 0, len(meta))
+	for k := range meta {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + meta[k]
+	}
+	return strings.Join(pairs, ", ")
+}
+
+var nodeStatusNames = map[NodeStatus]string{
+	NodeStatusPending:   "pending",
+	NodeStatusRunning:   "running",
+	NodeStatusCompleted: "completed",
+	NodeStatusFailed:    "failed",
+	NodeStatusSkipped:   "skipped",
+}
+
+var nodeStatusColors = map[NodeStatus]string{
+	NodeStatusPending:   "#d3d3d3",
+	NodeStatusRunning:   "#ffeb3b",
+	NodeStatusCompleted: "#8bc34a",
+	NodeStatusFailed:    "#f44336",
+	NodeStatusSkipped:   "#90caf9",
+}
+
+func (s NodeStatus) String() string {
+	if name, ok := nodeStatusNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("NodeStatus(%d)", int(s))
+}
+
 func (g *Graph) String() string {
+	return g.dot(false)
+}
+
+func (g *Graph) StringWithStatus() string {
+	return g.dot(true)
+}
+
+func (g *Graph) dot(withStatus bool) string {
 	var sb strings.Builder
 
 	sb.WriteString("digraph Graph {\n")
 	sb.WriteString("    rankdir=TD;\n\n")
 
-	for name := range g.nodes {
-		fmt.Fprintf(&sb, "    %q [shape=box,label=%q];\n", name, name)
+	for _, name := range g.nodeOrder {
+		node := g.nodes[name]
+		attrs := fmt.Sprintf("shape=box,label=%q", g.nodeLabel(node))
+		if len(node.meta) > 0 {
+			attrs += fmt.Sprintf(",tooltip=%q", formatMeta(node.meta))
+		}
+		if withStatus {
+			node.mu.RLock()
+			status := node.status
+			node.mu.RUnlock()
+			attrs += fmt.Sprintf(",style=filled,fillcolor=%q", nodeStatusColors[status])
+		}
+		fmt.Fprintf(&sb, "    %q [%s];\n", name, attrs)
 	}
 
 	sb.WriteString("\n")
 
-	for _, edges := range g.edges {
-		for _, edge := range edges {
-			label := ""
-			if edge.cond != nil {
-				label = fmt.Sprintf(",label=%q", "cond")
+	for _, from := range g.nodeOrder {
+		for _, edge := range g.edges[from] {
+			attrs := ""
+			if text := edgeLabel(edge); text != "" {
+				attrs = fmt.Sprintf("label=%q", text)
 			}
-			fmt.Fprintf(&sb, "    %q -> %q [%s];\n", edge.from, edge.to, label)
+			fmt.Fprintf(&sb, "    %q -> %q [%s];\n", edge.from, edge.to, attrs)
 		}
 	}
 
@@ -987,28 +2430,136 @@ func (g *Graph) String() string {
 	return sb.String()
 }
 
+func edgeLabel(edge *Edge) string {
+	if edge.label != "" {
+		return edge.label
+	}
+	if edge.cond != nil {
+		return "cond"
+	}
+	return ""
+}
+
+func mermaidEdgeText(text string) string {
+	plain := strings.IndexFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune(" _-.", r)
+	}) < 0
+	if plain {
+		return text
+	}
+	return mermaidQuote(text)
+}
+
+func mermaidQuote(text string) string {
+	return `"` + strings.ReplaceAll(text, `"`, "#quot;") + `"`
+}
+
 func (g *Graph) Mermaid() string {
+	return g.mermaid(false, false)
+}
+
+func (g *Graph) MermaidWithStatus() string {
+	return g.mermaid(true, false)
+}
+
+func (g *Graph) MermaidClustered() string {
+	return g.mermaid(false, true)
+}
+
+const defaultMermaidCluster = "default"
+
+func (g *Graph) writeMermaidClusters(sb *strings.Builder) {
+	var clusters []string
+	members := make(map[string][]string)
+	for _, name := range g.nodeOrder {
+		cluster := defaultMermaidCluster
+		if tags := g.nodes[name].tags; len(tags) > 0 {
+			cluster = tags[0]
+		}
+		if _, ok := members[cluster]; !ok && cluster != defaultMermaidCluster {
+			clusters = append(clusters, cluster)
+		}
+		members[cluster] = append(members[cluster], name)
+	}
+	if _, ok := members[defaultMermaidCluster]; ok {
+		clusters = append(clusters, defaultMermaidCluster)
+	}
+
+	for i, cluster := range clusters {
+		fmt.Fprintf(sb, "    subgraph cluster_%d[%s]\n", i, mermaidQuote(cluster))
+		for _, name := range members[cluster] {
+			if label := g.nodeLabel(g.nodes[name]); g.showDurations && label != name {
+				fmt.Fprintf(sb, "        %s[%q]\n", name, label)
+			} else {
+				fmt.Fprintf(sb, "        %s\n", name)
+			}
+		}
+		sb.WriteString("    end\n")
+	}
+	sb.WriteString("\n")
+}
+
+func (g *Graph) mermaid(withStatus, clustered bool) string {
 	var sb strings.Builder
 
 	sb.WriteString("graph TD\n\n")
 
-	for _, edges := range g.edges {
-		for _, edge := range edges {
+	if clustered {
+		g.writeMermaidClusters(&sb)
+	} else if g.showDurations {
+		for _, name := range g.nodeOrder {
+			if label := g.nodeLabel(g.nodes[name]); label != name {
+				fmt.Fprintf(&sb, "    %s[%q]\n", name, label)
+			}
+		}
+	}
+
+	for _, from := range g.nodeOrder {
+		for _, edge := range g.edges[from] {
 			label := ""
-			if edge.cond != nil {
-				label = "|cond|"
+			if text := edgeLabel(edge); text != "" {
+				label = "|" + mermaidEdgeText(text) + "|"
 			}
 			fmt.Fprintf(&sb, "    %s --> %s%s\n", edge.from, label, edge.to)
 		}
 	}
 
-	for name := range g.nodes {
-		if _, hasEdges := g.edges[name]; !hasEdges {
+	for _, name := range g.nodeOrder {
+		if _, hasEdges := g.edges[name]; !hasEdges && !clustered {
 			if g.inDegree[name] == 0 {
 				fmt.Fprintf(&sb, "    %s\n", name)
 			}
 		}
 	}
 
+	for _, name := range g.nodeOrder {
+		if node := g.nodes[name]; len(node.meta) > 0 {
+			fmt.Fprintf(&sb, "    %%%% %s: %s\n", name, formatMeta(node.meta))
+		}
+	}
+
+	if withStatus {
+		byStatus := make(map[NodeStatus][]string, len(nodeStatusNames))
+		for _, name := range g.nodeOrder {
+			node := g.nodes[name]
+			node.mu.RLock()
+			byStatus[node.status] = append(byStatus[node.status], name)
+			node.mu.RUnlock()
+		}
+
+		sb.WriteString("\n")
+		for _, status := range []NodeStatus{NodeStatusPending, NodeStatusRunning, NodeStatusCompleted, NodeStatusFailed, NodeStatusSkipped} {
+			fmt.Fprintf(&sb, "    classDef %s fill:%s\n", status, nodeStatusColors[status])
+		}
+		for _, status := range []NodeStatus{NodeStatusPending, NodeStatusRunning, NodeStatusCompleted, NodeStatusFailed, NodeStatusSkipped} {
+			names := byStatus[status]
+			if len(names) == 0 {
+				continue
+			}
+			sort.Strings(names)
+			fmt.Fprintf(&sb, "    class %s %s\n", strings.Join(names, ","), status)
+		}
+	}
+
 	return sb.String()
 }
@@ -1,9 +1,15 @@
 package flow
 
 import (
+	"encoding/json"
 	"reflect"
+	"strings"
+	"sync"
+	"time"
 )
 
+const nodeMetaKeyPrefix = "node_meta."
+
 func (g *Graph) SaveCheckpoint() (*Checkpoint, error) {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
@@ -21,8 +27,16 @@ func (g *Graph) SaveCheckpoint() (*Checkpoint, error) {
 			Status: int(node.status),
 		}
 
-		switch node.status {
-		case NodeStatusCompleted, NodeStatusFailed:
+		if (node.status == NodeStatusCompleted || node.status == NodeStatusSkipped) && len(node.result) > 0 {
+			if _, err := json.Marshal(node.result); err == nil {
+				step.Result = append([]any{}, node.result...)
+			} else {
+				step.Status = int(NodeStatusPending)
+			}
+		}
+
+		switch NodeStatus(step.Status) {
+		case NodeStatusCompleted, NodeStatusFailed, NodeStatusSkipped:
 			step.Executed = true
 			executed = append(executed, name)
 		case NodeStatusPending, NodeStatusRunning:
@@ -34,11 +48,12 @@ func (g *Graph) SaveCheckpoint() (*Checkpoint, error) {
 		steps = append(steps, step)
 	}
 
-	nodeResults := make(map[string][]any)
 	for name, node := range g.nodes {
 		node.mu.RLock()
-		if len(node.result) > 0 {
-			nodeResults[name] = append([]any{}, node.result...)
+		if len(node.meta) > 0 {
+			if data, err := json.Marshal(node.meta); err == nil {
+				checkpoint.SetMetadata(nodeMetaKeyPrefix+name, string(data))
+			}
 		}
 		node.mu.RUnlock()
 	}
@@ -46,13 +61,14 @@ func (g *Graph) SaveCheckpoint() (*Checkpoint, error) {
 	checkpoint.Data.Steps = steps
 	checkpoint.Data.Current = len(executed) - 1
 	checkpoint.Data.Extra = map[string]any{
-		"node_results":   nodeResults,
 		"executed":       executed,
 		"pending":        pending,
 		"paused_at_node": g.pausedAtNode,
 	}
 
 	switch {
+	case g.canceled:
+		checkpoint.State = FlowStateCanceled
 	case g.err != nil:
 		checkpoint.Data.Error = g.err.Error()
 		checkpoint.State = FlowStateFailed
@@ -79,6 +95,14 @@ func (g *Graph) LoadCheckpoint(checkpoint *Checkpoint) error {
 		if node, ok := g.nodes[step.Name]; ok {
 			node.mu.Lock()
 			node.status = NodeStatus(step.Status)
+			if len(step.Result) > 0 {
+				if results, ok := decodeNodeResults(node, step.Result); ok {
+					node.result = results
+				} else {
+					node.status = NodeStatusPending
+					node.result = nil
+				}
+			}
 			node.mu.Unlock()
 		}
 	}
@@ -104,7 +128,10 @@ func (g *Graph) LoadCheckpoint(checkpoint *Checkpoint) error {
 						}
 						if node, ok := g.nodes[name]; ok {
 							node.mu.Lock()
-							node.result = g.convertResultsToNodeTypes(node, rs)
+							if decoded, ok := decodeNodeResults(node, rs); ok {
+								rs = decoded
+							}
+							node.result = rs
 							node.mu.Unlock()
 						}
 					}
@@ -116,16 +143,34 @@ func (g *Graph) LoadCheckpoint(checkpoint *Checkpoint) error {
 		}
 	}
 
+	for key, value := range checkpoint.Metadata {
+		name, ok := strings.CutPrefix(key, nodeMetaKeyPrefix)
+		if !ok {
+			continue
+		}
+		node, ok := g.nodes[name]
+		if !ok {
+			continue
+		}
+		var meta map[string]string
+		if err := json.Unmarshal([]byte(value), &meta); err != nil {
+			return ErrInvalidCheckpoint
+		}
+		node.mu.Lock()
+		node.meta = meta
+		node.mu.Unlock()
+	}
+
 	if data.Error != "" {
-		g.err = &FlowError{Message: data.Error}
+		g.err = &FlowError{Kind: ErrExecutionFailedErr, Message: data.Error}
 	}
 
 	return nil
 }
 
-func (g *Graph) convertResultsToNodeTypes(node *Node, results []any) []any {
-	if node == nil || node.fn == nil || node.fnType == nil || len(results) == 0 {
-		return results
+func decodeNodeResults(node *Node, results []any) ([]any, bool) {
+	if node.fnType == nil {
+		return results, true
 	}
 
 	numOut := node.numOut
@@ -133,38 +178,108 @@ func (g *Graph) convertResultsToNodeTypes(node *Node, results []any) []any {
 		numOut--
 	}
 
-	if numOut == 0 {
-		return results
-	}
-
-	converted := make([]any, len(results))
+	decoded := make([]any, len(results))
 	for i, result := range results {
-		if result == nil {
-			converted[i] = nil
+		if i >= numOut || result == nil {
+			decoded[i] = result
 			continue
 		}
 
-		var targetType reflect.Type
-		if i < numOut {
-			targetType = node.fnType.Out(i)
-		} else {
-			converted[i] = result
+		targetType := node.fnType.Out(i)
+		resultVal := reflect.ValueOf(result)
+		if resultVal.Type().AssignableTo(targetType) {
+			decoded[i] = result
 			continue
 		}
 
-		resultVal := reflect.ValueOf(result)
-		if !resultVal.Type().AssignableTo(targetType) {
-			if resultVal.CanConvert(targetType) {
-				converted[i] = resultVal.Convert(targetType).Interface()
-			} else {
-				converted[i] = result
+		if data, err := json.Marshal(result); err == nil {
+			target := reflect.New(targetType)
+			if err := json.Unmarshal(data, target.Interface()); err == nil {
+				decoded[i] = target.Elem().Interface()
+				continue
 			}
-		} else {
-			converted[i] = result
 		}
+		if !resultVal.CanConvert(targetType) {
+			return nil, false
+		}
+		decoded[i] = resultVal.Convert(targetType).Interface()
 	}
+	return decoded, true
+}
+
+type autoCheckpoint struct {
+	store     CheckpointStore
+	key       string
+	every     int
+	completed int
+	err       error
+	mu        sync.Mutex
+	saveMu    sync.Mutex
+}
+
+func (g *Graph) EnableAutoCheckpoint(store CheckpointStore, key string, every int) {
+	if every <= 0 {
+		every = 1
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.autoCheckpoint = &autoCheckpoint{store: store, key: key, every: every}
+}
+
+func (g *Graph) DisableAutoCheckpoint() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.autoCheckpoint = nil
+}
+
+func (g *Graph) AutoCheckpointError() error {
+	g.mu.RLock()
+	auto := g.autoCheckpoint
+	g.mu.RUnlock()
+	if auto == nil {
+		return nil
+	}
+	auto.mu.Lock()
+	defer auto.mu.Unlock()
+	return auto.err
+}
+
+func (g *Graph) recordCompletion(nodeName string) {
+	g.mu.Lock()
+	g.stepNames[nodeName] = len(g.stepNames)
+	auto := g.autoCheckpoint
+	g.mu.Unlock()
+
+	g.reportProgress()
+
+	if auto == nil {
+		return
+	}
+
+	auto.mu.Lock()
+	auto.completed++
+	due := auto.completed%auto.every == 0
+	auto.mu.Unlock()
+	if !due {
+		return
+	}
+
+	// saveMu keeps concurrent saves in order, so an older snapshot never
+	// overwrites a newer one; auto.mu stays free for AutoCheckpointError.
+	auto.saveMu.Lock()
+	err := g.SaveToStore(auto.store, auto.key)
+	auto.saveMu.Unlock()
+	if err != nil {
+		auto.mu.Lock()
+		auto.err = err
+		auto.mu.Unlock()
+	}
+}
 
-	return converted
+func (a *autoCheckpoint) resetCount() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.completed = 0
 }
 
 func (g *Graph) SaveToStore(store CheckpointStore, key string) error {
@@ -188,14 +303,20 @@ func (g *Graph) Reset() {
 	defer g.mu.Unlock()
 
 	g.err = nil
+	g.canceled = false
 	g.execPlanValid = false
 	g.layersValid = false
+	g.runStartedAt = time.Time{}
+	g.runFinishedAt = time.Time{}
 
 	for _, node := range g.nodes {
 		node.mu.Lock()
 		node.status = NodeStatusPending
 		node.result = nil
 		node.err = nil
+		node.startedAt = time.Time{}
+		node.finishedAt = time.Time{}
+		node.interrupted = false
 		node.mu.Unlock()
 	}
 }
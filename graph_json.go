@@ -0,0 +1,259 @@
+package flow
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+const (
+	GraphDefinitionVersion = 1
+
+	ErrUnsupportedVersion = "unsupported graph definition version"
+	ErrUnknownEdgeType    = "unknown edge type"
+	ErrUnboundNode        = "no function registered for node"
+	ErrUnboundCondition   = "no condition registered for edge"
+)
+
+var edgeTypeNames = map[EdgeType]string{
+	EdgeTypeNormal: "normal",
+	EdgeTypeLoop:   "loop",
+	EdgeTypeBranch: "branch",
+}
+
+type GraphDefinition struct {
+	Version int              `json:"version" yaml:"version"`
+	Nodes   []NodeDefinition `json:"nodes" yaml:"nodes"`
+	Edges   []EdgeDefinition `json:"edges" yaml:"edges"`
+}
+
+type NodeDefinition struct {
+	Name        string            `json:"name" yaml:"name"`
+	Action      string            `json:"action,omitempty" yaml:"action,omitempty"`
+	Description string            `json:"description,omitempty" yaml:"description,omitempty"`
+	Meta        map[string]string `json:"meta,omitempty" yaml:"meta,omitempty"`
+	Tags        []string          `json:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
+type EdgeDefinition struct {
+	From          string `json:"from" yaml:"from"`
+	To            string `json:"to" yaml:"to"`
+	Type          string `json:"type,omitempty" yaml:"type,omitempty"`
+	Label         string `json:"label,omitempty" yaml:"label,omitempty"`
+	Condition     string `json:"condition,omitempty" yaml:"condition,omitempty"`
+	Default       bool   `json:"default,omitempty" yaml:"default,omitempty"`
+	MaxIterations int    `json:"max_iterations,omitempty" yaml:"max_iterations,omitempty"`
+	ErrorOnMax    bool   `json:"error_on_max,omitempty" yaml:"error_on_max,omitempty"`
+	Priority      int    `json:"priority,omitempty" yaml:"priority,omitempty"`
+	CarryError    bool   `json:"carry_error,omitempty" yaml:"carry_error,omitempty"`
+	OnError       bool   `json:"on_error,omitempty" yaml:"on_error,omitempty"`
+	Optional      bool   `json:"optional,omitempty" yaml:"optional,omitempty"`
+}
+
+func (nd NodeDefinition) action() string {
+	if nd.Action != "" {
+		return nd.Action
+	}
+	return nd.Name
+}
+
+func ConditionKey(from, to string) string {
+	return from + "->" + to
+}
+
+func (g *Graph) Definition() GraphDefinition {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	def := GraphDefinition{Version: GraphDefinitionVersion}
+	for _, name := range slices.Sorted(maps.Keys(g.nodes)) {
+		node := g.nodes[name]
+		node.mu.RLock()
+		nd := NodeDefinition{Name: name, Description: node.description}
+		if len(node.meta) > 0 {
+			nd.Meta = maps.Clone(node.meta)
+		}
+		nd.Tags = slices.Clone(node.tags)
+		node.mu.RUnlock()
+		def.Nodes = append(def.Nodes, nd)
+	}
+
+	for _, from := range slices.Sorted(maps.Keys(g.edges)) {
+		for _, edge := range g.edges[from] {
+			ed := EdgeDefinition{
+				From:       edge.from,
+				To:         edge.to,
+				Type:       edgeTypeNames[edge.edgeType],
+				Label:      edge.label,
+				Priority:   edge.priority,
+				CarryError: edge.carryError,
+				OnError:    edge.onError,
+				Optional:   edge.optional,
+			}
+			switch {
+			case edge.isDefault:
+				ed.Default = true
+			case edge.cond != nil:
+				ed.Condition = ConditionKey(edge.from, edge.to)
+			}
+			if edge.edgeType == EdgeTypeLoop {
+				ed.MaxIterations = edge.weight
+				ed.ErrorOnMax = edge.errorOnMax
+			}
+			def.Edges = append(def.Edges, ed)
+		}
+	}
+	return def
+}
+
+func (g *Graph) ExportJSON() ([]byte, error) {
+	if g.err != nil {
+		return nil, g.err
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(g.Definition()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func LoadGraphJSON(data []byte, registry map[string]any) (*Graph, error) {
+	var def GraphDefinition
+	if err := json.Unmarshal(data, &def); err != nil {
+		return nil, err
+	}
+	return BuildGraph(def, registry)
+}
+
+func (def GraphDefinition) Validate(registry map[string]any) error {
+	if def.Version != 0 && def.Version != GraphDefinitionVersion {
+		return &FlowError{Kind: ErrUnsupportedVersionErr, Message: fmt.Sprintf("%s: %d", ErrUnsupportedVersion, def.Version)}
+	}
+
+	names := make(map[string]bool, len(def.Nodes))
+	for _, node := range def.Nodes {
+		if names[node.Name] {
+			return &FlowError{Kind: ErrDuplicateNodeErr, Message: fmt.Sprintf("%s: %s", ErrDuplicateNode, node.Name)}
+		}
+		names[node.Name] = true
+		if _, ok := registry[node.action()]; !ok {
+			return &FlowError{Kind: ErrUnboundNodeErr, Message: fmt.Sprintf("%s: %s", ErrUnboundNode, node.action())}
+		}
+	}
+
+	for _, edge := range def.Edges {
+		if !names[edge.From] {
+			return &FlowError{Kind: ErrNodeNotFoundErr, Message: fmt.Sprintf("%s: %s (edge %s -> %s)", ErrNodeNotFound, edge.From, edge.From, edge.To)}
+		}
+		if !names[edge.To] {
+			return &FlowError{Kind: ErrNodeNotFoundErr, Message: fmt.Sprintf("%s: %s (edge %s -> %s)", ErrNodeNotFound, edge.To, edge.From, edge.To)}
+		}
+		edgeType, err := parseEdgeType(edge.Type)
+		if err != nil {
+			return err
+		}
+		if edge.Default && edgeType != EdgeTypeBranch {
+			return &FlowError{Kind: ErrUnknownEdgeTypeErr, Message: fmt.Sprintf("%s: default edge %s -> %s must be a branch", ErrUnknownEdgeType, edge.From, edge.To)}
+		}
+		if edge.Condition != "" {
+			if _, ok := registry[edge.Condition]; !ok {
+				return &FlowError{Kind: ErrUnboundConditionErr, Message: fmt.Sprintf("%s: %s", ErrUnboundCondition, edge.Condition)}
+			}
+		}
+	}
+	return nil
+}
+
+func BuildGraph(def GraphDefinition, registry map[string]any) (*Graph, error) {
+	if err := def.Validate(registry); err != nil {
+		return nil, err
+	}
+
+	g := NewGraph(WithCapacity(len(def.Nodes)))
+	for _, nd := range def.Nodes {
+		var opts []NodeOption
+		if len(nd.Meta) > 0 {
+			opts = append(opts, WithMeta(nd.Meta))
+		}
+		if len(nd.Tags) > 0 {
+			opts = append(opts, WithTags(nd.Tags...))
+		}
+		g.AddNode(nd.Name, registry[nd.action()], opts...)
+		if g.err != nil {
+			return nil, g.err
+		}
+		g.nodes[nd.Name].description = nd.Description
+	}
+
+	var defaults []EdgeDefinition
+	for _, ed := range def.Edges {
+		if ed.Default {
+			defaults = append(defaults, ed)
+			continue
+		}
+		if err := g.addDefinedEdge(ed, registry, nil); err != nil {
+			return nil, err
+		}
+	}
+	for _, ed := range defaults {
+		var conds []CondFunc
+		for _, edge := range g.edges[ed.From] {
+			if edge.edgeType == EdgeTypeBranch {
+				conds = append(conds, edge.condFunc)
+			}
+		}
+		if err := g.addDefinedEdge(ed, registry, conds); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+func (g *Graph) addDefinedEdge(ed EdgeDefinition, registry map[string]any, fallbackOf []CondFunc) error {
+	edgeType, _ := parseEdgeType(ed.Type)
+	opts := []EdgeOption{WithEdgeType(edgeType)}
+	if ed.Default {
+		opts = append(opts, WithCondition(branchFallback(fallbackOf)), withDefaultBranch())
+	} else if ed.Condition != "" {
+		opts = append(opts, WithCondition(registry[ed.Condition]))
+	}
+	if ed.Label != "" {
+		opts = append(opts, WithLabel(ed.Label))
+	}
+	if ed.MaxIterations > 0 {
+		opts = append(opts, WithMaxIterations(ed.MaxIterations))
+	}
+	if ed.ErrorOnMax {
+		opts = append(opts, WithLoopErrorOnMax())
+	}
+	if ed.Priority != 0 {
+		opts = append(opts, WithPriority(ed.Priority))
+	}
+	if ed.CarryError {
+		opts = append(opts, WithCarryError())
+	}
+	if ed.OnError {
+		opts = append(opts, withOnError())
+	}
+	if ed.Optional {
+		opts = append(opts, WithOptional())
+	}
+	return g.AddEdgeE(ed.From, ed.To, opts...)
+}
+
+func parseEdgeType(name string) (EdgeType, error) {
+	if name == "" {
+		return EdgeTypeNormal, nil
+	}
+	for t, n := range edgeTypeNames {
+		if n == name {
+			return t, nil
+		}
+	}
+	return 0, &FlowError{Kind: ErrUnknownEdgeTypeErr, Message: fmt.Sprintf("%s: %s", ErrUnknownEdgeType, name)}
+}
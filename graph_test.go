@@ -2,9 +2,14 @@ package flow
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -59,7 +64,7 @@ func TestGraphRunWithContext(t *testing.T) {
 		if err == nil {
 			t.Fatalf("Expected context canceled error")
 		}
-		if !strings.Contains(err.Error(), "execution canceled") {
+		if !errors.Is(err, context.Canceled) {
 			t.Errorf("Expected canceled error, got %v", err.Error())
 		}
 	})
@@ -1625,3 +1630,3524 @@ func TestGraphLoopParallel(t *testing.T) {
 		t.Errorf("Expected [9], got: %v", result)
 	}
 }
+
+func TestGraphCarryError(t *testing.T) {
+	build := func(fail bool) *Graph {
+		graph := NewGraph()
+		graph.AddNode("start", func() int { return 10 })
+		graph.AddNode("fetch", func(n int) (int, error) {
+			if fail {
+				return 0, fmt.Errorf("fetch failed")
+			}
+			return n * 2, nil
+		})
+		graph.AddNode("handle", func(n int, err error) string {
+			if err != nil {
+				return "fallback: " + err.Error()
+			}
+			return fmt.Sprintf("value: %d", n)
+		})
+		graph.AddEdge("start", "fetch")
+		graph.AddEdge("fetch", "handle", WithCarryError())
+		return graph
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		graph := build(false)
+		assertNoError(t, graph.Run())
+		assertNodeResult(t, graph, "handle", "value: 20")
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		graph := build(true)
+		assertNoError(t, graph.Run())
+		assertNodeStatus(t, graph, "fetch", NodeStatusFailed)
+		assertNodeResult(t, graph, "handle", "fallback: fetch failed")
+	})
+
+	t.Run("Sequential", func(t *testing.T) {
+		graph := build(true)
+		assertNoError(t, graph.RunSequential())
+		assertNodeResult(t, graph, "handle", "fallback: fetch failed")
+	})
+
+	t.Run("WithValueInputs", func(t *testing.T) {
+		graph := NewGraph()
+		graph.AddNode("start", func() int { return 10 })
+		graph.AddNode("fetch", func(n int) (int, error) { return 0, fmt.Errorf("fetch failed") })
+		graph.AddNode("handle", func(n int, err error) int {
+			if err != nil {
+				return -1
+			}
+			return n
+		})
+		graph.AddNode("end", func(n int) int { return n + 1 })
+		graph.AddEdge("start", "fetch")
+		graph.AddEdge("fetch", "handle", WithCarryError())
+		graph.AddEdge("handle", "end")
+
+		assertNoError(t, graph.Run())
+		assertNodeResult(t, graph, "end", 0)
+	})
+
+	t.Run("NormalEdgeStillFails", func(t *testing.T) {
+		graph := build(true)
+		graph.AddNode("other", func(n int) int { return n })
+		graph.AddEdge("fetch", "other")
+
+		err := graph.Run()
+		assertError(t, err)
+		assertContains(t, err.Error(), "fetch failed")
+
+		graph = build(true)
+		graph.AddNode("other", func(n int) int { return n })
+		graph.AddEdge("fetch", "other")
+		assertError(t, graph.RunSequential())
+	})
+}
+
+func TestGraphNodeRetry(t *testing.T) {
+	t.Run("SucceedsAfterRetries", func(t *testing.T) {
+		attempts := 0
+		graph := NewGraph()
+		graph.AddNode("start", func() int { return 10 })
+		graph.AddNodeWithRetry("fetch", func(n int) (int, error) {
+			attempts++
+			if attempts < 3 {
+				return 0, fmt.Errorf("transient")
+			}
+			return n * 2, nil
+		}, RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond, Multiplier: 2})
+		graph.AddEdge("start", "fetch")
+
+		assertNoError(t, graph.Run())
+		assertNodeResult(t, graph, "fetch", 20)
+		assertEqual(t, 3, attempts)
+	})
+
+	t.Run("ExhaustsAttempts", func(t *testing.T) {
+		attempts := 0
+		graph := NewGraph()
+		graph.AddNodeWithRetry("fetch", func() (int, error) {
+			attempts++
+			return 0, fmt.Errorf("permanent")
+		}, RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond})
+
+		err := graph.Run()
+		assertError(t, err)
+		assertContains(t, err.Error(), "permanent")
+		assertEqual(t, 3, attempts)
+		assertNodeStatus(t, graph, "fetch", NodeStatusFailed)
+
+		var retryErr *RetryError
+		if !errors.As(graph.NodeError("fetch"), &retryErr) {
+			t.Fatalf("Expected RetryError, got %v", graph.NodeError("fetch"))
+		}
+		assertEqual(t, 3, retryErr.Attempts)
+		assertEqual(t, "permanent", errors.Unwrap(retryErr).Error())
+	})
+
+	t.Run("CanceledContext", func(t *testing.T) {
+		attempts := 0
+		ctx, cancel := context.WithCancel(context.Background())
+		graph := NewGraph()
+		graph.AddNodeWithRetry("fetch", func() (int, error) {
+			attempts++
+			cancel()
+			return 0, fmt.Errorf("transient")
+		}, RetryPolicy{MaxAttempts: 5, Backoff: time.Hour})
+
+		assertError(t, graph.RunSequentialWithContext(ctx))
+		assertEqual(t, 1, attempts)
+	})
+
+	t.Run("DuplicateNode", func(t *testing.T) {
+		graph := NewGraph()
+		graph.AddNode("fetch", func() int { return 1 })
+		graph.AddNodeWithRetry("fetch", func() int { return 1 }, RetryPolicy{MaxAttempts: 2})
+		assertError(t, graph.Error())
+	})
+}
+
+func TestGraphNodeMeta(t *testing.T) {
+	graph := NewGraph()
+	meta := map[string]string{"owner": "payments", "docs": "https://example.com/fetch"}
+	graph.AddNode("start", func() int { return 10 })
+	graph.AddNode("fetch", func(n int) int { return n }, WithMeta(meta))
+	graph.AddEdge("start", "fetch")
+
+	meta["owner"] = "mutated"
+
+	info, err := graph.NodeInfo("fetch")
+	assertNoError(t, err)
+	assertEqual(t, "fetch", info.Name)
+	assertEqual(t, NodeStatusPending, info.Status)
+	assertEqual(t, "payments", info.Meta["owner"])
+
+	startMeta, err := graph.NodeMeta("start")
+	assertNoError(t, err)
+	if startMeta != nil {
+		t.Errorf("Expected nil meta, got: %v", startMeta)
+	}
+
+	_, err = graph.NodeInfo("nonexistent")
+	assertError(t, err)
+
+	assertContains(t, graph.String(), `tooltip="docs=https://example.com/fetch, owner=payments"`)
+	assertContains(t, graph.Mermaid(), "%% fetch: docs=https://example.com/fetch, owner=payments")
+
+	assertNoError(t, graph.Run())
+	info, _ = graph.NodeInfo("fetch")
+	assertEqual(t, NodeStatusCompleted, info.Status)
+}
+
+func TestGraphNodeResultAs(t *testing.T) {
+	graph := createSimpleLinearGraph(t)
+	graph.AddNode("noop", func(n int) {})
+	graph.AddEdge("double", "noop")
+
+	_, err := GraphNodeResultAs[int](graph, "double")
+	assertError(t, err)
+	assertContains(t, err.Error(), ErrNodeNotCompleted)
+
+	assertNoError(t, graph.Run())
+
+	value, err := GraphNodeResultAs[int](graph, "double")
+	assertNoError(t, err)
+	assertEqual(t, 20, value)
+
+	_, err = GraphNodeResultAs[string](graph, "double")
+	assertError(t, err)
+	assertContains(t, err.Error(), ErrResultType)
+
+	_, err = GraphNodeResultAs[int](graph, "noop")
+	assertError(t, err)
+	assertContains(t, err.Error(), ErrNoResult)
+
+	_, err = GraphNodeResultAs[int](graph, "nonexistent")
+	assertError(t, err)
+	assertContains(t, err.Error(), ErrNodeNotFound)
+}
+
+func TestGraphAddBranchEdgeWithDefault(t *testing.T) {
+	tests := []struct {
+		name     string
+		score    int
+		expected string
+	}{
+		{"High", 90, "high"},
+		{"Medium", 60, "medium"},
+		{"Low", 30, "low"},
+		{"Fallback", -1, "manual_review"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			graph := NewGraph()
+			graph.AddNode("score", func() int { return tc.score })
+			for _, target := range []string{"high", "medium", "low", "manual_review"} {
+				graph.AddNode(target, func(n int) string { return target })
+			}
+			graph.AddNode("end", func(s string) string { return s })
+			graph.AddBranchEdgeWithDefault("score", map[string]any{
+				"high":   func(n int) bool { return n >= 80 },
+				"medium": func(n int) bool { return n >= 50 && n < 80 },
+				"low":    func(n int) bool { return n >= 0 && n < 50 },
+			}, "manual_review")
+			for _, target := range []string{"high", "medium", "low", "manual_review"} {
+				graph.AddEdge(target, "end")
+			}
+
+			assertNoError(t, graph.Run())
+			assertNodeResult(t, graph, "end", tc.expected)
+		})
+	}
+
+	t.Run("UnknownDefault", func(t *testing.T) {
+		graph := NewGraph()
+		graph.AddNode("score", func() int { return 1 })
+		graph.AddNode("high", func(n int) int { return n })
+		graph.AddBranchEdgeWithDefault("score", map[string]any{
+			"high": func(n int) bool { return n > 10 },
+		}, "nonexistent")
+		assertError(t, graph.Error())
+	})
+
+	t.Run("InvalidCondition", func(t *testing.T) {
+		for _, cond := range []any{nil, true, "n > 10", (func(int) bool)(nil)} {
+			graph := NewGraph()
+			graph.AddNode("score", func() int { return 1 })
+			graph.AddNode("high", func(n int) int { return n })
+			graph.AddNode("manual_review", func(n int) int { return n })
+			graph.AddBranchEdgeWithDefault("score", map[string]any{"high": cond}, "manual_review")
+			if err := graph.Error(); !errors.Is(err, ErrInvalidBranchCondErr) {
+				t.Errorf("condition %#v: expected ErrInvalidBranchCondErr, got %v", cond, err)
+			}
+		}
+	})
+
+	t.Run("StateCondition", func(t *testing.T) {
+		graph := NewGraph()
+		graph.AddNode("score", func() int { return 1 })
+		graph.AddNode("high", func(n int) string { return "high" })
+		graph.AddNode("manual_review", func(n int) string { return "manual_review" })
+		graph.AddBranchEdgeWithDefault("score", map[string]any{
+			"high": func(view *GraphView) bool { return false },
+		}, "manual_review")
+
+		assertNoError(t, graph.Run())
+		assertNodeResult(t, graph, "manual_review", "manual_review")
+	})
+}
+
+func TestGraphClone(t *testing.T) {
+	graph := NewGraph()
+	graph.AddNode("start", func() int { return 10 }, WithMeta(map[string]string{"owner": "ops"}))
+	graph.AddNode("double", func(n int) int { return n * 2 })
+	graph.AddNode("end", func(n int) int { return n + 1 })
+	graph.AddEdge("start", "double")
+	graph.AddEdgeWithCondition("double", "end", func(n int) bool { return n > 0 })
+
+	assertNoError(t, graph.Run())
+	assertNodeResult(t, graph, "end", 21)
+
+	clone := graph.Clone()
+	assertNodeStatus(t, clone, "end", NodeStatusPending)
+	result, _ := clone.NodeResult("end")
+	if len(result) != 0 {
+		t.Fatalf("Expected empty clone result, got: %v", result)
+	}
+
+	assertNoError(t, clone.Run())
+	assertNodeResult(t, clone, "end", 21)
+
+	if clone.nodes["start"] == graph.nodes["start"] {
+		t.Fatal("Expected clone not to share node pointers")
+	}
+	if len(clone.execPlan) > 0 && &clone.execPlan[0] == &graph.execPlan[0] {
+		t.Fatal("Expected clone not to share execution plan")
+	}
+
+	clone.ClearStatus()
+	assertNodeStatus(t, graph, "end", NodeStatusCompleted)
+	assertNodeResult(t, graph, "end", 21)
+
+	clone.nodes["start"].meta["owner"] = "changed"
+	meta, _ := graph.NodeMeta("start")
+	assertEqual(t, "ops", meta["owner"])
+
+	clone.AddNode("extra", func(n int) int { return n })
+	clone.AddEdge("end", "extra")
+	if _, ok := graph.nodes["extra"]; ok {
+		t.Fatal("Expected structural changes on clone not to affect original")
+	}
+}
+
+type recordingObserver struct {
+	mu     sync.Mutex
+	id     string
+	events *[]string
+}
+
+func (o *recordingObserver) record(event string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	*o.events = append(*o.events, o.id+":"+event)
+}
+
+func (o *recordingObserver) OnNodeStart(name string) {
+	o.record("start:" + name)
+}
+
+func (o *recordingObserver) OnNodeComplete(name string, results []any, d time.Duration) {
+	o.record(fmt.Sprintf("complete:%s:%v", name, results))
+}
+
+func (o *recordingObserver) OnNodeError(name string, err error) {
+	o.record("error:" + name)
+}
+
+type panickingObserver struct{}
+
+func (panickingObserver) OnNodeStart(name string) { panic("start") }
+func (panickingObserver) OnNodeComplete(name string, results []any, d time.Duration) {
+	panic("complete")
+}
+func (panickingObserver) OnNodeError(name string, err error) { panic("error") }
+
+func TestGraphObserver(t *testing.T) {
+	t.Run("Sequential", func(t *testing.T) {
+		var events []string
+		graph := createSimpleLinearGraph(t)
+		graph.AddObserver(&recordingObserver{id: "a", events: &events})
+		graph.AddObserver(panickingObserver{})
+		graph.AddObserver(&recordingObserver{id: "b", events: &events})
+
+		assertNoError(t, graph.RunSequential())
+		assertEqual(t, []string{
+			"a:start:start", "b:start:start",
+			"a:complete:start:[10]", "b:complete:start:[10]",
+			"a:start:double", "b:start:double",
+			"a:complete:double:[20]", "b:complete:double:[20]",
+		}, events)
+	})
+
+	t.Run("ParallelError", func(t *testing.T) {
+		var events []string
+		graph := NewGraph()
+		graph.AddNode("start", func() (int, error) { return 0, fmt.Errorf("boom") })
+		graph.AddObserver(&recordingObserver{id: "a", events: &events})
+		graph.AddObserver(panickingObserver{})
+
+		assertError(t, graph.Run())
+		assertEqual(t, []string{"a:start:start", "a:error:start"}, events)
+	})
+}
+
+func TestGraphNodeDuration(t *testing.T) {
+	graph := NewGraph()
+	graph.AddNode("start", func() int { return 10 })
+	graph.AddNode("slow", func(n int) int {
+		time.Sleep(20 * time.Millisecond)
+		return n
+	})
+	graph.AddEdge("start", "slow")
+
+	_, err := graph.NodeDuration("slow")
+	assertError(t, err)
+
+	assertNoError(t, graph.Run())
+
+	d, err := graph.NodeDuration("slow")
+	assertNoError(t, err)
+	if d < 20*time.Millisecond {
+		t.Errorf("Expected duration >= 20ms, got: %v", d)
+	}
+
+	_, err = graph.NodeDuration("nonexistent")
+	assertError(t, err)
+
+	if strings.Contains(graph.String(), "slow (") {
+		t.Error("Expected no duration labels by default")
+	}
+	graph.SetShowDurations(true)
+	assertContains(t, graph.String(), "label=\"slow (")
+	assertContains(t, graph.Mermaid(), "slow[\"slow (")
+
+	graph.ClearStatus()
+	_, err = graph.NodeDuration("slow")
+	assertError(t, err)
+}
+
+func TestGraphOutputWithStatus(t *testing.T) {
+	graph := NewGraph()
+	graph.AddNode("start", func() int { return 10 })
+	graph.AddNode("fail", func(n int) (int, error) { return 0, fmt.Errorf("boom") })
+	graph.AddNode("end", func(n int) int { return n })
+	graph.AddEdge("start", "fail")
+	graph.AddEdge("fail", "end")
+
+	assertError(t, graph.RunSequential())
+
+	dot := graph.StringWithStatus()
+	assertContains(t, dot, `"start" [shape=box,label="start",style=filled,fillcolor="#8bc34a"];`)
+	assertContains(t, dot, `"fail" [shape=box,label="fail",style=filled,fillcolor="#f44336"];`)
+	assertContains(t, dot, `"end" [shape=box,label="end",style=filled,fillcolor="#d3d3d3"];`)
+	if strings.Contains(graph.String(), "fillcolor") {
+		t.Error("Expected plain output to have no status colors")
+	}
+
+	mermaid := graph.MermaidWithStatus()
+	assertContains(t, mermaid, "classDef completed fill:#8bc34a")
+	assertContains(t, mermaid, "class start completed")
+	assertContains(t, mermaid, "class fail failed")
+	assertContains(t, mermaid, "class end pending")
+	if strings.Contains(mermaid, " running\n") {
+		t.Error("Expected no class line for statuses without nodes")
+	}
+	assertEqual(t, "completed", NodeStatusCompleted.String())
+}
+
+type testCtxKey struct{}
+
+func TestGraphContextNode(t *testing.T) {
+	t.Run("InjectsRunContext", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), testCtxKey{}, "tenant-a")
+		graph := NewGraph()
+		graph.AddNode("start", func() int { return 10 })
+		graph.AddNode("tag", func(ctx context.Context, n int) string {
+			return fmt.Sprintf("%v:%d", ctx.Value(testCtxKey{}), n)
+		})
+		graph.AddEdge("start", "tag")
+
+		assertNoError(t, graph.RunWithContext(ctx))
+		assertNodeResult(t, graph, "tag", "tenant-a:10")
+
+		graph.ClearStatus()
+		assertNoError(t, graph.RunSequentialWithContext(ctx))
+		assertNodeResult(t, graph, "tag", "tenant-a:10")
+	})
+
+	t.Run("ContextOnly", func(t *testing.T) {
+		graph := NewGraph()
+		graph.AddNode("start", func(ctx context.Context) bool { return ctx != nil })
+		assertNoError(t, graph.Run())
+		assertNodeResult(t, graph, "start", true)
+	})
+
+	t.Run("Deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		graph := NewGraph()
+		graph.AddNode("slow", func(ctx context.Context) error {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Second):
+				return nil
+			}
+		})
+		assertError(t, graph.RunSequentialWithContext(ctx))
+		if !errors.Is(graph.NodeError("slow"), context.DeadlineExceeded) {
+			t.Errorf("Expected deadline exceeded, got: %v", graph.NodeError("slow"))
+		}
+	})
+}
+
+func TestGraphAddSubGraph(t *testing.T) {
+	newStage := func() *Graph {
+		sub := NewGraph()
+		sub.AddNode("double", func(n int) int { return n * 2 })
+		sub.AddNode("inc", func(n int) int { return n + 1 })
+		sub.AddEdge("double", "inc")
+		return sub
+	}
+
+	t.Run("Parallel", func(t *testing.T) {
+		graph := NewGraph()
+		graph.AddNode("start", func() int { return 10 })
+		graph.AddSubGraph("stage2", newStage())
+		graph.AddNode("end", func(n int) string { return fmt.Sprintf("result=%d", n) })
+		graph.AddEdge("start", "stage2")
+		graph.AddEdge("stage2", "end")
+
+		assertNoError(t, graph.Run())
+		assertNodeResult(t, graph, "stage2", 21)
+		assertNodeResult(t, graph, "end", "result=21")
+	})
+
+	t.Run("SequentialRerun", func(t *testing.T) {
+		graph := NewGraph()
+		graph.AddNode("start", func() int { return 1 })
+		graph.AddSubGraph("stage2", newStage())
+		graph.AddEdge("start", "stage2")
+		graph.AddLoopEdge("stage2", func(n int) bool { return n < 20 }, 10)
+
+		assertNoError(t, graph.RunSequential())
+		assertNodeResult(t, graph, "stage2", 31)
+	})
+
+	t.Run("ErrorPropagates", func(t *testing.T) {
+		sub := NewGraph()
+		sub.AddNode("fail", func(n int) (int, error) { return 0, fmt.Errorf("sub failed") })
+
+		graph := NewGraph()
+		graph.AddNode("start", func() int { return 1 })
+		graph.AddSubGraph("stage2", sub)
+		graph.AddEdge("start", "stage2")
+
+		err := graph.Run()
+		assertError(t, err)
+		assertContains(t, err.Error(), "sub failed")
+		assertNodeStatus(t, graph, "stage2", NodeStatusFailed)
+	})
+
+	t.Run("PausePropagates", func(t *testing.T) {
+		sub := newStage()
+		sub.SetPauseConfig(NewPauseConfig().SetPauseAtNodes("inc"))
+
+		graph := NewGraph()
+		graph.AddNode("start", func() int { return 10 })
+		graph.AddSubGraph("stage2", sub)
+		graph.AddEdge("start", "stage2")
+
+		err := graph.Run()
+		if !errors.Is(err, ErrFlowPaused) {
+			t.Fatalf("Expected ErrFlowPaused, got: %v", err)
+		}
+		assertEqual(t, "stage2", graph.GetPausedAtNode())
+		assertNodeStatus(t, graph, "stage2", NodeStatusPending)
+
+		sub.SetPauseConfig(nil)
+		assertNoError(t, graph.Resume(context.Background()))
+		assertNodeResult(t, graph, "stage2", 21)
+	})
+
+	t.Run("NilSubGraph", func(t *testing.T) {
+		graph := NewGraph()
+		graph.AddSubGraph("stage2", nil)
+		assertError(t, graph.Error())
+	})
+}
+
+func TestGraphAddEdgeE(t *testing.T) {
+	graph := NewGraph()
+	graph.AddNode("a", func() int { return 1 })
+	graph.AddNode("b", func(n int) int { return n + 1 })
+	graph.AddNode("c", func(n int) int { return n + 1 })
+
+	assertError(t, graph.AddEdgeE("a", "missing"))
+	assertError(t, graph.AddEdgeE("a", "a"))
+	assertNoError(t, graph.AddEdgeE("a", "b"))
+	assertNoError(t, graph.AddEdgeE("b", "c"))
+	err := graph.AddEdgeE("c", "a")
+	assertError(t, err)
+	assertContains(t, err.Error(), ErrCyclicDependency)
+	err = graph.AddEdgeE("a", "b", WithEdgeType(EdgeTypeLoop))
+	if !errors.Is(err, ErrInvalidLoopEdgeErr) {
+		t.Errorf("expected ErrInvalidLoopEdgeErr, got %v", err)
+	}
+
+	assertNoError(t, graph.Error())
+	assertNoError(t, graph.Run())
+	assertNodeResult(t, graph, "c", 3)
+}
+
+func TestGraphEdgePriority(t *testing.T) {
+	build := func(order *[]string, opts ...GraphOption) *Graph {
+		var mu sync.Mutex
+		record := func(name string) func(int) int {
+			return func(n int) int {
+				mu.Lock()
+				*order = append(*order, name)
+				mu.Unlock()
+				return n
+			}
+		}
+		graph := NewGraph(opts...)
+		graph.AddNode("start", func() int { return 1 })
+		graph.AddNode("low", record("low"))
+		graph.AddNode("mid", record("mid"))
+		graph.AddNode("high", record("high"))
+		graph.AddEdge("start", "low", WithPriority(1))
+		graph.AddEdge("start", "mid")
+		graph.AddEdge("start", "high", WithPriority(10))
+		return graph
+	}
+
+	for i := 0; i < 10; i++ {
+		var order []string
+		graph := build(&order)
+		assertNoError(t, graph.RunSequential())
+		assertEqual(t, []string{"high", "low", "mid"}, order)
+	}
+
+	pool := NewWorkerPool(1)
+	defer pool.Shutdown()
+	for i := 0; i < 10; i++ {
+		var order []string
+		graph := build(&order, WithWorkerPool(pool))
+		assertNoError(t, graph.Run())
+		assertEqual(t, []string{"high", "low", "mid"}, order)
+	}
+
+	var order []string
+	graph := build(&order, WithLargeGraphThreshold(1))
+	layers, err := graph.buildLayers()
+	assertNoError(t, err)
+	assertEqual(t, []string{"high", "low", "mid"}, layers[1])
+	assertNoError(t, graph.Run())
+}
+
+func TestGraphFairScheduling(t *testing.T) {
+	build := func(opts ...GraphOption) *Graph {
+		graph := NewGraph(opts...)
+		graph.AddNode("start", func() int { return 1 })
+		for _, name := range []string{"B", "C", "b1", "b2", "b3", "c1", "c2"} {
+			graph.AddNode(name, func(n int) int { return n })
+		}
+		graph.AddEdge("start", "B")
+		graph.AddEdge("start", "C")
+		graph.AddEdge("B", "b1")
+		graph.AddEdge("B", "b2")
+		graph.AddEdge("B", "b3")
+		graph.AddEdge("C", "c1")
+		graph.AddEdge("c1", "c2")
+		return graph
+	}
+
+	order, err := build().TopologicalOrder()
+	assertNoError(t, err)
+	assertEqual(t, []string{"start", "B", "C", "b1", "b2", "b3", "c1", "c2"}, order)
+
+	order, err = build(WithFairScheduling()).TopologicalOrder()
+	assertNoError(t, err)
+	assertEqual(t, []string{"start", "B", "C", "b1", "c1", "b2", "c2", "b3"}, order)
+
+	graph := build(WithFairScheduling(), WithLargeGraphThreshold(1))
+	layers, err := graph.buildLayers()
+	assertNoError(t, err)
+	assertEqual(t, []string{"b1", "c1", "b2", "b3"}, layers[2])
+	assertNoError(t, graph.Run())
+	assertNoError(t, graph.Clone().Run())
+}
+
+func TestGraphTopologicalOrder(t *testing.T) {
+	graph := NewGraph()
+	graph.AddNode("start", func() int { return 1 })
+	graph.AddNode("a", func(n int) int { return n })
+	graph.AddNode("b", func(n int) int { return n })
+	graph.AddNode("end", func(a, b int) int { return a + b })
+	graph.AddEdge("start", "a")
+	graph.AddEdge("start", "b")
+	graph.AddEdge("a", "end")
+	graph.AddEdge("b", "end")
+
+	order, err := graph.TopologicalOrder()
+	assertNoError(t, err)
+	assertEqual(t, 4, len(order))
+
+	index := make(map[string]int, len(order))
+	for i, name := range order {
+		index[name] = i
+	}
+	if index["start"] > index["a"] || index["a"] > index["end"] || index["b"] > index["end"] {
+		t.Fatalf("Expected topological order, got: %v", order)
+	}
+
+	order[0] = "mutated"
+	again, err := graph.TopologicalOrder()
+	assertNoError(t, err)
+	if again[0] == "mutated" {
+		t.Fatal("Expected TopologicalOrder to return a copy")
+	}
+
+	status, _ := graph.NodeStatus("start")
+	assertEqual(t, NodeStatusPending, status)
+
+	broken := NewGraph()
+	broken.AddEdge("missing", "other")
+	_, err = broken.TopologicalOrder()
+	assertError(t, err)
+}
+
+func TestGraphFindCycle(t *testing.T) {
+	graph := NewGraph()
+	graph.AddNode("a", func() int { return 1 })
+	graph.AddNode("b", func(n int) int { return n })
+	graph.AddNode("c", func(n int) int { return n })
+	graph.AddEdge("a", "b")
+	graph.AddEdge("b", "c")
+	graph.AddLoopEdge("c", func(n int) bool { return false })
+
+	if cycle := graph.FindCycle(); cycle != nil {
+		t.Fatalf("Expected no cycle, got: %v", cycle)
+	}
+
+	err := graph.AddEdgeE("c", "a")
+	assertError(t, err)
+	assertContains(t, err.Error(), "cyclic dependency detected: c -> a -> b -> c")
+
+	graph.edges["c"] = append(graph.edges["c"], &Edge{from: "c", to: "a"})
+	assertEqual(t, []string{"a", "b", "c", "a"}, graph.FindCycle())
+}
+
+func TestGraphAddMapNode(t *testing.T) {
+	t.Run("Parallel", func(t *testing.T) {
+		var active, peak int32
+		graph := NewGraph()
+		graph.AddNode("init", func() []int { return []int{1, 2, 3, 4, 5} })
+		graph.AddMapNode("square", func(n int) int {
+			cur := atomic.AddInt32(&active, 1)
+			for {
+				old := atomic.LoadInt32(&peak)
+				if cur <= old || atomic.CompareAndSwapInt32(&peak, old, cur) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&active, -1)
+			return n * n
+		})
+		graph.AddNode("sum", func(values []int) int {
+			total := 0
+			for _, v := range values {
+				total += v
+			}
+			return total
+		})
+		graph.AddEdge("init", "square")
+		graph.AddEdge("square", "sum")
+
+		assertNoError(t, graph.Run())
+		assertNodeResult(t, graph, "square", []int{1, 4, 9, 16, 25})
+		assertNodeResult(t, graph, "sum", 55)
+		if atomic.LoadInt32(&peak) < 2 {
+			t.Errorf("Expected elements to run concurrently, peak was %d", peak)
+		}
+	})
+
+	t.Run("ElementError", func(t *testing.T) {
+		graph := NewGraph()
+		graph.AddNode("init", func() []string { return []string{"1", "x", "3"} })
+		errInvalid := errors.New("invalid")
+		graph.AddMapNode("parse", func(s string) (int, error) {
+			if s == "x" {
+				return 0, fmt.Errorf("%w %q", errInvalid, s)
+			}
+			return len(s), nil
+		})
+		graph.AddEdge("init", "parse")
+
+		err := graph.RunSequential()
+		assertError(t, err)
+		assertContains(t, err.Error(), "element 1: invalid \"x\"")
+		if !errors.Is(err, errInvalid) {
+			t.Errorf("Expected element error to match its cause, got %v", err)
+		}
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		graph := NewGraph()
+		graph.AddNode("init", func() []int { return []int{} })
+		graph.AddMapNode("double", func(n int) int { return n * 2 })
+		graph.AddEdge("init", "double")
+
+		assertNoError(t, graph.RunSequential())
+		assertNodeResult(t, graph, "double", []int{})
+	})
+
+	t.Run("InvalidFunc", func(t *testing.T) {
+		graph := NewGraph()
+		graph.AddMapNode("bad", func(a, b int) int { return a + b })
+		assertError(t, graph.Error())
+
+		for _, fn := range []any{nil, 42, (func(int) int)(nil)} {
+			graph := NewGraph()
+			graph.AddMapNode("bad", fn)
+			if !errors.Is(graph.Error(), ErrInvalidMapFuncErr) {
+				t.Errorf("Expected invalid map func error for %T, got %v", fn, graph.Error())
+			}
+		}
+	})
+}
+
+func TestGraphAddReduceNode(t *testing.T) {
+	t.Run("Sum", func(t *testing.T) {
+		graph := NewGraph()
+		graph.AddNode("init", func() []int { return []int{1, 2, 3, 4, 5} })
+		graph.AddMapNode("square", func(n int) int { return n * n })
+		graph.AddReduceNode("sum", 0, func(acc, n int) int { return acc + n })
+		graph.AddNode("summary", func(total int) string { return fmt.Sprintf("total=%d", total) })
+		graph.AddEdge("init", "square")
+		graph.AddEdge("square", "sum")
+		graph.AddEdge("sum", "summary")
+
+		assertNoError(t, graph.RunSequential())
+		assertNodeResult(t, graph, "sum", 55)
+		assertNodeResult(t, graph, "summary", "total=55")
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		graph := NewGraph()
+		graph.AddNode("init", func() []string { return nil })
+		graph.AddReduceNode("join", "start", func(acc, s string) string { return acc + "," + s })
+		graph.AddEdge("init", "join")
+
+		assertNoError(t, graph.Run())
+		assertNodeResult(t, graph, "join", "start")
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		graph := NewGraph()
+		graph.AddNode("init", func() []int { return []int{1, -1, 2} })
+		errNegative := errors.New("negative value")
+		graph.AddReduceNode("sum", 0, func(acc, n int) (int, error) {
+			if n < 0 {
+				return 0, errNegative
+			}
+			return acc + n, nil
+		})
+		graph.AddEdge("init", "sum")
+
+		err := graph.RunSequential()
+		assertError(t, err)
+		assertContains(t, err.Error(), "element 1: negative value")
+		if !errors.Is(err, errNegative) {
+			t.Errorf("Expected element error to match its cause, got %v", err)
+		}
+	})
+
+	t.Run("InvalidFunc", func(t *testing.T) {
+		graph := NewGraph()
+		graph.AddReduceNode("bad", 0, func(acc int, s string) string { return s })
+		assertError(t, graph.Error())
+
+		for _, fn := range []any{nil, "sum", (func(int, int) int)(nil)} {
+			graph := NewGraph()
+			graph.AddReduceNode("bad", 0, fn)
+			if !errors.Is(graph.Error(), ErrInvalidReduceFuncErr) {
+				t.Errorf("Expected invalid reduce func error for %T, got %v", fn, graph.Error())
+			}
+		}
+	})
+
+	t.Run("InvalidInitial", func(t *testing.T) {
+		graph := NewGraph()
+		graph.AddReduceNode("bad", "zero", func(acc, n int) int { return acc + n })
+		assertError(t, graph.Error())
+	})
+}
+
+func TestGraphLoopInfo(t *testing.T) {
+	for _, mode := range []string{"Sequential", "Parallel"} {
+		t.Run(mode, func(t *testing.T) {
+			var seen []LoopInfo
+			graph := NewGraph()
+			graph.AddNode("start", func() int { return 0 })
+			graph.AddNode("retry", func(n int, info LoopInfo) int {
+				seen = append(seen, info)
+				return n + info.Iteration
+			})
+			graph.AddEdge("start", "retry")
+			graph.AddLoopEdge("retry", func(n int) bool { return n < 6 }, 5)
+
+			var err error
+			if mode == "Sequential" {
+				err = graph.RunSequential()
+			} else {
+				err = graph.Run()
+			}
+			assertNoError(t, err)
+			assertNodeResult(t, graph, "retry", 6)
+
+			expected := []LoopInfo{{1, 5}, {2, 5}, {3, 5}}
+			if !reflect.DeepEqual(seen, expected) {
+				t.Errorf("Expected loop info %v, got %v", expected, seen)
+			}
+		})
+	}
+
+	t.Run("WithoutLoopEdge", func(t *testing.T) {
+		graph := NewGraph()
+		graph.AddNode("start", func() int { return 1 })
+		graph.AddNode("once", func(ctx context.Context, n int, info LoopInfo) int {
+			return n*10 + info.Iteration*100 + info.Max
+		})
+		graph.AddEdge("start", "once")
+
+		assertNoError(t, graph.Run())
+		assertNodeResult(t, graph, "once", 111)
+	})
+}
+
+func TestGraphSetMaxConcurrency(t *testing.T) {
+	run := func(t *testing.T, limit int, opts ...GraphOption) int32 {
+		t.Helper()
+		var active, peak int32
+		graph := NewGraph(opts...)
+		graph.SetMaxConcurrency(limit)
+		graph.AddNode("start", func() int { return 1 })
+		for i := range 6 {
+			name := fmt.Sprintf("worker%d", i)
+			graph.AddNode(name, func(n int) int {
+				cur := atomic.AddInt32(&active, 1)
+				for {
+					old := atomic.LoadInt32(&peak)
+					if cur <= old || atomic.CompareAndSwapInt32(&peak, old, cur) {
+						break
+					}
+				}
+				time.Sleep(10 * time.Millisecond)
+				atomic.AddInt32(&active, -1)
+				return n
+			})
+			graph.AddEdge("start", name)
+		}
+		assertNoError(t, graph.Run())
+		return atomic.LoadInt32(&peak)
+	}
+
+	t.Run("Limit", func(t *testing.T) {
+		if peak := run(t, 2); peak > 2 {
+			t.Errorf("Expected at most 2 concurrent nodes, got %d", peak)
+		}
+	})
+
+	t.Run("Serialized", func(t *testing.T) {
+		if peak := run(t, 1); peak != 1 {
+			t.Errorf("Expected serialized execution, got peak %d", peak)
+		}
+	})
+
+	t.Run("LargeGraph", func(t *testing.T) {
+		if peak := run(t, 2, WithLargeGraphThreshold(2)); peak > 2 {
+			t.Errorf("Expected at most 2 concurrent nodes, got %d", peak)
+		}
+	})
+
+	t.Run("Unlimited", func(t *testing.T) {
+		if peak := run(t, 0); peak < 2 {
+			t.Errorf("Expected concurrent execution, got peak %d", peak)
+		}
+	})
+}
+
+func TestGraphWithWorkerPool(t *testing.T) {
+	newGraph := func(pool *WorkerPool, opts ...GraphOption) (*Graph, *int32) {
+		var active, peak int32
+		graph := NewGraph(append([]GraphOption{WithWorkerPool(pool)}, opts...)...)
+		graph.AddNode("start", func() int { return 1 })
+		for i := range 4 {
+			name := fmt.Sprintf("worker%d", i)
+			graph.AddNode(name, func(n int) int {
+				cur := atomic.AddInt32(&active, 1)
+				for {
+					old := atomic.LoadInt32(&peak)
+					if cur <= old || atomic.CompareAndSwapInt32(&peak, old, cur) {
+						break
+					}
+				}
+				time.Sleep(5 * time.Millisecond)
+				atomic.AddInt32(&active, -1)
+				return n + 1
+			})
+			graph.AddEdge("start", name)
+		}
+		return graph, &peak
+	}
+
+	t.Run("DedicatedPool", func(t *testing.T) {
+		pool := NewWorkerPool(1)
+		defer pool.Shutdown()
+		if pool.Size() != 1 {
+			t.Errorf("Expected pool size 1, got %d", pool.Size())
+		}
+
+		graph, peak := newGraph(pool)
+		assertNoError(t, graph.Run())
+		assertNodeResult(t, graph, "worker3", 2)
+		if *peak != 1 {
+			t.Errorf("Expected single-worker pool to serialize nodes, got peak %d", *peak)
+		}
+	})
+
+	t.Run("LargeGraph", func(t *testing.T) {
+		pool := NewWorkerPool(2)
+		defer pool.Shutdown()
+
+		graph, peak := newGraph(pool, WithLargeGraphThreshold(2))
+		assertNoError(t, graph.Run())
+		if *peak > 2 {
+			t.Errorf("Expected at most 2 concurrent nodes, got %d", *peak)
+		}
+	})
+
+	t.Run("SharedPool", func(t *testing.T) {
+		pool := NewWorkerPool(4)
+		defer pool.Shutdown()
+
+		var wg sync.WaitGroup
+		for range 3 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				graph, _ := newGraph(pool)
+				assertNoError(t, graph.Run())
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("Shutdown", func(t *testing.T) {
+		pool := NewWorkerPool(2)
+		pool.Shutdown()
+		pool.Shutdown()
+		if !pool.Closed() {
+			t.Error("Expected pool to be closed")
+		}
+
+		graph, _ := newGraph(pool)
+		err := graph.Run()
+		assertError(t, err)
+		assertContains(t, err.Error(), ErrWorkerPoolClosed)
+
+		done := make(chan error, 1)
+		go func() { done <- pool.Submit(&nodeTask{name: "late"}) }()
+		select {
+		case err := <-done:
+			if !errors.Is(err, ErrWorkerPoolClosedErr) {
+				t.Fatalf("expected closed pool error from Submit, got %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Submit after Shutdown blocked")
+		}
+	})
+}
+
+func TestGraphProgressHandler(t *testing.T) {
+	for _, mode := range []string{"Sequential", "Parallel"} {
+		t.Run(mode, func(t *testing.T) {
+			var mu sync.Mutex
+			var calls [][2]int
+
+			graph := NewGraph()
+			graph.AddNode("a", func() int { return 1 })
+			graph.AddNode("b", func(n int) int { return n + 1 })
+			graph.AddNode("c", func(n int) int { return n + 1 })
+			graph.AddNode("d", func(n int) int { return n + 1 })
+			graph.AddEdge("a", "b")
+			graph.AddEdge("b", "c")
+			graph.AddEdge("c", "d")
+			graph.SetProgressHandler(func(completed, total int) {
+				mu.Lock()
+				defer mu.Unlock()
+				calls = append(calls, [2]int{completed, total})
+			})
+
+			var err error
+			if mode == "Sequential" {
+				err = graph.RunSequential()
+			} else {
+				err = graph.Run()
+			}
+			assertNoError(t, err)
+
+			expected := [][2]int{{1, 4}, {2, 4}, {3, 4}, {4, 4}}
+			if !reflect.DeepEqual(calls, expected) {
+				t.Errorf("Expected progress %v, got %v", expected, calls)
+			}
+		})
+	}
+}
+
+func TestGraphValidate(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		graph := NewGraph()
+		graph.AddNode("init", func() (int, string) { return 1, "a" })
+		graph.AddNode("pair", func(n int, s string) string { return fmt.Sprint(n, s) })
+		graph.AddNode("list", func() []int { return []int{1, 2} })
+		graph.AddNode("spread", func(a, b int) int { return a + b })
+		graph.AddEdge("init", "pair")
+		graph.AddEdge("list", "spread")
+
+		assertNoError(t, graph.Validate())
+	})
+
+	t.Run("MissingInputs", func(t *testing.T) {
+		graph := NewGraph()
+		graph.AddNode("a", func() int { return 1 })
+		graph.AddNode("b", func() (int, error) { return 2, nil })
+		graph.AddNode("sum3", func(x, y, z int) int { return x + y + z })
+		graph.AddEdge("a", "sum3")
+		graph.AddEdge("b", "sum3")
+
+		err := graph.Validate()
+		var verr *ValidationError
+		if !errors.As(err, &verr) {
+			t.Fatalf("Expected ValidationError, got %v", err)
+		}
+		assertContains(t, err.Error(), "node sum3 requires 3 inputs but incoming edges supply 2")
+	})
+
+	t.Run("CycleAndUnreachable", func(t *testing.T) {
+		graph := NewGraph()
+		graph.AddNode("start", func() int { return 1 })
+		graph.AddNode("a", func(n int) int { return n })
+		graph.AddNode("b", func(n int) int { return n })
+		graph.AddEdge("a", "b")
+		graph.edges["b"] = append(graph.edges["b"], &Edge{from: "b", to: "a"})
+		graph.inDegree["a"]++
+
+		err := graph.Validate()
+		assertError(t, err)
+		assertContains(t, err.Error(), ErrCyclicDependency)
+		assertContains(t, err.Error(), "node a is unreachable")
+		assertContains(t, err.Error(), "node b is unreachable")
+	})
+
+	t.Run("DanglingEdge", func(t *testing.T) {
+		graph := NewGraph()
+		graph.AddNode("start", func() int { return 1 })
+		graph.edges["start"] = append(graph.edges["start"], &Edge{from: "start", to: "ghost", edgeType: EdgeTypeBranch})
+
+		err := graph.Validate()
+		assertError(t, err)
+		assertContains(t, err.Error(), "edge start -> ghost: node not found: ghost")
+	})
+
+	t.Run("DoesNotExecute", func(t *testing.T) {
+		ran := false
+		graph := NewGraph()
+		graph.AddNode("start", func() int { ran = true; return 1 })
+
+		assertNoError(t, graph.Validate())
+		if ran {
+			t.Error("Expected Validate not to execute nodes")
+		}
+	})
+}
+
+func TestGraphUnreachableNodes(t *testing.T) {
+	t.Run("AllReachable", func(t *testing.T) {
+		graph := NewGraph()
+		graph.AddNode("start", func() int { return 1 })
+		graph.AddNode("next", func(n int) int { return n + 1 })
+		graph.AddEdge("start", "next")
+		graph.AddLoopEdge("next", func(n int) bool { return n < 3 })
+
+		if nodes := graph.UnreachableNodes(); len(nodes) != 0 {
+			t.Errorf("Expected no unreachable nodes, got %v", nodes)
+		}
+		assertNoError(t, graph.Run())
+		if nodes := graph.UnreachableNodes(); len(nodes) != 0 {
+			t.Errorf("Expected no unreachable nodes after run, got %v", nodes)
+		}
+	})
+
+	t.Run("BlockedByCycle", func(t *testing.T) {
+		graph := NewGraph()
+		graph.AddNode("start", func() int { return 1 })
+		graph.AddNode("a", func(n int) int { return n })
+		graph.AddNode("b", func(n int) int { return n })
+		graph.AddNode("c", func(n int) int { return n })
+		graph.AddEdge("a", "b")
+		graph.AddEdge("b", "c")
+		graph.edges["b"] = append(graph.edges["b"], &Edge{from: "b", to: "a"})
+
+		expected := []string{"a", "b", "c"}
+		if nodes := graph.UnreachableNodes(); !reflect.DeepEqual(nodes, expected) {
+			t.Errorf("Expected %v, got %v", expected, nodes)
+		}
+	})
+}
+
+func TestGraphTerminalResults(t *testing.T) {
+	graph := NewGraph()
+	graph.AddNode("start", func() int { return 4 })
+	graph.AddNode("end_square", func(n int) int { return n * n })
+	graph.AddNode("end_cube", func(n int) int { return n * n * n })
+	graph.AddNode("check", func(n int) int { return n })
+	graph.AddNode("big", func(n int) string { return "big" })
+	graph.AddNode("small", func(n int) string { return "small" })
+	graph.AddEdge("start", "end_square")
+	graph.AddEdge("start", "end_cube")
+	graph.AddEdge("start", "check")
+	graph.AddBranchEdge("check", map[string]any{
+		"big":   func(n int) bool { return n > 10 },
+		"small": func(n int) bool { return n <= 10 },
+	})
+
+	if results := graph.TerminalResults(); len(results) != 0 {
+		t.Errorf("Expected no terminal results before run, got %v", results)
+	}
+
+	assertNoError(t, graph.Run())
+
+	expected := map[string][]any{
+		"end_square": {16},
+		"end_cube":   {64},
+		"small":      {"small"},
+	}
+	if results := graph.TerminalResults(); !reflect.DeepEqual(results, expected) {
+		t.Errorf("Expected %v, got %v", expected, results)
+	}
+}
+
+func TestGraphEdgeLabels(t *testing.T) {
+	graph := NewGraph()
+	graph.AddNode("start", func() int { return 60 })
+	graph.AddNode("high", func(n int) int { return n })
+	graph.AddNode("check", func(n int) int { return n })
+	graph.AddNode("approve", func(n int) int { return n })
+	graph.AddNode("reject", func(n int) int { return n })
+	graph.AddNode("manual", func(n int) int { return n })
+	graph.AddEdgeWithCondition("start", "high", func(n int) bool { return n > 50 }, WithLabel("n > 50"))
+	graph.AddEdge("start", "check")
+	graph.AddBranchEdgeWithDefault("check", map[string]any{
+		"approve": func(n int) bool { return n < 100 },
+		"reject":  func(n int) bool { return n > 1000 },
+	}, "manual")
+
+	mermaid := graph.Mermaid()
+	assertContains(t, mermaid, `start --> |"n > 50"|high`)
+	assertContains(t, mermaid, "check --> |approve|approve")
+	assertContains(t, mermaid, "check --> |reject|reject")
+	assertContains(t, mermaid, "check --> |default|manual")
+	assertContains(t, mermaid, "start --> check")
+
+	dot := graph.String()
+	assertContains(t, dot, `"start" -> "high" [label="n > 50"];`)
+	assertContains(t, dot, `"check" -> "manual" [label="default"];`)
+	assertContains(t, dot, `"start" -> "check" [];`)
+
+	clone := graph.Clone()
+	assertContains(t, clone.Mermaid(), `start --> |"n > 50"|high`)
+
+	quoted := NewGraph()
+	quoted.AddNode("a", func() int { return 1 })
+	quoted.AddNode("b", func(n int) int { return n })
+	quoted.AddEdgeWithCondition("a", "b", func(n int) bool { return n > 0 }, WithLabel(`x | "y"`))
+	assertContains(t, quoted.Mermaid(), `a --> |"x | #quot;y#quot;"|b`)
+}
+
+func TestGraphJSONRoundTrip(t *testing.T) {
+	registry := map[string]any{
+		"start":                        func() int { return 3 },
+		"grow":                         func(n int) int { return n * 2 },
+		"check":                        func(n int) int { return n },
+		"big":                          func(n int) string { return fmt.Sprintf("big:%d", n) },
+		"small":                        func(n int) string { return fmt.Sprintf("small:%d", n) },
+		ConditionKey("grow", "grow"):   func(n int) bool { return n < 20 },
+		ConditionKey("check", "big"):   func(n int) bool { return n >= 20 },
+		ConditionKey("check", "small"): func(n int) bool { return n < 20 },
+	}
+
+	graph := NewGraph()
+	graph.AddNode("start", registry["start"], WithMeta(map[string]string{"owner": "ops"}))
+	graph.AddNode("grow", registry["grow"])
+	graph.AddNode("check", registry["check"])
+	graph.AddNode("big", registry["big"])
+	graph.AddNode("small", registry["small"])
+	graph.AddEdge("start", "grow", WithPriority(2))
+	graph.AddLoopEdge("grow", registry[ConditionKey("grow", "grow")], 10)
+	graph.AddEdge("grow", "check")
+	graph.AddBranchEdge("check", map[string]any{
+		"big":   registry[ConditionKey("check", "big")],
+		"small": registry[ConditionKey("check", "small")],
+	})
+
+	data, err := graph.ExportJSON()
+	assertNoError(t, err)
+	assertContains(t, string(data), `"type": "branch"`)
+	assertContains(t, string(data), `"condition": "check->big"`)
+
+	loaded, err := LoadGraphJSON(data, registry)
+	assertNoError(t, err)
+
+	again, err := loaded.ExportJSON()
+	assertNoError(t, err)
+	if string(again) != string(data) {
+		t.Errorf("Expected stable export, got:\n%s\nwant:\n%s", again, data)
+	}
+
+	assertNoError(t, loaded.Run())
+	assertNodeResult(t, loaded, "big", "big:24")
+	if meta, _ := loaded.NodeMeta("start"); meta["owner"] != "ops" {
+		t.Errorf("Expected meta to round-trip, got %v", meta)
+	}
+
+	t.Run("MissingNode", func(t *testing.T) {
+		_, err := LoadGraphJSON([]byte(`{"version":1,"nodes":[{"name":"a"}],"edges":[{"from":"a","to":"b"}]}`),
+			map[string]any{"a": func() int { return 1 }})
+		assertError(t, err)
+		assertContains(t, err.Error(), "node not found: b")
+	})
+
+	t.Run("UnboundFunction", func(t *testing.T) {
+		_, err := LoadGraphJSON([]byte(`{"version":1,"nodes":[{"name":"a"}]}`), nil)
+		assertError(t, err)
+		assertContains(t, err.Error(), ErrUnboundNode)
+	})
+
+	t.Run("UnknownEdgeType", func(t *testing.T) {
+		_, err := LoadGraphJSON([]byte(`{"nodes":[{"name":"a"},{"name":"b"}],"edges":[{"from":"a","to":"b","type":"jump"}]}`),
+			map[string]any{"a": nil, "b": nil})
+		assertError(t, err)
+		assertContains(t, err.Error(), ErrUnknownEdgeType)
+	})
+}
+
+func TestGraphAddEdgeOutOfOrder(t *testing.T) {
+	graph := NewGraph()
+	graph.AddNode("a", func() int { return 1 })
+	graph.AddNode("b", func(n int) int { return n + 1 })
+	graph.AddNode("c", func(n int) int { return n + 1 })
+	graph.AddNode("d", func(n int) int { return n + 1 })
+
+	assertNoError(t, graph.AddEdgeE("c", "d"))
+	assertNoError(t, graph.AddEdgeE("b", "c"))
+	assertNoError(t, graph.AddEdgeE("a", "b"))
+	assertError(t, graph.AddEdgeE("d", "a"))
+
+	assertNoError(t, graph.Run())
+	assertNodeResult(t, graph, "d", 4)
+}
+
+func TestGraphAddCachedNode(t *testing.T) {
+	var calls int32
+	input := 3
+
+	graph := NewGraph()
+	graph.AddNode("input", func() int { return input })
+	graph.AddCachedNode("square", func(n int) int {
+		atomic.AddInt32(&calls, 1)
+		return n * n
+	})
+	graph.AddEdge("input", "square")
+
+	for range 3 {
+		graph.Reset()
+		assertNoError(t, graph.Run())
+		assertNodeResult(t, graph, "square", 9)
+	}
+	if calls != 1 {
+		t.Errorf("Expected 1 call for identical inputs, got %d", calls)
+	}
+
+	input = 4
+	graph.Reset()
+	assertNoError(t, graph.RunSequential())
+	assertNodeResult(t, graph, "square", 16)
+	if calls != 2 {
+		t.Errorf("Expected a new call for different inputs, got %d", calls)
+	}
+
+	graph.ClearNodeCache()
+	graph.Reset()
+	assertNoError(t, graph.Run())
+	if calls != 3 {
+		t.Errorf("Expected cache to be cleared, got %d calls", calls)
+	}
+
+	t.Run("LossyInputsNotShared", func(t *testing.T) {
+		type secret struct{ n int }
+		type public struct{ N int }
+		var calls int
+		var input any
+		graph := NewGraph()
+		graph.AddNode("input", func() any { return input })
+		graph.AddCachedNode("describe", func(v any) string {
+			calls++
+			return fmt.Sprint(v)
+		})
+		graph.AddEdge("input", "describe")
+
+		for _, tc := range []struct {
+			input    any
+			expected string
+			calls    int
+		}{
+			{secret{1}, "{1}", 1},
+			{secret{2}, "{2}", 2},
+			{public{1}, "{1}", 3},
+			{public{1}, "{1}", 3},
+			{map[string]int{"N": 1}, "map[N:1]", 4},
+		} {
+			input = tc.input
+			graph.Reset()
+			assertNoError(t, graph.Run())
+			assertNodeResult(t, graph, "describe", tc.expected)
+			assertEqual(t, tc.calls, calls)
+		}
+	})
+
+	t.Run("ErrorsNotCached", func(t *testing.T) {
+		var attempts int
+		graph := NewGraph()
+		graph.AddCachedNode("flaky", func() (int, error) {
+			attempts++
+			if attempts == 1 {
+				return 0, errors.New("temporary")
+			}
+			return attempts, nil
+		})
+
+		assertError(t, graph.Run())
+		graph.Reset()
+		assertNoError(t, graph.Run())
+		assertNodeResult(t, graph, "flaky", 2)
+	})
+
+	t.Run("UnhashableInputs", func(t *testing.T) {
+		var runs int
+		ch := make(chan int)
+		graph := NewGraph()
+		graph.AddNode("source", func() chan int { return ch })
+		graph.AddCachedNode("consume", func(c chan int) int {
+			runs++
+			return runs
+		})
+		graph.AddEdge("source", "consume")
+
+		assertNoError(t, graph.Run())
+		graph.Reset()
+		assertNoError(t, graph.Run())
+		if runs != 2 {
+			t.Errorf("Expected unhashable inputs to bypass the cache, got %d runs", runs)
+		}
+	})
+}
+
+func TestGraphRunUntil(t *testing.T) {
+	graph := NewGraph()
+	graph.AddNode("load", func() int { return 2 })
+	graph.AddNode("config", func() int { return 10 })
+	graph.AddNode("other", func() int { return 99 })
+	graph.AddNode("combine", func(a, b int) int { return a * b })
+	graph.AddNode("approve", func(n int) int { return n + 1 })
+	graph.AddNode("ship", func(n int) int { return n + 1 })
+	graph.AddEdge("load", "combine")
+	graph.AddEdge("config", "combine")
+	graph.AddEdge("combine", "approve")
+	graph.AddEdge("approve", "ship")
+
+	assertNoError(t, graph.RunUntil(context.Background(), "approve"))
+	assertNodeResult(t, graph, "approve", 21)
+	assertNodeStatus(t, graph, "ship", NodeStatusPending)
+	assertNodeStatus(t, graph, "other", NodeStatusPending)
+	assertNodeStatus(t, graph, "load", NodeStatusCompleted)
+
+	assertNoError(t, graph.RunSequential())
+	assertNodeResult(t, graph, "ship", 22)
+
+	err := graph.RunUntil(context.Background(), "missing")
+	assertError(t, err)
+	assertContains(t, err.Error(), ErrNodeNotFound)
+}
+
+func TestGraphRunFrom(t *testing.T) {
+	var loads atomic.Int32
+	graph := NewGraph()
+	graph.AddNode("load", func() int { loads.Add(1); return 2 })
+	graph.AddNode("double", func(n int) int { return n * 2 })
+	graph.AddNode("format", func(n int) string { return strconv.Itoa(n) })
+	graph.AddEdge("load", "double")
+	graph.AddEdge("double", "format")
+
+	assertNoError(t, graph.RunSequential())
+	assertNodeResult(t, graph, "format", "4")
+
+	assertNoError(t, graph.RunFrom(context.Background(), "double", nil))
+	assertNodeResult(t, graph, "format", "4")
+	if loads.Load() != 1 {
+		t.Errorf("expected load to run once, ran %d times", loads.Load())
+	}
+
+	assertNoError(t, graph.RunFrom(context.Background(), "double", []any{5}))
+	assertNodeResult(t, graph, "double", 10)
+	assertNodeResult(t, graph, "format", "10")
+	if loads.Load() != 1 {
+		t.Errorf("expected load to run once, ran %d times", loads.Load())
+	}
+
+	err := graph.RunFrom(context.Background(), "double", []any{1, 2})
+	assertError(t, err)
+	assertContains(t, err.Error(), ErrArgCountMismatch)
+
+	err = graph.RunFrom(context.Background(), "missing", nil)
+	assertError(t, err)
+	assertContains(t, err.Error(), ErrNodeNotFound)
+
+	fresh := NewGraph()
+	fresh.AddNode("load", func() int { return 2 })
+	fresh.AddNode("double", func(n int) int { return n * 2 })
+	fresh.AddEdge("load", "double")
+	err = fresh.RunFrom(context.Background(), "double", nil)
+	assertError(t, err)
+	assertContains(t, err.Error(), ErrNodeNotCompleted)
+
+	var sides atomic.Int32
+	diamond := NewGraph()
+	diamond.AddNode("a", func() int { return 1 })
+	diamond.AddNode("b", func(n int) int { sides.Add(1); return n + 10 })
+	diamond.AddNode("c", func(n int) int { return n * 2 })
+	diamond.AddNode("d", func(b, c int) int { return b + c })
+	diamond.AddEdge("a", "b")
+	diamond.AddEdge("a", "c")
+	diamond.AddEdge("b", "d")
+	diamond.AddEdge("c", "d")
+
+	err = diamond.RunFrom(context.Background(), "c", []any{5})
+	if !errors.Is(err, ErrNodeNotCompletedErr) || !strings.Contains(err.Error(), "b (input of d)") {
+		t.Fatalf("expected b to be reported as not completed, got %v", err)
+	}
+	assertNodeStatus(t, diamond, "a", NodeStatusPending)
+
+	assertNoError(t, diamond.Run())
+	assertNodeResult(t, diamond, "d", 13)
+	assertNoError(t, diamond.RunFrom(context.Background(), "c", []any{5}))
+	assertNodeResult(t, diamond, "c", 10)
+	assertNodeResult(t, diamond, "d", 21)
+	assertEqual(t, int32(1), sides.Load())
+}
+
+func TestGraphDeterministicPlan(t *testing.T) {
+	build := func() []string {
+		graph := NewGraph()
+		for _, name := range []string{"delta", "alpha", "charlie", "bravo", "echo"} {
+			graph.AddNode(name, func() int { return 1 })
+		}
+		graph.AddNode("sink", func(values []int) int { return len(values) })
+		for _, name := range []string{"delta", "alpha", "charlie", "bravo", "echo"} {
+			graph.AddEdge(name, "sink")
+		}
+		order, err := graph.TopologicalOrder()
+		assertNoError(t, err)
+		return order
+	}
+
+	expected := []string{"alpha", "bravo", "charlie", "delta", "echo", "sink"}
+	for range 50 {
+		if order := build(); !reflect.DeepEqual(order, expected) {
+			t.Fatalf("expected stable plan %v, got %v", expected, order)
+		}
+	}
+}
+
+func TestGraphOutputOrder(t *testing.T) {
+	build := func() *Graph {
+		graph := NewGraph()
+		graph.AddNode("zeta", func() int { return 1 })
+		graph.AddNode("alpha", func(n int) int { return n })
+		graph.AddNode("mid", func(n int) int { return n })
+		graph.AddNode("lone", func() int { return 0 })
+		graph.AddEdge("zeta", "alpha")
+		graph.AddEdge("zeta", "mid")
+		graph.AddEdge("alpha", "mid")
+		return graph
+	}
+
+	mermaid := build().Mermaid()
+	dot := build().String()
+	for range 20 {
+		assertEqual(t, build().Mermaid(), mermaid)
+		assertEqual(t, build().String(), dot)
+	}
+
+	zeta := strings.Index(mermaid, "zeta --> alpha")
+	alpha := strings.Index(mermaid, "alpha --> mid")
+	if zeta < 0 || alpha < 0 || zeta > alpha {
+		t.Errorf("expected edges in node insertion order, got:\n%s", mermaid)
+	}
+	if strings.Index(dot, `"zeta" [`) > strings.Index(dot, `"alpha" [`) {
+		t.Errorf("expected nodes in insertion order, got:\n%s", dot)
+	}
+	assertEqual(t, build().Clone().Mermaid(), mermaid)
+}
+
+func TestGraphErrorModeCollect(t *testing.T) {
+	build := func() *Graph {
+		graph := NewGraph()
+		graph.AddNode("fetchA", func() (int, error) { return 0, errors.New("a unavailable") })
+		graph.AddNode("saveA", func(n int) int { return n })
+		graph.AddNode("fetchB", func() (int, error) { return 0, errors.New("b unavailable") })
+		graph.AddNode("saveB", func(n int) int { return n })
+		graph.AddNode("fetchC", func() int { return 3 })
+		graph.AddNode("saveC", func(n int) int { return n * 2 })
+		graph.AddEdge("fetchA", "saveA")
+		graph.AddEdge("fetchB", "saveB")
+		graph.AddEdge("fetchC", "saveC")
+		graph.SetErrorMode(ErrorModeCollect)
+		return graph
+	}
+
+	runners := map[string]func(*Graph) error{
+		"parallel":   (*Graph).Run,
+		"sequential": (*Graph).RunSequential,
+		"large": func(g *Graph) error {
+			g.largeThreshold = 1
+			return g.Run()
+		},
+	}
+	for mode, run := range runners {
+		t.Run(mode, func(t *testing.T) {
+			graph := build()
+			err := run(graph)
+			assertError(t, err)
+
+			var multi *MultiError
+			if !errors.As(err, &multi) {
+				t.Fatalf("expected MultiError, got %T: %v", err, err)
+			}
+			assertEqual(t, 2, len(multi.Errors))
+			assertContains(t, err.Error(), "fetchA")
+			assertContains(t, err.Error(), "fetchB")
+
+			assertNodeResult(t, graph, "saveC", 6)
+			assertNodeStatus(t, graph, "fetchA", NodeStatusFailed)
+			assertNodeStatus(t, graph, "saveA", NodeStatusPending)
+			assertNodeStatus(t, graph, "saveB", NodeStatusPending)
+		})
+	}
+
+	graph := build()
+	graph.SetErrorMode(ErrorModeFailFast)
+	err := graph.Run()
+	assertError(t, err)
+	var multi *MultiError
+	if errors.As(err, &multi) {
+		t.Errorf("expected a single error in fail-fast mode, got %v", err)
+	}
+}
+
+func TestGraphAddWeightedBranch(t *testing.T) {
+	picks := func(seed int64, runs int) []string {
+		graph := NewGraph()
+		graph.AddNode("start", func() int { return 1 })
+		graph.AddNode("stable", func(n int) string { return "stable" })
+		graph.AddNode("canary", func(n int) string { return "canary" })
+		graph.AddNode("end", func(s string) string { return s })
+		graph.AddWeightedBranch("start", map[string]int{"stable": 3, "canary": 1})
+		graph.AddEdge("stable", "end")
+		graph.AddEdge("canary", "end")
+		graph.SetRandSeed(seed)
+		assertNoError(t, graph.Error())
+
+		var out []string
+		for i := 0; i < runs; i++ {
+			graph.ClearStatus()
+			assertNoError(t, graph.Run())
+			result, err := graph.NodeResult("end")
+			assertNoError(t, err)
+			out = append(out, result[0].(string))
+		}
+		return out
+	}
+
+	first := picks(42, 400)
+	assertEqual(t, first, picks(42, 400))
+	canary := 0
+	for _, pick := range first {
+		if pick == "canary" {
+			canary++
+		}
+	}
+	if canary < 60 || canary > 140 {
+		t.Errorf("Expected about a quarter of runs on canary, got %d of %d", canary, len(first))
+	}
+
+	batchPicks := func() []string {
+		graph := NewGraph()
+		graph.AddNode("start", func(n int) int { return n })
+		graph.AddNode("a", func(n int) int { return n })
+		graph.AddNode("b", func(n int) int { return n })
+		graph.AddWeightedBranch("start", map[string]int{"a": 1, "b": 1})
+		graph.SetRandSeed(7)
+
+		inputs := make([][]any, 40)
+		for i := range inputs {
+			inputs[i] = []any{i}
+		}
+		results, err := graph.RunBatch(context.Background(), inputs)
+		assertNoError(t, err)
+		out := make([]string, len(results))
+		for i, result := range results {
+			if _, ok := result["a"]; ok {
+				out[i] = "a"
+			} else {
+				out[i] = "b"
+			}
+		}
+		return out
+	}
+	batch := batchPicks()
+	assertEqual(t, batch, batchPicks())
+	onA := 0
+	for _, pick := range batch {
+		if pick == "a" {
+			onA++
+		}
+	}
+	if onA < 10 || onA > 30 {
+		t.Errorf("Expected batch runs to spread over both targets, got %d of %d on a", onA, len(batch))
+	}
+
+	graph := NewGraph()
+	graph.AddNode("start", func() int { return 1 })
+	graph.AddNode("a", func(n int) int { return n })
+	graph.AddWeightedBranch("start", map[string]int{"a": 0})
+	if !errors.Is(graph.Error(), ErrInvalidBranchWeightErr) {
+		t.Errorf("Expected invalid branch weight error, got %v", graph.Error())
+	}
+}
+
+func TestGraphBranchModeFirstMatch(t *testing.T) {
+	build := func(mode BranchMode) *Graph {
+		graph := NewGraph()
+		graph.AddNode("score", func() int { return 95 })
+		graph.AddNode("excellent", func(n int) string { return "excellent" })
+		graph.AddNode("good", func(n int) string { return "good" })
+		graph.AddNode("pass", func(n int) string { return "pass" })
+		graph.AddOrderedBranchEdge("score", []BranchCase{
+			{To: "excellent", Cond: func(n int) bool { return n >= 90 }},
+			{To: "good", Cond: func(n int) bool { return n >= 75 }},
+			{To: "pass", Cond: func(n int) bool { return n >= 60 }},
+		})
+		graph.SetBranchMode(mode)
+		return graph
+	}
+
+	graph := build(BranchModeFirstMatch)
+	assertNoError(t, graph.Run())
+	assertNodeResult(t, graph, "excellent", "excellent")
+	assertNodeStatus(t, graph, "good", NodeStatusPending)
+	assertNodeStatus(t, graph, "pass", NodeStatusPending)
+
+	graph = build(BranchModeAll)
+	assertNoError(t, graph.Run())
+	assertNodeStatus(t, graph, "excellent", NodeStatusCompleted)
+	assertNodeStatus(t, graph, "good", NodeStatusCompleted)
+	assertNodeStatus(t, graph, "pass", NodeStatusCompleted)
+
+	mapped := NewGraph()
+	mapped.AddNode("check", func() int { return 10 })
+	mapped.AddNode("b", func(n int) int { return n })
+	mapped.AddNode("a", func(n int) int { return n })
+	mapped.AddBranchEdge("check", map[string]any{
+		"b": func(n int) bool { return n > 0 },
+		"a": func(n int) bool { return n > 5 },
+	})
+	mapped.SetBranchMode(BranchModeFirstMatch)
+	assertNoError(t, mapped.Run())
+	assertNodeStatus(t, mapped, "a", NodeStatusCompleted)
+	assertNodeStatus(t, mapped, "b", NodeStatusPending)
+
+	var calls atomic.Int32
+	counted := func(limit int) func(int) bool {
+		return func(n int) bool {
+			calls.Add(1)
+			return n >= limit
+		}
+	}
+	once := NewGraph()
+	once.AddNode("score", func() int { return 70 })
+	cases := make([]BranchCase, 0, 5)
+	for i, limit := range []int{90, 80, 75, 65, 60} {
+		name := fmt.Sprintf("tier%d", i)
+		once.AddNode(name, func(n int) int { return n })
+		cases = append(cases, BranchCase{To: name, Cond: counted(limit)})
+	}
+	once.AddOrderedBranchEdge("score", cases)
+	once.SetBranchMode(BranchModeFirstMatch)
+	for run := range 2 {
+		calls.Store(0)
+		assertNoError(t, once.Run())
+		assertNodeStatus(t, once, "tier3", NodeStatusCompleted)
+		if got := calls.Load(); got != 4 {
+			t.Fatalf("run %d: expected 4 condition calls, got %d", run, got)
+		}
+		once.Reset()
+	}
+}
+
+func TestGraphInputsOutputsOf(t *testing.T) {
+	graph := NewGraph()
+	graph.AddNode("orders", func() int { return 1 })
+	graph.AddNode("users", func() int { return 2 })
+	graph.AddNode("join", func(a, b int) int { return a + b })
+	graph.AddNode("report", func(n int) int { return n })
+	graph.AddNode("audit", func(n int) int { return n })
+	graph.AddEdge("users", "join")
+	graph.AddEdge("orders", "join")
+	graph.AddEdge("join", "report")
+	graph.AddEdge("join", "audit")
+
+	assertEqual(t, []string{"orders", "users"}, graph.InputsOf("join"))
+	assertEqual(t, []string{"audit", "report"}, graph.OutputsOf("join"))
+	assertEqual(t, []string{}, graph.InputsOf("orders"))
+	assertEqual(t, []string{}, graph.OutputsOf("report"))
+	if graph.InputsOf("missing") != nil || graph.OutputsOf("missing") != nil {
+		t.Error("expected nil for unknown node")
+	}
+
+	inputs := graph.InputsOf("join")
+	inputs[0] = "changed"
+	assertEqual(t, []string{"orders", "users"}, graph.InputsOf("join"))
+}
+
+func TestGraphStallTimeout(t *testing.T) {
+	build := func() *Graph {
+		graph := NewGraph()
+		graph.AddNode("fetch", func() int { return 1 })
+		graph.AddNode("hang", func(ctx context.Context, n int) int {
+			<-ctx.Done()
+			return n
+		})
+		graph.AddNode("report", func(n int) int { return n })
+		graph.AddEdge("fetch", "hang")
+		graph.AddEdge("hang", "report")
+		graph.SetStallTimeout(50 * time.Millisecond)
+		return graph
+	}
+
+	start := time.Now()
+	err := build().Run()
+	assertError(t, err)
+	assertContains(t, err.Error(), ErrExecutionStalled)
+	assertContains(t, err.Error(), "hang (running)")
+	assertContains(t, err.Error(), "report waiting on hang (running)")
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected stall detection to abort quickly, took %v", elapsed)
+	}
+
+	large := build()
+	large.largeThreshold = 1
+	err = large.Run()
+	assertError(t, err)
+	assertContains(t, err.Error(), ErrExecutionStalled)
+}
+
+func TestGraphMixedIncomingEdgeOrder(t *testing.T) {
+	for range 20 {
+		graph := NewGraph()
+		graph.AddNode("route", func() int { return 5 })
+		graph.AddNode("config", func() string { return "cfg" })
+		graph.AddNode("target", func(n int, cfg string) string { return cfg + ":" + strconv.Itoa(n) })
+		graph.AddNode("other", func(n int, cfg string) string { return "other" })
+		graph.AddBranchEdge("route", map[string]any{
+			"target": func(n int) bool { return n > 0 },
+			"other":  func(n int) bool { return n < 0 },
+		})
+		graph.AddEdge("config", "target")
+		graph.AddEdge("config", "other")
+		assertNoError(t, graph.Run())
+		assertNodeResult(t, graph, "target", "cfg:5")
+		assertNodeStatus(t, graph, "other", NodeStatusPending)
+	}
+
+	for range 20 {
+		graph := NewGraph()
+		graph.AddNode("route", func() int { return 5 })
+		graph.AddNode("left", func(n int) string { return "left" })
+		graph.AddNode("right", func(n int) string { return "right" })
+		graph.AddNode("config", func() int { return 7 })
+		graph.AddNode("join", func(n int, side string) string { return side + ":" + strconv.Itoa(n) })
+		graph.AddBranchEdge("route", map[string]any{
+			"left":  func(n int) bool { return n > 0 },
+			"right": func(n int) bool { return n < 0 },
+		})
+		graph.AddEdge("config", "join")
+		graph.AddEdge("right", "join")
+		graph.AddEdge("left", "join")
+		assertNoError(t, graph.Run())
+		assertNodeResult(t, graph, "join", "left:7")
+	}
+}
+
+func TestGraphVariadicFanIn(t *testing.T) {
+	sum := func(parts ...int) int {
+		total := 0
+		for _, p := range parts {
+			total += p
+		}
+		return total
+	}
+
+	for _, sequential := range []bool{false, true} {
+		graph := NewGraph()
+		graph.AddNode("a", func() int { return 1 })
+		graph.AddNode("b", func() int { return 2 })
+		graph.AddNode("c", func() int { return 3 })
+		graph.AddNode("merge", sum)
+		graph.AddEdge("a", "merge")
+		graph.AddEdge("b", "merge")
+		graph.AddEdge("c", "merge")
+		if sequential {
+			assertNoError(t, graph.RunSequential())
+		} else {
+			assertNoError(t, graph.Run())
+		}
+		assertNodeResult(t, graph, "merge", 6)
+	}
+
+	graph := NewGraph()
+	graph.AddNode("a", func() int { return 4 })
+	graph.AddNode("merge", sum)
+	graph.AddEdge("a", "merge")
+	assertNoError(t, graph.Run())
+	assertNodeResult(t, graph, "merge", 4)
+
+	graph = NewGraph()
+	graph.AddNode("prefix", func() string { return "n" })
+	graph.AddNode("a", func() int { return 1 })
+	graph.AddNode("b", func() int { return 2 })
+	graph.AddNode("join", func(prefix string, parts ...int) string {
+		out := prefix
+		for _, p := range parts {
+			out += strconv.Itoa(p)
+		}
+		return out
+	})
+	graph.AddEdge("prefix", "join")
+	graph.AddEdge("a", "join")
+	graph.AddEdge("b", "join")
+	assertNoError(t, graph.RunSequential())
+	assertNodeResult(t, graph, "join", "n12")
+}
+
+func TestGraphMetrics(t *testing.T) {
+	graph := NewGraph()
+	graph.AddNode("start", func() int { return 1 })
+	graph.AddNode("slow", func(n int) int {
+		time.Sleep(20 * time.Millisecond)
+		return n
+	})
+	graph.AddNode("fail", func(n int) (int, error) { return 0, errors.New("boom") })
+	graph.AddNode("after", func(n int) int { return n })
+	graph.AddEdge("start", "slow")
+	graph.AddEdge("start", "fail")
+	graph.AddEdge("fail", "after")
+
+	metrics := graph.Metrics()
+	if metrics.TotalNodes != 4 || metrics.Pending != 4 || metrics.Skipped != 0 || metrics.WallTime != 0 {
+		t.Fatalf("unexpected metrics before run: %+v", metrics)
+	}
+
+	graph.SetErrorMode(ErrorModeCollect)
+	if err := graph.Run(); err == nil {
+		t.Fatal("expected run to fail")
+	}
+
+	metrics = graph.Metrics()
+	if metrics.Completed != 2 || metrics.Failed != 1 || metrics.Skipped != 1 || metrics.Pending != 0 {
+		t.Fatalf("unexpected counts: %+v", metrics)
+	}
+	if metrics.SlowestNode != "slow" || metrics.SlowestDuration < 20*time.Millisecond {
+		t.Fatalf("unexpected slowest node: %+v", metrics)
+	}
+	if metrics.WallTime < metrics.SlowestDuration {
+		t.Fatalf("wall time %v shorter than slowest node %v", metrics.WallTime, metrics.SlowestDuration)
+	}
+
+	data, err := json.Marshal(metrics)
+	assertNoError(t, err)
+	var decoded RunMetrics
+	assertNoError(t, json.Unmarshal(data, &decoded))
+	if decoded != metrics {
+		t.Fatalf("metrics did not round-trip: %+v != %+v", decoded, metrics)
+	}
+
+	graph.Reset()
+	if metrics := graph.Metrics(); metrics.Pending != 4 || metrics.WallTime != 0 || metrics.SlowestNode != "" {
+		t.Fatalf("unexpected metrics after reset: %+v", metrics)
+	}
+
+	paused := NewGraph()
+	paused.AddNode("start", func() int { return 1 })
+	paused.AddNode("middle", func(n int) int { return n })
+	paused.AddNode("end", func(n int) int { return n })
+	paused.AddEdge("start", "middle")
+	paused.AddEdge("middle", "end")
+	paused.SetPauseConfig(NewPauseConfig().SetPauseAtNodes("middle"))
+	if err := paused.Run(); !errors.Is(err, ErrFlowPaused) {
+		t.Fatalf("expected pause, got %v", err)
+	}
+	if metrics := paused.Metrics(); metrics.Completed != 1 || metrics.Pending != 2 || metrics.Skipped != 0 {
+		t.Fatalf("unexpected metrics after pause: %+v", metrics)
+	}
+	assertNoError(t, paused.ResumeFrom(context.Background()))
+	if metrics := paused.Metrics(); metrics.Completed != 3 || metrics.Pending != 0 || metrics.Skipped != 0 {
+		t.Fatalf("unexpected metrics after resume: %+v", metrics)
+	}
+}
+
+func TestGraphSkipNode(t *testing.T) {
+	build := func() *Graph {
+		graph := NewGraph()
+		graph.AddNode("start", func() int { return 5 })
+		graph.AddNode("double", func(n int) int { return n * 2 })
+		graph.AddNode("square", func(n int) int { return n * n }, WithSkipIf(func(inputs []any) bool {
+			return inputs[0].(int) > 100
+		}))
+		graph.AddNode("end", func(n int) string { return strconv.Itoa(n) })
+		graph.AddEdge("start", "double")
+		graph.AddEdge("double", "square")
+		graph.AddEdge("square", "end")
+		return graph
+	}
+
+	for _, sequential := range []bool{false, true} {
+		graph := build()
+		assertNoError(t, graph.SkipNode("double"))
+		if sequential {
+			assertNoError(t, graph.RunSequential())
+		} else {
+			assertNoError(t, graph.Run())
+		}
+		assertNodeStatus(t, graph, "double", NodeStatusSkipped)
+		assertNodeStatus(t, graph, "square", NodeStatusCompleted)
+		assertNodeResult(t, graph, "end", "25")
+		if skipped := graph.GetNodesByStatus(NodeStatusSkipped); len(skipped) != 1 || skipped[0] != "double" {
+			t.Fatalf("expected only double to be skipped, got %v", skipped)
+		}
+		if !strings.Contains(graph.StringWithStatus(), nodeStatusColors[NodeStatusSkipped]) {
+			t.Fatal("expected skipped node to be rendered with its own color")
+		}
+		if !strings.Contains(graph.MermaidWithStatus(), "class double skipped") {
+			t.Fatal("expected skipped class in mermaid output")
+		}
+	}
+
+	graph := NewGraph()
+	graph.AddNode("start", func() int { return 50 })
+	graph.AddNode("double", func(n int) int { return n * 2 })
+	graph.AddNode("square", func(n int) int { return n * n }, WithSkipIf(func(inputs []any) bool {
+		return inputs[0].(int) > 60
+	}))
+	graph.AddEdge("start", "double")
+	graph.AddEdge("double", "square")
+	assertNoError(t, graph.Run())
+	assertNodeStatus(t, graph, "square", NodeStatusSkipped)
+	assertNodeResult(t, graph, "square", 100)
+	if metrics := graph.Metrics(); metrics.Skipped != 1 || metrics.Completed != 2 {
+		t.Fatalf("unexpected metrics: %+v", metrics)
+	}
+
+	if err := graph.SkipNode("missing"); err == nil {
+		t.Fatal("expected error for unknown node")
+	}
+}
+
+func TestGraphDryRun(t *testing.T) {
+	var calls atomic.Int32
+	graph := NewGraph()
+	graph.AddNode("start", func() int { calls.Add(1); return 1 })
+	graph.AddNode("format", func(n int) string { calls.Add(1); return strconv.Itoa(n) })
+	graph.AddNode("parse", func(s string) (int, error) { calls.Add(1); return strconv.Atoi(s) })
+	graph.AddNode("sum", func(parts ...int) int { calls.Add(1); return len(parts) })
+	graph.AddEdge("start", "format")
+	graph.AddEdge("format", "parse")
+	graph.AddEdge("parse", "sum")
+	graph.AddEdge("start", "sum")
+	assertNoError(t, graph.DryRun())
+
+	graph.AddNode("bad", func(m map[string]int) int { calls.Add(1); return len(m) })
+	graph.AddEdge("format", "bad")
+	err := graph.DryRun()
+	if err == nil || !strings.Contains(err.Error(), "node bad: "+ErrArgTypeMismatch) {
+		t.Fatalf("expected type mismatch for bad, got %v", err)
+	}
+	if calls.Load() != 0 {
+		t.Fatalf("dry run called %d node functions", calls.Load())
+	}
+
+	branch := NewGraph()
+	branch.AddNode("route", func() int { return 1 })
+	branch.AddNode("left", func(n int) int { return n })
+	branch.AddNode("right", func(n int) string { return "" })
+	branch.AddNode("join", func(n int) int { return n })
+	branch.AddBranchEdge("route", map[string]any{
+		"left":  func(n int) bool { return n > 0 },
+		"right": func(n int) bool { return n <= 0 },
+	})
+	branch.AddEdge("left", "join")
+	branch.AddEdge("right", "join")
+	err = branch.DryRun()
+	if err == nil || !strings.Contains(err.Error(), "node join") {
+		t.Fatalf("expected mismatch on the right branch, got %v", err)
+	}
+
+	count := NewGraph()
+	count.AddNode("a", func() int { return 1 })
+	count.AddNode("b", func(x, y int) int { return x + y })
+	count.AddEdge("a", "b")
+	if err := count.DryRun(); err == nil || !strings.Contains(err.Error(), ErrArgCountMismatch) {
+		t.Fatalf("expected count mismatch, got %v", err)
+	}
+
+	handled := NewGraph()
+	handled.AddNode("a", func() (int, error) { return 0, errors.New("boom") })
+	handled.AddNode("h", func(err error) string { return err.Error() })
+	handled.AddErrorEdge("a", "h")
+	assertNoError(t, handled.DryRun())
+	assertNoError(t, handled.Validate())
+	assertNoError(t, handled.Run())
+	assertNodeResult(t, handled, "h", "boom")
+}
+
+func TestGraphFlowErrorCause(t *testing.T) {
+	errBoom := errors.New("boom")
+	build := func() *Graph {
+		graph := NewGraph()
+		graph.AddNode("start", func() int { return 1 })
+		graph.AddNode("explode", func(n int) (int, error) { return 0, errBoom })
+		graph.AddNode("after", func(n int) int { return n })
+		graph.AddEdge("start", "explode")
+		graph.AddEdge("explode", "after")
+		return graph
+	}
+
+	for _, run := range []func(*Graph) error{(*Graph).Run, (*Graph).RunSequential} {
+		err := run(build())
+		if !errors.Is(err, errBoom) {
+			t.Fatalf("expected errors.Is to find the node error, got %v", err)
+		}
+		var fe *FlowError
+		if !errors.As(err, &fe) || fe.Node != "explode" || fe.Cause != errBoom {
+			t.Fatalf("expected FlowError for explode, got %#v", fe)
+		}
+	}
+
+	graph := build()
+	graph.SetErrorMode(ErrorModeCollect)
+	err := graph.Run()
+	var fe *FlowError
+	if !errors.As(err, &fe) || fe.Node != "explode" || !errors.Is(err, errBoom) {
+		t.Fatalf("expected collected FlowError for explode, got %v", err)
+	}
+}
+
+func TestSentinelErrors(t *testing.T) {
+	graph := NewGraph()
+	graph.AddNode("a", func() int { return 1 })
+	graph.AddNode("a", func() int { return 2 })
+	if !errors.Is(graph.Error(), ErrDuplicateNodeErr) {
+		t.Fatalf("expected duplicate node sentinel, got %v", graph.Error())
+	}
+
+	graph = NewGraph()
+	graph.AddNode("a", func() string { return "x" })
+	graph.AddNode("b", func(m map[string]int) int { return len(m) })
+	graph.AddEdge("a", "b")
+	err := graph.Run()
+	if !errors.Is(err, ErrArgTypeMismatchErr) || errors.Is(err, ErrArgCountMismatchErr) {
+		t.Fatalf("expected only the type mismatch sentinel to match, got %v", err)
+	}
+
+	if _, err := graph.NodeDuration("missing"); !errors.Is(err, ErrNodeNotFoundErr) {
+		t.Fatalf("expected node not found sentinel, got %v", err)
+	}
+	if err := graph.SkipNode("missing"); !errors.Is(err, ErrNodeNotFoundErr) {
+		t.Fatalf("expected prefixed node not found to match, got %v", err)
+	}
+	if errors.Is(&FlowError{Message: ErrNodeNotFound}, ErrNodeNotFoundErr) {
+		t.Fatal("expected a FlowError without a kind not to match by message")
+	}
+	if errors.Is(&FlowError{Kind: ErrNodeNotFoundErr, Message: ErrNodeNotFound}, errors.New(ErrNodeNotFound)) {
+		t.Fatal("expected only the sentinel itself to match")
+	}
+	if !errors.Is(NewGraph().AddSubGraph("sub", nil).Error(), ErrNilSubGraphErr) {
+		t.Fatal("expected nil sub-graph sentinel")
+	}
+	if err := graph.DryRun(); !errors.Is(err, ErrValidationFailedErr) {
+		t.Fatalf("expected validation sentinel, got %v", err)
+	}
+}
+
+func TestGraphAddNodesAndEdges(t *testing.T) {
+	graph := NewGraph().
+		AddNodes(map[string]any{
+			"start":  func() int { return 2 },
+			"double": func(n int) int { return n * 2 },
+			"square": func(n int) int { return n * n },
+		}).
+		AddEdges([][2]string{{"start", "double"}, {"double", "square"}})
+	assertNoError(t, graph.Run())
+	assertNodeResult(t, graph, "square", 16)
+
+	graph = NewGraph().AddNode("start", func() int { return 1 })
+	graph.AddNodes(map[string]any{"start": func() int { return 2 }})
+	if !errors.Is(graph.Error(), ErrDuplicateNodeErr) {
+		t.Fatalf("expected duplicate node error, got %v", graph.Error())
+	}
+
+	graph = NewGraph().AddNodes(map[string]any{"a": func() int { return 1 }})
+	graph.AddEdges([][2]string{{"a", "first"}, {"second", "a"}})
+	if !errors.Is(graph.Error(), ErrNodeNotFoundErr) || !strings.Contains(graph.Error().Error(), "first") {
+		t.Fatalf("expected first missing node error to be latched, got %v", graph.Error())
+	}
+}
+
+func TestGraphLoopStop(t *testing.T) {
+	stop := make(chan struct{})
+	graph := NewGraph()
+	graph.AddNode("start", func() int { return 0 })
+	graph.AddNode("poll", func(n int) int {
+		if n+1 == 3 {
+			close(stop)
+		}
+		return n + 1
+	})
+	graph.AddEdge("start", "poll")
+	graph.AddEdge("poll", "poll",
+		WithEdgeType(EdgeTypeLoop),
+		WithCondition(func(n int) bool { return true }),
+		WithMaxIterations(100),
+		WithLoopStop(context.Background(), stop),
+	)
+	assertNoError(t, graph.Run())
+	assertNodeResult(t, graph, "poll", 3)
+	if interrupted, err := graph.LoopInterrupted("poll"); err != nil || !interrupted {
+		t.Fatalf("expected poll loop to be interrupted, got %v, %v", interrupted, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	graph = NewGraph()
+	graph.AddNode("start", func() int { return 0 })
+	graph.AddNode("poll", func(n int) int {
+		if n+1 == 2 {
+			cancel()
+		}
+		return n + 1
+	})
+	graph.AddEdge("start", "poll")
+	graph.AddEdge("poll", "poll",
+		WithEdgeType(EdgeTypeLoop),
+		WithCondition(func(n int) bool { return n < 5 }),
+		WithLoopStop(ctx, nil),
+	)
+	assertNoError(t, graph.RunSequential())
+	assertNodeResult(t, graph, "poll", 2)
+
+	graph = NewGraph()
+	graph.AddNode("start", func() int { return 0 })
+	graph.AddNode("poll", func(n int) int { return n + 1 })
+	graph.AddEdge("start", "poll")
+	graph.AddEdge("poll", "poll",
+		WithEdgeType(EdgeTypeLoop),
+		WithCondition(func(n int) bool { return n < 4 }),
+		WithLoopStop(context.Background(), make(chan struct{})),
+	)
+	assertNoError(t, graph.Run())
+	assertNodeResult(t, graph, "poll", 4)
+	if interrupted, _ := graph.LoopInterrupted("poll"); interrupted {
+		t.Fatal("expected loop to finish normally")
+	}
+}
+
+func TestPoolStats(t *testing.T) {
+	objects := NewObjectPool(func() *int { return new(int) }, WithStats[*int]())
+	x := objects.Get()
+	objects.Put(x)
+	objects.Get()
+	stats := objects.Stats()
+	if stats.Gets != 2 || stats.Puts != 1 || stats.Misses < 1 {
+		t.Fatalf("unexpected object pool stats: %+v", stats)
+	}
+
+	slicePool := NewSlicePool[int](4, 2, WithSliceStats[int]())
+	s := slicePool.Get(8)
+	slicePool.Put(s)
+	slicePool.Put(make([]int, 0, 1))
+	stats = slicePool.Stats()
+	if stats.Gets != 1 || stats.Puts != 2 || stats.Discards != 1 || stats.Misses < 1 {
+		t.Fatalf("unexpected slice pool stats: %+v", stats)
+	}
+	if stats.DefaultCapacity != 4 || stats.MinCapacity != 2 {
+		t.Fatalf("unexpected slice pool sizing: %+v", stats)
+	}
+
+	plain := NewObjectPool(func() *int { return new(int) })
+	plain.Put(plain.Get())
+	if stats := plain.Stats(); stats != (PoolStats{}) {
+		t.Fatalf("expected no stats without WithStats, got %+v", stats)
+	}
+}
+
+func TestGraphTemplateInstance(t *testing.T) {
+	template, err := NewGraphBuilder().
+		AddNode("start", func() int { return 2 }).
+		AddNode("double", func(n int) int { return n * 2 }, WithMeta(map[string]string{"team": "core"})).
+		AddNode("square", func(n int) int { return n * n }).
+		AddEdge("start", "double").
+		AddEdge("double", "square").
+		Build()
+	assertNoError(t, err)
+
+	var wg sync.WaitGroup
+	instances := make([]*Graph, 8)
+	for i := range instances {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			instances[i] = template.Instance()
+			if i%2 == 0 {
+				assertNoError(t, instances[i].Run())
+			} else {
+				assertNoError(t, instances[i].RunSequential())
+			}
+		}()
+	}
+	wg.Wait()
+	for _, instance := range instances {
+		assertNodeResult(t, instance, "square", 16)
+	}
+
+	fresh := template.Instance()
+	assertNodeStatus(t, fresh, "square", NodeStatusPending)
+	if meta, _ := fresh.NodeMeta("double"); meta["team"] != "core" {
+		t.Fatalf("expected meta to be carried over, got %v", meta)
+	}
+
+	fresh.AddNode("report", func(n int) string { return strconv.Itoa(n) })
+	fresh.AddEdge("square", "report")
+	assertNoError(t, fresh.Run())
+	assertNodeResult(t, fresh, "report", "16")
+	if outputs := template.Instance().OutputsOf("square"); len(outputs) != 0 {
+		t.Fatalf("extending an instance must not change the template, got %v", outputs)
+	}
+
+	if _, err := NewGraphBuilder().AddNode("a", func() {}).AddNode("a", func() {}).Build(); err == nil {
+		t.Fatal("expected duplicate node error from Build")
+	}
+	_, err = NewGraphBuilder().
+		AddNode("a", func(n int) int { return n }).
+		AddNode("b", func(n int) int { return n }).
+		AddEdge("a", "b").
+		AddEdge("b", "a").
+		Build()
+	if !errors.Is(err, ErrCyclicDependencyErr) {
+		t.Fatalf("expected cyclic dependency error, got %v", err)
+	}
+}
+
+func TestGraphResultInterceptor(t *testing.T) {
+	for _, sequential := range []bool{false, true} {
+		graph := NewGraph()
+		graph.AddNode("start", func() string { return "  hello " })
+		graph.AddNode("upper", func(s string) string { return strings.ToUpper(s) })
+		graph.AddEdge("start", "upper")
+
+		var mu sync.Mutex
+		seen := make(map[string]int)
+		graph.SetResultInterceptor(func(node string, results []any) []any {
+			mu.Lock()
+			seen[node]++
+			mu.Unlock()
+			if s, ok := results[0].(string); ok {
+				return []any{strings.TrimSpace(s)}
+			}
+			return results
+		})
+		if sequential {
+			assertNoError(t, graph.RunSequential())
+		} else {
+			assertNoError(t, graph.Run())
+		}
+		assertNodeResult(t, graph, "start", "hello")
+		assertNodeResult(t, graph, "upper", "HELLO")
+		if seen["start"] != 1 || seen["upper"] != 1 {
+			t.Fatalf("expected interceptor once per node, got %v", seen)
+		}
+
+		graph.Reset()
+		graph.SetResultInterceptor(nil)
+		assertNoError(t, graph.Run())
+		assertNodeResult(t, graph, "upper", "  HELLO ")
+	}
+
+	graph := NewGraph()
+	graph.AddNode("fail", func() (int, error) { return 0, errors.New("boom") })
+	called := false
+	graph.SetResultInterceptor(func(string, []any) []any { called = true; return nil })
+	if err := graph.Run(); err == nil || called {
+		t.Fatalf("expected failure without interception, err=%v called=%v", err, called)
+	}
+}
+
+func TestGraphInputInterceptor(t *testing.T) {
+	type configKey struct{}
+	for _, sequential := range []bool{false, true} {
+		graph := NewGraph()
+		graph.AddNode("config", func() {})
+		graph.AddNode("a", func() int { return 1 })
+		graph.AddNode("b", func() int { return 2 })
+		graph.AddNode("sum", func(ctx context.Context, parts ...int) string {
+			total := 0
+			for _, p := range parts {
+				total += p
+			}
+			return fmt.Sprintf("%v:%d", ctx.Value(configKey{}), total)
+		})
+		graph.AddEdge("a", "sum")
+		graph.AddEdge("b", "sum")
+
+		var mu sync.Mutex
+		var empty []string
+		graph.SetInputInterceptor(func(node string, inputs []any) []any {
+			if node == "sum" {
+				return append(inputs, 10)
+			}
+			mu.Lock()
+			empty = append(empty, node)
+			mu.Unlock()
+			return nil
+		})
+
+		ctx := context.WithValue(context.Background(), configKey{}, "cfg")
+		if sequential {
+			assertNoError(t, graph.RunSequentialWithContext(ctx))
+		} else {
+			assertNoError(t, graph.RunWithContext(ctx))
+		}
+		assertNodeResult(t, graph, "sum", "cfg:13")
+		assertNodeResult(t, graph, "a", 1)
+		if len(empty) != 3 {
+			t.Fatalf("expected interceptor to see the three source nodes, got %v", empty)
+		}
+	}
+}
+
+func TestGraphNodesByTag(t *testing.T) {
+	graph := NewGraph()
+	graph.AddNodeWithTags("fetch", func() int { return 1 }, "io", "external")
+	graph.AddNodeWithTags("compute", func(n int) int { return n * 2 }, "cpu")
+	graph.AddNodeWithTags("store", func(n int) int { return n }, "io")
+	graph.AddNode("log", func(n int) {}, WithTags("io", "io"))
+	graph.AddEdge("fetch", "compute")
+	graph.AddEdge("compute", "store")
+	graph.AddEdge("store", "log")
+
+	want := []string{"fetch", "store", "log"}
+	if got := graph.NodesByTag("io"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if got := graph.NodesByTag("gpu"); len(got) != 0 {
+		t.Fatalf("expected no nodes for unknown tag, got %v", got)
+	}
+	if tags, _ := graph.NodeTags("log"); !reflect.DeepEqual(tags, []string{"io"}) {
+		t.Fatalf("expected duplicate tags to collapse, got %v", tags)
+	}
+
+	assertNoError(t, graph.Run())
+	graph.Reset()
+	if got := graph.NodesByTag("io"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected tags to survive Reset, got %v", got)
+	}
+	if got := graph.Clone().NodesByTag("io"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected tags to survive Clone, got %v", got)
+	}
+
+	data, err := graph.ExportJSON()
+	assertNoError(t, err)
+	if !strings.Contains(string(data), `"tags": [`) {
+		t.Fatalf("expected tags in JSON export:\n%s", data)
+	}
+	loaded, err := LoadGraphJSON(data, map[string]any{
+		"fetch":   func() int { return 1 },
+		"compute": func(n int) int { return n * 2 },
+		"store":   func(n int) int { return n },
+		"log":     func(n int) {},
+	})
+	assertNoError(t, err)
+	if got := loaded.NodesByTag("cpu"); !reflect.DeepEqual(got, []string{"compute"}) {
+		t.Fatalf("expected tags to round-trip through JSON, got %v", got)
+	}
+}
+
+func TestGraphPruneUnreached(t *testing.T) {
+	graph := NewGraph()
+	graph.AddNode("route", func() int { return 1 })
+	graph.AddNode("left", func(n int) int { return n })
+	graph.AddNode("right", func(n int) int { return n })
+	graph.AddNode("load", func() (int, error) { return 0, errors.New("load failed") })
+	graph.AddNode("loadSave", func(n int) int { return n })
+	graph.AddBranchEdge("route", map[string]any{
+		"left":  func(n int) bool { return n < 0 },
+		"right": func(n int) bool { return n > 0 },
+	})
+	graph.AddEdge("load", "loadSave")
+	graph.SetErrorMode(ErrorModeCollect)
+
+	assertError(t, graph.Run())
+	pruned := graph.PruneUnreached()
+	if !reflect.DeepEqual(pruned, []string{"left"}) {
+		t.Fatalf("expected left branch to be pruned, got %v", pruned)
+	}
+	assertNodeStatus(t, graph, "left", NodeStatusSkipped)
+	assertNodeStatus(t, graph, "right", NodeStatusCompleted)
+	assertNodeStatus(t, graph, "loadSave", NodeStatusPending)
+
+	assertError(t, graph.Run())
+	assertNodeStatus(t, graph, "left", NodeStatusPending)
+	assertNodeStatus(t, graph, "right", NodeStatusCompleted)
+}
+
+func TestGraphStatefulEdge(t *testing.T) {
+	build := func(amount int) *Graph {
+		graph := NewGraph()
+		graph.AddNode("order", func() int { return amount })
+		graph.AddNode("review", func(n int) string { return "reviewed" })
+		graph.AddNode("approve", func(s string) string { return "approved" })
+		graph.AddNode("escalate", func(s string) string { return "escalated" })
+		graph.AddEdge("order", "review")
+		graph.AddStatefulEdge("review", "approve", func(view *GraphView) bool {
+			results, ok := view.Result("order")
+			return ok && results[0].(int) < 1000
+		})
+		graph.AddStatefulEdge("review", "escalate", func(view *GraphView) bool {
+			results, ok := view.Result("order")
+			return ok && results[0].(int) >= 1000
+		})
+		return graph
+	}
+
+	graph := build(50)
+	assertNoError(t, graph.Run())
+	assertNodeResult(t, graph, "approve", "approved")
+	assertNodeStatus(t, graph, "escalate", NodeStatusPending)
+
+	graph = build(5000).Clone()
+	assertNoError(t, graph.Run())
+	assertNodeResult(t, graph, "escalate", "escalated")
+	assertNodeStatus(t, graph, "approve", NodeStatusPending)
+
+	view := &GraphView{graph: graph}
+	if _, ok := view.Result("approve"); ok {
+		t.Fatal("expected no result for a node that did not run")
+	}
+	assertEqual(t, NodeStatusCompleted, view.Status("review"))
+}
+
+func TestGraphReplaceNode(t *testing.T) {
+	graph := NewGraph()
+	graph.AddNode("source", func() int { return 4 })
+	graph.AddNode("score", func(n int) int { return n * 2 })
+	graph.AddEdge("source", "score")
+
+	assertNoError(t, graph.Run())
+	assertNodeResult(t, graph, "score", 8)
+
+	assertNoError(t, graph.ReplaceNode("score", func(n int) int { return n * 10 }))
+	graph.Reset()
+	assertNoError(t, graph.Run())
+	assertNodeResult(t, graph, "score", 40)
+
+	err := graph.ReplaceNode("score", func(a, b int) int { return a + b })
+	if !errors.Is(err, ErrArgCountMismatchErr) {
+		t.Fatalf("expected argument count error, got %v", err)
+	}
+	assertNodeResult(t, graph, "score", 40)
+
+	graph.ClearStatus()
+	assertNoError(t, graph.ReplaceNode("score", func(ns ...int) int { return len(ns) }))
+	assertNoError(t, graph.Run())
+	assertNodeResult(t, graph, "score", 1)
+
+	assertError(t, graph.ReplaceNode("score", 42))
+	assertError(t, graph.ReplaceNode("missing", func() {}))
+}
+
+func TestGraphRunBatch(t *testing.T) {
+	graph := NewGraph()
+	graph.AddNode("parse", func(n int) (int, error) {
+		if n < 0 {
+			return 0, errors.New("negative input")
+		}
+		return n, nil
+	})
+	graph.AddNode("double", func(n int) int { return n * 2 })
+	graph.AddEdge("parse", "double")
+
+	results, err := graph.RunBatch(context.Background(), [][]any{{1}, {2}, {3}}, WithBatchConcurrency(2))
+	assertNoError(t, err)
+	for i, want := range []int{2, 4, 6} {
+		if got := results[i]["double"]; !reflect.DeepEqual(got, []any{want}) {
+			t.Fatalf("run %d: expected %d, got %v", i, want, got)
+		}
+	}
+	assertNodeStatus(t, graph, "parse", NodeStatusPending)
+
+	results, err = graph.RunBatch(context.Background(), [][]any{{1}, {-1}, {3}})
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("expected BatchError, got %T: %v", err, err)
+	}
+	assertError(t, batchErr.Errors[1])
+	assertNoError(t, batchErr.Errors[0])
+	assertContains(t, err.Error(), "run 1")
+	if results[1] != nil || !reflect.DeepEqual(results[2]["double"], []any{6}) {
+		t.Fatalf("unexpected batch results: %v", results)
+	}
+
+	_, err = graph.RunBatch(context.Background(), [][]any{{-1}, {2}}, WithBatchFailFast(), WithBatchConcurrency(1))
+	assertError(t, err)
+	if errors.As(err, &batchErr) {
+		t.Fatalf("expected the first failure in fail-fast mode, got %v", err)
+	}
+}
+
+func TestGraphRunBatchSubGraph(t *testing.T) {
+	sub := NewGraph()
+	sub.AddNode("double", func(n int) int { return n * 2 })
+	sub.AddNode("inc", func(n int) int { return n + 1 })
+	sub.AddEdge("double", "inc")
+
+	graph := NewGraph()
+	graph.AddNode("parse", func(n int) int { return n })
+	graph.AddSubGraph("stage", sub)
+	graph.AddEdge("parse", "stage")
+
+	inputs := make([][]any, 50)
+	for i := range inputs {
+		inputs[i] = []any{i}
+	}
+	results, err := graph.RunBatch(context.Background(), inputs, WithBatchConcurrency(8))
+	assertNoError(t, err)
+	for i, result := range results {
+		if !reflect.DeepEqual(result["stage"], []any{i*2 + 1}) {
+			t.Fatalf("run %d: expected %d, got %v", i, i*2+1, result["stage"])
+		}
+	}
+}
+
+func TestGraphAddErrorEdge(t *testing.T) {
+	build := func(chargeErr error) *Graph {
+		graph := NewGraph()
+		graph.AddNode("order", func() int { return 42 })
+		graph.AddNode("charge", func(id int) (int, error) { return id, chargeErr })
+		graph.AddNode("ship", func(id int) string { return "shipped" })
+		graph.AddNode("notify", func(s string) string { return s })
+		graph.AddNode("cancel", func(err error) string { return "canceled: " + err.Error() })
+		graph.AddEdge("order", "charge")
+		graph.AddEdge("charge", "ship")
+		graph.AddEdge("ship", "notify")
+		graph.AddErrorEdge("charge", "cancel")
+		graph.SetPauseConfig(NewPauseConfig().SetPauseOnError())
+		return graph
+	}
+
+	runners := map[string]func(*Graph) error{
+		"parallel":   (*Graph).Run,
+		"sequential": (*Graph).RunSequential,
+		"large": func(g *Graph) error {
+			g.largeThreshold = 1
+			return g.Run()
+		},
+	}
+	for mode, run := range runners {
+		t.Run(mode, func(t *testing.T) {
+			graph := build(errors.New("card declined"))
+			assertNoError(t, run(graph))
+			assertNodeStatus(t, graph, "charge", NodeStatusFailed)
+			assertNodeResult(t, graph, "cancel", "canceled: card declined")
+			assertNodeStatus(t, graph, "ship", NodeStatusPending)
+			assertNodeStatus(t, graph, "notify", NodeStatusPending)
+			assertEqual(t, "", graph.GetPausedAtNode())
+
+			graph = build(nil)
+			assertNoError(t, run(graph))
+			assertNodeResult(t, graph, "notify", "shipped")
+			assertNodeStatus(t, graph, "cancel", NodeStatusPending)
+		})
+	}
+
+	graph := build(&FatalError{Err: errors.New("fraud")})
+	assertError(t, graph.Run())
+	assertNodeStatus(t, graph, "cancel", NodeStatusPending)
+}
+
+func TestGraphCompensation(t *testing.T) {
+	var rolledBack []string
+	graph := NewGraph()
+	graph.AddNode("reserve", func() int { return 3 })
+	graph.AddNode("charge", func(n int) int { return n * 10 })
+	graph.AddNode("notify", func(amount int) int { return amount })
+	graph.AddNode("ship", func(amount int) (int, error) { return 0, errors.New("carrier unavailable") })
+	graph.AddEdge("reserve", "charge")
+	graph.AddEdge("charge", "notify")
+	graph.AddEdge("notify", "ship")
+	graph.AddCompensation("reserve", func(inputs, results []any) error {
+		rolledBack = append(rolledBack, fmt.Sprintf("reserve:%v", results[0]))
+		return nil
+	})
+	graph.AddCompensation("charge", func(inputs, results []any) error {
+		rolledBack = append(rolledBack, fmt.Sprintf("charge:%v->%v", inputs[0], results[0]))
+		return nil
+	})
+
+	err := graph.Run()
+	assertContains(t, err.Error(), "carrier unavailable")
+	if !reflect.DeepEqual(rolledBack, []string{"charge:3->30", "reserve:3"}) {
+		t.Fatalf("expected reverse-order rollback, got %v", rolledBack)
+	}
+	assertNodeStatus(t, graph, "reserve", NodeStatusPending)
+	assertNodeStatus(t, graph, "charge", NodeStatusPending)
+	assertNodeStatus(t, graph, "notify", NodeStatusCompleted)
+
+	graph.AddCompensation("notify", func(inputs, results []any) error {
+		return errors.New("cannot unsend")
+	})
+	graph.Reset()
+	err = graph.RunSequential()
+	var multi *MultiError
+	if !errors.As(err, &multi) || len(multi.Errors) != 2 {
+		t.Fatalf("expected run and compensation failures, got %v", err)
+	}
+	assertContains(t, err.Error(), "compensation for notify failed")
+	assertNodeStatus(t, graph, "notify", NodeStatusCompleted)
+
+	assertError(t, NewGraph().AddCompensation("missing", nil).Error())
+}
+
+func TestGraphTypedCond(t *testing.T) {
+	graph := NewGraph()
+	graph.AddNode("check", func() int { return 150 })
+	graph.AddNode("high", func(n int) string { return "high" })
+	graph.AddNode("low", func(n int) string { return "low" })
+	graph.AddNode("text", func(n int) string { return "text" })
+	graph.AddEdgeWithCondition("check", "high", TypedCond(func(n int) bool { return n > 100 }))
+	graph.AddEdgeWithCondition("check", "low", func(n any) bool { return n.(int) <= 100 })
+	graph.AddEdgeWithCondition("check", "text", TypedCond(func(s string) bool { return true }))
+
+	for range 2 {
+		assertNoError(t, graph.Run())
+		assertNodeResult(t, graph, "high", "high")
+		assertNodeStatus(t, graph, "low", NodeStatusPending)
+		assertNodeStatus(t, graph, "text", NodeStatusPending)
+		graph.ClearStatus()
+	}
+}
+
+func TestGraphAllResults(t *testing.T) {
+	graph := NewGraph()
+	graph.AddNode("load", func() []int { return []int{1, 2} })
+	graph.AddNode("count", func(ns []int) int { return len(ns) })
+	graph.AddNode("fail", func(n int) (int, error) { return 0, errors.New("boom") })
+	graph.AddEdge("load", "count")
+	graph.AddEdge("count", "fail")
+
+	assertError(t, graph.Run())
+	results := graph.AllResults()
+	assertEqual(t, 2, len(results))
+	if !reflect.DeepEqual(results["count"], []any{2}) {
+		t.Fatalf("expected count result, got %v", results["count"])
+	}
+	if _, ok := results["fail"]; ok {
+		t.Fatal("expected failed node to be omitted")
+	}
+
+	results["count"][0] = 99
+	assertNodeResult(t, graph, "count", 2)
+}
+
+func TestGraphFreeze(t *testing.T) {
+	graph := NewGraph()
+	graph.AddNode("fetch", func() int { return 1 })
+	graph.AddNode("parse", func(n int) int { return n + 1 })
+	graph.AddEdge("fetch", "parse")
+	graph.Freeze()
+
+	if err := graph.AddNodeE("audit", func(n int) {}); !errors.Is(err, ErrGraphFrozenErr) {
+		t.Fatalf("expected frozen error, got %v", err)
+	}
+	if err := graph.AddEdgeE("parse", "fetch"); !errors.Is(err, ErrGraphFrozenErr) {
+		t.Fatalf("expected frozen error, got %v", err)
+	}
+	if err := graph.ReplaceNode("parse", func(n int) int { return n }); !errors.Is(err, ErrGraphFrozenErr) {
+		t.Fatalf("expected frozen error, got %v", err)
+	}
+	if err := graph.SetNodeResource("parse", "gpu", 1); !errors.Is(err, ErrGraphFrozenErr) {
+		t.Fatalf("expected frozen error, got %v", err)
+	}
+	assertNoError(t, graph.Run())
+	assertNodeResult(t, graph, "parse", 2)
+
+	clone := graph.Clone()
+	if clone.Frozen() {
+		t.Fatal("expected clone to be unfrozen")
+	}
+	assertNoError(t, clone.AddNodeE("audit", func(n int) {}))
+	assertNoError(t, clone.AddEdgeE("parse", "audit"))
+
+	mutations := map[string]func(*Graph) *Graph{
+		"AddNode": func(g *Graph) *Graph { return g.AddNode("audit", func(n int) {}) },
+		"AddEdge": func(g *Graph) *Graph { return g.AddEdge("parse", "fetch") },
+		"AddCachedNode": func(g *Graph) *Graph {
+			return g.AddCachedNode("cached", func() int { return 0 })
+		},
+		"AddCompensation": func(g *Graph) *Graph {
+			return g.AddCompensation("parse", func(inputs, results []any) error { return nil })
+		},
+	}
+	for name, mutate := range mutations {
+		frozen := NewGraph()
+		frozen.AddNode("fetch", func() int { return 1 })
+		frozen.AddNode("parse", func(n int) int { return n + 1 })
+		frozen.AddEdge("fetch", "parse")
+		frozen.Freeze()
+		if err := mutate(frozen).Error(); !errors.Is(err, ErrGraphFrozenErr) {
+			t.Errorf("%s: expected latched frozen error, got %v", name, err)
+		}
+		assertEqual(t, 2, len(frozen.nodes))
+	}
+}
+
+func TestGraphRunWithInputs(t *testing.T) {
+	graph := NewGraph()
+	graph.AddNode("load", func(path string, limit int) string { return fmt.Sprintf("%s:%d", path, limit) })
+	graph.AddNode("tag", func(tags ...string) int { return len(tags) })
+	graph.AddNode("report", func(s string, n int) string { return fmt.Sprintf("%s/%d", s, n) })
+	graph.AddEdge("load", "report")
+	graph.AddEdge("tag", "report")
+
+	ctx := context.Background()
+	assertNoError(t, graph.RunWithInputs(ctx, map[string][]any{
+		"load": {"a.csv", 10},
+		"tag":  {"x", "y"},
+	}))
+	assertNodeResult(t, graph, "report", "a.csv:10/2")
+
+	graph.Reset()
+	assertNoError(t, graph.Clone().RunWithInputs(ctx, map[string][]any{"load": {"b.csv", 1}}))
+
+	err := graph.RunWithInputs(ctx, map[string][]any{"report": {"x", 1}})
+	if !errors.Is(err, ErrNotStartNodeErr) {
+		t.Fatalf("expected not a start node error, got %v", err)
+	}
+	err = graph.RunWithInputs(ctx, map[string][]any{"load": {"a.csv"}})
+	if !errors.Is(err, ErrArgCountMismatchErr) {
+		t.Fatalf("expected argument count error, got %v", err)
+	}
+	assertError(t, graph.RunWithInputs(ctx, map[string][]any{"missing": nil}))
+
+	graph.Reset()
+	assertNoError(t, graph.RunWithInputs(ctx, map[string][]any{"load": {"c.csv", 3}, "tag": {}}))
+	assertNodeResult(t, graph, "report", "c.csv:3/0")
+}
+
+func TestGraphOptionalEdge(t *testing.T) {
+	build := func(n int) *Graph {
+		graph := NewGraph()
+		graph.AddNode("route", func() int { return n })
+		graph.AddNode("left", func(n int) string { return "left" })
+		graph.AddNode("right", func(n int) string { return "right" })
+		graph.AddNode("join", func(l, r string) string { return l + "|" + r })
+		graph.AddBranchEdge("route", map[string]any{
+			"left":  func(n int) bool { return n < 0 },
+			"right": func(n int) bool { return n > 0 },
+		})
+		graph.AddEdge("left", "join", WithOptional())
+		graph.AddEdge("right", "join", WithOptional())
+		return graph
+	}
+
+	graph := build(1)
+	assertNoError(t, graph.Run())
+	assertNodeStatus(t, graph, "left", NodeStatusPending)
+	results, _ := graph.NodeResult("join")
+	if results[0] != "|right" {
+		t.Fatalf("expected join of right branch only, got %v", results)
+	}
+
+	graph = build(-1)
+	assertNoError(t, graph.Run())
+	results, _ = graph.NodeResult("join")
+	if results[0] != "left|" {
+		t.Fatalf("expected join of left branch only, got %v", results)
+	}
+}
+
+func TestGraphRemoveNodeAndEdge(t *testing.T) {
+	graph := NewGraph()
+	graph.AddNode("a", func() int { return 2 })
+	graph.AddNode("b", func(n int) int { return n + 1 })
+	graph.AddNode("c", func(n int) int { return n * 10 })
+	graph.AddEdge("a", "b")
+	graph.AddEdge("b", "c")
+
+	assertNoError(t, graph.Run())
+	results, _ := graph.NodeResult("c")
+	if results[0] != 30 {
+		t.Fatalf("expected 30, got %v", results)
+	}
+
+	assertNoError(t, graph.RemoveEdge("b", "c"))
+	graph.AddEdge("a", "c")
+	graph.ClearStatus()
+	assertNoError(t, graph.Run())
+	results, _ = graph.NodeResult("c")
+	if results[0] != 20 {
+		t.Fatalf("expected c to read from a after rewiring, got %v", results)
+	}
+
+	assertNoError(t, graph.RemoveNode("b"))
+	order, err := graph.TopologicalOrder()
+	assertNoError(t, err)
+	if !reflect.DeepEqual(order, []string{"a", "c"}) {
+		t.Fatalf("expected plan [a c], got %v", order)
+	}
+	graph.ClearStatus()
+	assertNoError(t, graph.Run())
+	if _, err := graph.NodeStatus("b"); !errors.Is(err, ErrNodeNotFoundErr) {
+		t.Fatalf("expected removed node to be gone, got %v", err)
+	}
+
+	if err := graph.RemoveEdge("a", "b"); !errors.Is(err, ErrEdgeNotFoundErr) {
+		t.Fatalf("expected ErrEdgeNotFound, got %v", err)
+	}
+	if err := graph.RemoveNode("b"); !errors.Is(err, ErrNodeNotFoundErr) {
+		t.Fatalf("expected ErrNodeNotFound, got %v", err)
+	}
+	graph.Freeze()
+	if err := graph.RemoveNode("c"); !errors.Is(err, ErrGraphFrozenErr) {
+		t.Fatalf("expected ErrGraphFrozen, got %v", err)
+	}
+}
+
+func TestGraphMermaidClustered(t *testing.T) {
+	graph := NewGraph()
+	graph.AddNodeWithTags("read", func() string { return "" }, "extract")
+	graph.AddNodeWithTags("parse", func(s string) string { return s }, "transform")
+	graph.AddNodeWithTags("write", func(s string) {}, "load")
+	graph.AddNode("audit", func() {})
+	graph.AddEdge("read", "parse")
+	graph.AddEdge("parse", "write")
+
+	expected := `graph TD
+
+    subgraph cluster_0["extract"]
+        read
+    end
+    subgraph cluster_1["transform"]
+        parse
+    end
+    subgraph cluster_2["load"]
+        write
+    end
+    subgraph cluster_3["default"]
+        audit
+    end
+
+    read --> parse
+    parse --> write
+`
+	if output := graph.MermaidClustered(); output != expected {
+		t.Fatalf("unexpected clustered output:\n%s", output)
+	}
+
+	named := NewGraph()
+	named.AddNodeWithTags("billing", func() int { return 1 }, "billing")
+	named.AddNodeWithTags("send", func(n int) {}, `mail "out", v2`)
+	named.AddEdge("billing", "send")
+	output := named.MermaidClustered()
+	assertContains(t, output, "    subgraph cluster_0[\"billing\"]\n        billing\n    end\n")
+	assertContains(t, output, "    subgraph cluster_1[\"mail #quot;out#quot;, v2\"]\n        send\n    end\n")
+}
+
+type testSpanKey struct{}
+
+type testSpan struct {
+	name   string
+	parent string
+	attrs  map[string]any
+	ended  bool
+}
+
+func (s *testSpan) SetAttribute(key string, value any) { s.attrs[key] = value }
+func (s *testSpan) End()                               { s.ended = true }
+
+type testTracer struct {
+	mu    sync.Mutex
+	spans map[string]*testSpan
+}
+
+func (tr *testTracer) StartSpan(ctx context.Context, name string) (context.Context, Span) {
+	span := &testSpan{name: name, attrs: make(map[string]any)}
+	if parent, ok := ctx.Value(testSpanKey{}).(*testSpan); ok {
+		span.parent = parent.name
+	}
+	tr.mu.Lock()
+	tr.spans[name] = span
+	tr.mu.Unlock()
+	return context.WithValue(ctx, testSpanKey{}, span), span
+}
+
+func TestGraphSetTracer(t *testing.T) {
+	tracer := &testTracer{spans: make(map[string]*testSpan)}
+	graph := NewGraph().SetTracer(tracer)
+	graph.AddNode("fetch", func() int { return 1 })
+	graph.AddNode("store", func(ctx context.Context, n int) (string, error) {
+		span, _ := ctx.Value(testSpanKey{}).(*testSpan)
+		if span == nil || span.name != "store" {
+			return "", errors.New("node context does not carry its span")
+		}
+		return "", errors.New("store failed")
+	})
+	graph.AddEdge("fetch", "store")
+
+	root := &testSpan{name: "request", attrs: make(map[string]any)}
+	ctx := context.WithValue(context.Background(), testSpanKey{}, root)
+	err := graph.RunWithContext(ctx)
+	if err == nil || !strings.Contains(err.Error(), "store failed") {
+		t.Fatalf("expected store failure, got %v", err)
+	}
+
+	for _, name := range []string{"fetch", "store"} {
+		span := tracer.spans[name]
+		if span == nil || !span.ended || span.parent != "request" {
+			t.Fatalf("expected ended span %s under request, got %+v", name, span)
+		}
+		if span.attrs[SpanAttrNodeName] != name {
+			t.Fatalf("expected node name attribute on %s, got %v", name, span.attrs)
+		}
+		if _, ok := span.attrs[SpanAttrNodeDuration].(int64); !ok {
+			t.Fatalf("expected duration attribute on %s, got %v", name, span.attrs)
+		}
+	}
+	if status := tracer.spans["fetch"].attrs[SpanAttrNodeStatus]; status != "completed" {
+		t.Fatalf("expected fetch completed, got %v", status)
+	}
+	if status := tracer.spans["store"].attrs[SpanAttrNodeStatus]; status != "failed" {
+		t.Fatalf("expected store failed, got %v", status)
+	}
+}
+
+func TestGraphNodePanicRecovered(t *testing.T) {
+	graph := NewGraph()
+	graph.AddNode("start", func() int { return 1 })
+	graph.AddNode("explode", func(n int) int { panic("boom") })
+	graph.AddNode("steady", func(n int) int { return n + 1 })
+	graph.AddNode("after", func(n int) int { return n })
+	graph.AddEdge("start", "explode")
+	graph.AddEdge("start", "steady")
+	graph.AddEdge("explode", "after")
+
+	err := graph.Run()
+	if !errors.Is(err, ErrFunctionPanickedErr) {
+		t.Fatalf("expected ErrFunctionPanicked, got %v", err)
+	}
+	var flowErr *FlowError
+	if !errors.As(err, &flowErr) || flowErr.Node != "explode" || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("expected panic value and node name in error, got %v", err)
+	}
+	assertNodeStatus(t, graph, "explode", NodeStatusFailed)
+	assertNodeStatus(t, graph, "after", NodeStatusPending)
+
+	callbacks := []struct {
+		name  string
+		opts  []NodeOption
+		setup func(graph *Graph)
+	}{
+		{name: "condition", setup: func(graph *Graph) {
+			graph.AddEdgeWithCondition("start", "next", func(n int) bool { panic("boom") })
+		}},
+		{name: "skipIf", opts: []NodeOption{WithSkipIf(func([]any) bool { panic("boom") })}},
+		{name: "validator", opts: []NodeOption{WithValidator(func([]any) error { panic("boom") })}},
+		{name: "inputInterceptor", setup: func(graph *Graph) {
+			graph.SetInputInterceptor(func(node string, inputs []any) []any {
+				if node == "next" {
+					panic("boom")
+				}
+				return nil
+			})
+		}},
+		{name: "resultInterceptor", setup: func(graph *Graph) {
+			graph.SetResultInterceptor(func(node string, results []any) []any {
+				if node == "next" {
+					panic("boom")
+				}
+				return results
+			})
+		}},
+		{name: "loopCondition", setup: func(graph *Graph) {
+			graph.AddLoopEdge("next", func(n int) bool { panic("boom") }, 3)
+		}},
+	}
+	for _, tc := range callbacks {
+		for _, sequential := range []bool{false, true} {
+			if tc.name == "condition" && sequential {
+				continue
+			}
+			graph := NewGraph()
+			graph.AddNode("start", func() int { return 1 })
+			graph.AddNode("next", func(n int) int { return n }, tc.opts...)
+			if tc.setup != nil {
+				tc.setup(graph)
+			}
+			if tc.name != "condition" {
+				graph.AddEdge("start", "next")
+			}
+			var err error
+			if sequential {
+				err = graph.RunSequential()
+			} else {
+				err = graph.Run()
+			}
+			if !errors.Is(err, ErrFunctionPanickedErr) || !strings.Contains(err.Error(), "boom") {
+				t.Fatalf("%s (sequential=%v): expected recovered panic, got %v", tc.name, sequential, err)
+			}
+			assertNodeStatus(t, graph, "next", NodeStatusFailed)
+		}
+	}
+}
+
+func TestGraphWithValidator(t *testing.T) {
+	errNotPositive := errors.New("amount must be positive")
+	build := func(amount int) (*Graph, *int) {
+		calls := 0
+		graph := NewGraph()
+		graph.AddNode("amount", func() int { return amount })
+		graph.AddNode("charge", func(n int) int {
+			calls++
+			return n * 100
+		}, WithValidator(func(inputs []any) error {
+			if inputs[0].(int) <= 0 {
+				return errNotPositive
+			}
+			return nil
+		}))
+		graph.AddEdge("amount", "charge")
+		return graph, &calls
+	}
+
+	graph, calls := build(3)
+	assertNoError(t, graph.Run())
+	assertNodeResult(t, graph, "charge", 300)
+
+	graph, calls = build(-1)
+	err := graph.Run()
+	if !errors.Is(err, errNotPositive) || !errors.Is(err, ErrInvalidInputErr) {
+		t.Fatalf("Expected validation error, got %v", err)
+	}
+	if *calls != 0 {
+		t.Errorf("Expected the node function not to run, got %d calls", *calls)
+	}
+	status, _ := graph.NodeStatus("charge")
+	assertEqual(t, NodeStatusFailed, status)
+}
+
+func TestGraphWithSliceAsSingle(t *testing.T) {
+	graph := NewGraph()
+	graph.AddNode("pair", func() []int { return []int{3, 4} })
+	graph.AddNode("sum", func(a, b int) int { return a + b })
+	graph.AddNode("count", func(xs []int) int { return len(xs) }, WithSliceAsSingle())
+	graph.AddEdge("pair", "sum")
+	graph.AddEdge("pair", "count")
+	assertNoError(t, graph.Run())
+	results, _ := graph.NodeResult("sum")
+	if results[0] != 7 {
+		t.Fatalf("expected slice to expand into sum's arguments, got %v", results)
+	}
+	results, _ = graph.NodeResult("count")
+	if results[0] != 2 {
+		t.Fatalf("expected count to receive the slice, got %v", results)
+	}
+
+	strict := NewGraph()
+	strict.AddNode("pair", func() []int { return []int{3, 4} })
+	strict.AddNode("sum", func(a, b int) int { return a + b }, WithSliceAsSingle())
+	strict.AddEdge("pair", "sum")
+	if err := strict.Run(); !errors.Is(err, ErrArgCountMismatchErr) {
+		t.Fatalf("expected argument count mismatch without expansion, got %v", err)
+	}
+
+	collect := NewGraph()
+	collect.AddNode("a", func() int { return 1 })
+	collect.AddNode("b", func() int { return 2 })
+	collect.AddNode("all", func(xs []int) int { return len(xs) }, WithSliceAsSingle())
+	collect.AddEdge("a", "all")
+	collect.AddEdge("b", "all")
+	if err := collect.Run(); !errors.Is(err, ErrArgCountMismatchErr) {
+		t.Fatalf("expected argument count mismatch without collection, got %v", err)
+	}
+}
+
+func TestGraphEqualAndDiff(t *testing.T) {
+	build := func(order []string) *Graph {
+		graph := NewGraph()
+		nodes := map[string]any{
+			"a": func() int { return 1 },
+			"b": func(n int) int { return n },
+			"c": func(n int) int { return n },
+		}
+		for _, name := range order {
+			graph.AddNode(name, nodes[name])
+		}
+		graph.AddEdge("a", "c")
+		graph.AddEdgeWithCondition("a", "b", func(n int) bool { return n > 0 })
+		return graph
+	}
+
+	left := build([]string{"a", "b", "c"})
+	right := build([]string{"c", "b", "a"})
+	assertNoError(t, right.Run())
+	if !left.Equal(right) {
+		t.Fatalf("expected equal graphs, diff:\n%s", left.Diff(right))
+	}
+
+	right = build([]string{"a", "b", "c"})
+	assertNoError(t, right.RemoveNode("c"))
+	right.AddNode("c", func(a, b int) int { return a + b })
+	right.AddNode("d", func() {})
+	right.AddEdge("a", "c")
+	right.AddEdge("b", "c")
+	expected := "~ node c (1 args) -> (2 args)\n" +
+		"+ node d (0 args)\n" +
+		"+ edge b -> c [normal]\n"
+	if diff := left.Diff(right); diff != expected {
+		t.Fatalf("unexpected diff:\n%s", diff)
+	}
+	if left.Equal(right) {
+		t.Fatal("expected graphs to differ")
+	}
+}
+
+func TestGraphLoopErrorOnMax(t *testing.T) {
+	build := func(limit int, opts ...EdgeOption) *Graph {
+		graph := NewGraph()
+		graph.AddNode("start", func() int { return 0 })
+		graph.AddNode("step", func(n int) int { return n + 1 })
+		graph.AddEdge("start", "step")
+		graph.AddEdge("step", "step", append([]EdgeOption{
+			WithEdgeType(EdgeTypeLoop),
+			WithCondition(func(n int) bool { return n < limit }),
+			WithMaxIterations(5),
+		}, opts...)...)
+		return graph
+	}
+
+	graph := build(100)
+	assertNoError(t, graph.Run())
+	results, _ := graph.NodeResult("step")
+	if results[0] != 5 {
+		t.Fatalf("expected silent stop at the cap, got %v", results)
+	}
+
+	graph = build(100, WithLoopErrorOnMax())
+	err := graph.Run()
+	if !errors.Is(err, ErrLoopMaxIterationsErr) || !strings.Contains(err.Error(), "at node step") {
+		t.Fatalf("expected loop max iterations error, got %v", err)
+	}
+	assertNodeStatus(t, graph, "step", NodeStatusFailed)
+
+	graph = build(5, WithLoopErrorOnMax())
+	assertNoError(t, graph.Run())
+	results, _ = graph.NodeResult("step")
+	if results[0] != 5 {
+		t.Fatalf("expected loop converging on the last iteration to succeed, got %v", results)
+	}
+}
+
+func TestGraphNodeSignature(t *testing.T) {
+	graph := NewGraph()
+	graph.AddNode("parse", func(ctx context.Context, raw string, strict bool) (int, []string, error) {
+		return 0, nil, nil
+	})
+
+	in, out, err := graph.NodeSignature("parse")
+	assertNoError(t, err)
+	if !reflect.DeepEqual(in, []reflect.Type{reflect.TypeOf(""), reflect.TypeOf(true)}) {
+		t.Fatalf("unexpected input types %v", in)
+	}
+	if !reflect.DeepEqual(out, []reflect.Type{reflect.TypeOf(0), reflect.TypeOf([]string(nil))}) {
+		t.Fatalf("unexpected output types %v", out)
+	}
+
+	in[0] = reflect.TypeOf(0)
+	in, _, _ = graph.NodeSignature("parse")
+	if in[0] != reflect.TypeOf("") {
+		t.Fatal("expected NodeSignature to return a copy")
+	}
+
+	if _, _, err := graph.NodeSignature("missing"); !errors.Is(err, ErrNodeNotFoundErr) {
+		t.Fatalf("expected ErrNodeNotFound, got %v", err)
+	}
+}
+
+func TestGraphShutdown(t *testing.T) {
+	build := func(release <-chan struct{}, finished *atomic.Bool) (*Graph, chan struct{}) {
+		started := make(chan struct{})
+		graph := NewGraph()
+		graph.AddNode("slow", func() int {
+			close(started)
+			<-release
+			finished.Store(true)
+			return 1
+		})
+		go func() { _ = graph.Run() }()
+		return graph, started
+	}
+
+	release := make(chan struct{})
+	var finished atomic.Bool
+	graph, started := build(release, &finished)
+	<-started
+	time.AfterFunc(20*time.Millisecond, func() { close(release) })
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assertNoError(t, graph.Shutdown(ctx))
+	if !finished.Load() {
+		t.Fatal("expected Shutdown to wait for the running node")
+	}
+
+	stuck := make(chan struct{})
+	defer close(stuck)
+	graph, started = build(stuck, &finished)
+	<-started
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	var shutdownErr *ShutdownError
+	err := graph.Shutdown(ctx)
+	if !errors.As(err, &shutdownErr) || !reflect.DeepEqual(shutdownErr.Running, []string{"slow"}) {
+		t.Fatalf("expected slow to be reported as running, got %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if graph.State() != FlowStateCanceled {
+		t.Fatalf("expected canceled state, got %v", graph.State())
+	}
+}
+
+func sumOf[T int | float64](values []T) T {
+	var total T
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+func TestAddTypedNode(t *testing.T) {
+	graph := NewGraph()
+	graph.AddNode("load", func() []int { return []int{1, 2, 3} })
+	AddTypedNode(graph, "sum", sumOf[int])
+	AddTypedNode(graph, "label", strconv.Itoa)
+	graph.AddEdge("load", "sum")
+	graph.AddEdge("sum", "label")
+
+	assertNoError(t, graph.Run())
+	total, err := GraphNodeResultAs[int](graph, "sum")
+	assertNoError(t, err)
+	if total != 6 {
+		t.Fatalf("expected 6, got %d", total)
+	}
+	label, _ := GraphNodeResultAs[string](graph, "label")
+	if label != "6" {
+		t.Fatalf("expected \"6\", got %q", label)
+	}
+
+	in, out, _ := graph.NodeSignature("sum")
+	if in[0] != reflect.TypeOf([]int(nil)) || out[0] != reflect.TypeOf(0) {
+		t.Fatalf("expected instantiated types, got %v -> %v", in, out)
+	}
+}
+
+func TestGraphLoopStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var iterations atomic.Int32
+	graph := NewGraph()
+	graph.AddNode("start", func() int { return 0 })
+	graph.AddNode("poll", func(n int) int {
+		if iterations.Add(1) == 3 {
+			cancel()
+		}
+		return n + 1
+	})
+	graph.AddEdge("start", "poll")
+	graph.AddLoopEdge("poll", func(n int) bool { return true }, 1000)
+
+	err := graph.RunWithContext(ctx)
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, ErrExecutionCanceledErr) {
+		t.Fatalf("expected cancellation error, got %v", err)
+	}
+	if got := iterations.Load(); got != 3 {
+		t.Fatalf("expected the loop to stop after 3 iterations, got %d", got)
+	}
+	assertNodeStatus(t, graph, "poll", NodeStatusFailed)
+}
+
+func TestGraphSetConcurrencyKey(t *testing.T) {
+	var active, maxActive, other atomic.Int32
+	write := func() int {
+		n := active.Add(1)
+		for {
+			m := maxActive.Load()
+			if n <= m || maxActive.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		active.Add(-1)
+		return 0
+	}
+
+	graph := NewGraph()
+	graph.AddNode("writeA", write)
+	graph.AddNode("writeB", write)
+	graph.AddNode("writeC", write)
+	graph.AddNode("read", func() int {
+		time.Sleep(10 * time.Millisecond)
+		other.Store(active.Load())
+		return 0
+	})
+	for _, name := range []string{"writeA", "writeB", "writeC"} {
+		assertNoError(t, graph.SetConcurrencyKey(name, "db"))
+	}
+
+	start := time.Now()
+	assertNoError(t, graph.Run())
+	if maxActive.Load() != 1 {
+		t.Fatalf("expected same-key nodes never to overlap, saw %d at once", maxActive.Load())
+	}
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Fatalf("expected same-key nodes to run one after another, took %v", elapsed)
+	}
+	if other.Load() == 0 {
+		t.Fatal("expected the unkeyed node to run alongside a keyed node")
+	}
+
+	if err := graph.SetConcurrencyKey("missing", "db"); !errors.Is(err, ErrNodeNotFoundErr) {
+		t.Fatalf("expected ErrNodeNotFound, got %v", err)
+	}
+}
+
+func TestGraphReplayLog(t *testing.T) {
+	type quote struct {
+		Symbol string
+		Price  float64
+	}
+	var calls atomic.Int32
+	build := func(price float64) *Graph {
+		graph := NewGraph()
+		graph.AddNode("symbol", func() string { return "ACME" })
+		graph.AddNode("fetch", func(symbol string) (quote, error) {
+			calls.Add(1)
+			return quote{Symbol: symbol, Price: price}, nil
+		})
+		graph.AddNode("format", func(q quote) string { return fmt.Sprintf("%s=%.1f", q.Symbol, q.Price) })
+		graph.AddEdge("symbol", "fetch")
+		graph.AddEdge("fetch", "format")
+		return graph
+	}
+
+	var log strings.Builder
+	graph := build(12.5).EnableReplayLog(&log)
+	assertNoError(t, graph.Run())
+	assertNoError(t, graph.ReplayLogError())
+	if lines := strings.Count(log.String(), "\n"); lines != 3 {
+		t.Fatalf("expected one log line per node, got %d:\n%s", lines, log.String())
+	}
+
+	calls.Store(0)
+	replay := build(99)
+	assertNoError(t, replay.ReplayFrom(strings.NewReader(log.String()), "format"))
+	if calls.Load() != 0 {
+		t.Fatal("expected the recorded fetch not to be called")
+	}
+	formatted, _ := GraphNodeResultAs[string](replay, "format")
+	if formatted != "ACME=12.5" {
+		t.Fatalf("expected format to rerun on recorded inputs, got %q", formatted)
+	}
+
+	if err := build(1).ReplayFrom(strings.NewReader(`{"node":"missing"}`)); !errors.Is(err, ErrInvalidReplayLogErr) {
+		t.Fatalf("expected invalid replay log error, got %v", err)
+	}
+
+	type secret struct{ v int }
+	var hides atomic.Int32
+	buildSecret := func() *Graph {
+		graph := NewGraph()
+		graph.AddNode("hide", func() secret {
+			hides.Add(1)
+			return secret{v: 7}
+		})
+		graph.AddNode("reveal", func(s secret) int { return s.v })
+		graph.AddEdge("hide", "reveal")
+		return graph
+	}
+	log.Reset()
+	assertNoError(t, buildSecret().EnableReplayLog(&log).Run())
+	var entry ReplayEntry
+	assertNoError(t, json.Unmarshal([]byte(strings.SplitN(log.String(), "\n", 2)[0]), &entry))
+	if entry.Node != "hide" || !entry.Unencodable || entry.Outputs != nil {
+		t.Fatalf("expected lossy outputs to be marked unencodable, got %+v", entry)
+	}
+	secretReplay := buildSecret()
+	assertNoError(t, secretReplay.ReplayFrom(strings.NewReader(log.String())))
+	assertEqual(t, int32(2), hides.Load())
+	assertNodeResult(t, secretReplay, "reveal", 7)
+}
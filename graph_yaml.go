@@ -0,0 +1,85 @@
+//go:build yaml
+
+package flow
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+type yamlGraphDocument struct {
+	Version  int              `yaml:"version"`
+	Nodes    []NodeDefinition `yaml:"nodes"`
+	Edges    []EdgeDefinition `yaml:"edges"`
+	Branches []yamlBranch     `yaml:"branches"`
+	Loops    []yamlLoop       `yaml:"loops"`
+}
+
+type yamlBranch struct {
+	From    string             `yaml:"from"`
+	Targets []yamlBranchTarget `yaml:"targets"`
+	Default string             `yaml:"default"`
+}
+
+type yamlBranchTarget struct {
+	To        string `yaml:"to"`
+	Condition string `yaml:"condition"`
+	Label     string `yaml:"label"`
+}
+
+type yamlLoop struct {
+	Node          string `yaml:"node"`
+	Condition     string `yaml:"condition"`
+	MaxIterations int    `yaml:"max_iterations"`
+}
+
+func LoadGraphYAML(data []byte, registry map[string]any) (*Graph, error) {
+	var doc yamlGraphDocument
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return BuildGraph(doc.definition(), registry)
+}
+
+func (doc yamlGraphDocument) definition() GraphDefinition {
+	def := GraphDefinition{
+		Version: doc.Version,
+		Nodes:   doc.Nodes,
+		Edges:   append([]EdgeDefinition(nil), doc.Edges...),
+	}
+
+	for _, loop := range doc.Loops {
+		def.Edges = append(def.Edges, EdgeDefinition{
+			From:          loop.Node,
+			To:            loop.Node,
+			Type:          edgeTypeNames[EdgeTypeLoop],
+			Condition:     loop.Condition,
+			MaxIterations: loop.MaxIterations,
+		})
+	}
+
+	for _, branch := range doc.Branches {
+		for _, target := range branch.Targets {
+			label := target.Label
+			if label == "" {
+				label = target.To
+			}
+			def.Edges = append(def.Edges, EdgeDefinition{
+				From:      branch.From,
+				To:        target.To,
+				Type:      edgeTypeNames[EdgeTypeBranch],
+				Label:     label,
+				Condition: target.Condition,
+			})
+		}
+		if branch.Default != "" {
+			def.Edges = append(def.Edges, EdgeDefinition{
+				From:    branch.From,
+				To:      branch.Default,
+				Type:    edgeTypeNames[EdgeTypeBranch],
+				Label:   "default",
+				Default: true,
+			})
+		}
+	}
+	return def
+}
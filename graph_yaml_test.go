@@ -0,0 +1,89 @@
+//go:build yaml
+
+package flow
+
+import (
+	"testing"
+)
+
+const testWorkflowYAML = `
+version: 1
+nodes:
+  - name: submit
+  - name: review
+    action: score
+  - name: revise
+  - name: approve
+  - name: reject
+  - name: escalate
+    meta:
+      team: finance
+edges:
+  - from: submit
+    to: review
+  - from: review
+    to: revise
+loops:
+  - node: revise
+    condition: needs_revision
+    max_iterations: 5
+branches:
+  - from: revise
+    targets:
+      - to: approve
+        condition: approved
+      - to: reject
+        condition: rejected
+        label: score < 10
+    default: escalate
+`
+
+func TestLoadGraphYAML(t *testing.T) {
+	registry := map[string]any{
+		"submit":         func() int { return 40 },
+		"score":          func(n int) int { return n + 5 },
+		"revise":         func(n int) int { return n + 10 },
+		"approve":        func(n int) string { return "approved" },
+		"reject":         func(n int) string { return "rejected" },
+		"escalate":       func(n int) string { return "escalated" },
+		"needs_revision": func(n int) bool { return n < 60 },
+		"approved":       func(n int) bool { return n >= 100 },
+		"rejected":       func(n int) bool { return n < 10 },
+	}
+
+	graph, err := LoadGraphYAML([]byte(testWorkflowYAML), registry)
+	assertNoError(t, err)
+	assertNoError(t, graph.Run())
+	assertNodeResult(t, graph, "revise", 65)
+	assertNodeResult(t, graph, "escalate", "escalated")
+
+	mermaid := graph.Mermaid()
+	assertContains(t, mermaid, `revise --> |"score < 10"|reject`)
+	assertContains(t, mermaid, "revise --> |default|escalate")
+
+	data, err := graph.ExportJSON()
+	assertNoError(t, err)
+	assertContains(t, string(data), `"default": true`)
+
+	t.Run("MissingNode", func(t *testing.T) {
+		_, err := LoadGraphYAML([]byte(`
+nodes:
+  - name: a
+edges:
+  - from: a
+    to: missing
+`), map[string]any{"a": func() int { return 1 }})
+		assertError(t, err)
+		assertContains(t, err.Error(), "node not found: missing")
+	})
+
+	t.Run("UnboundAction", func(t *testing.T) {
+		_, err := LoadGraphYAML([]byte(`
+nodes:
+  - name: a
+    action: unknown
+`), map[string]any{"a": func() int { return 1 }})
+		assertError(t, err)
+		assertContains(t, err.Error(), ErrUnboundNode+": unknown")
+	})
+}
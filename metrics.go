@@ -0,0 +1,58 @@
+package flow
+
+import "time"
+
+type RunMetrics struct {
+	TotalNodes      int           `json:"total_nodes"`
+	Completed       int           `json:"completed"`
+	Failed          int           `json:"failed"`
+	Running         int           `json:"running"`
+	Pending         int           `json:"pending"`
+	Skipped         int           `json:"skipped"`
+	WallTime        time.Duration `json:"wall_time"`
+	SlowestNode     string        `json:"slowest_node,omitempty"`
+	SlowestDuration time.Duration `json:"slowest_duration,omitempty"`
+}
+
+func (g *Graph) Metrics() RunMetrics {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	finished := !g.running && !g.runFinishedAt.IsZero()
+	metrics := RunMetrics{TotalNodes: len(g.nodes)}
+	switch {
+	case finished:
+		metrics.WallTime = g.runFinishedAt.Sub(g.runStartedAt)
+	case g.running:
+		metrics.WallTime = time.Since(g.runStartedAt)
+	}
+
+	for _, name := range g.nodeOrder {
+		node := g.nodes[name]
+		node.mu.RLock()
+		switch node.status {
+		case NodeStatusCompleted:
+			metrics.Completed++
+		case NodeStatusFailed:
+			metrics.Failed++
+		case NodeStatusRunning:
+			metrics.Running++
+		case NodeStatusSkipped:
+			metrics.Skipped++
+		default:
+			if finished && g.pausedAtNode == "" {
+				metrics.Skipped++
+			} else {
+				metrics.Pending++
+			}
+		}
+		if !node.finishedAt.IsZero() {
+			if d := node.finishedAt.Sub(node.startedAt); metrics.SlowestNode == "" || d > metrics.SlowestDuration {
+				metrics.SlowestNode = name
+				metrics.SlowestDuration = d
+			}
+		}
+		node.mu.RUnlock()
+	}
+	return metrics
+}
@@ -0,0 +1,156 @@
+package flow
+
+import (
+	"context"
+	"time"
+)
+
+type ProgressHandler func(completed, total int)
+
+type ResultInterceptor func(node string, results []any) []any
+
+type InputInterceptor func(node string, inputs []any) []any
+
+type Observer interface {
+	OnNodeStart(name string)
+	OnNodeComplete(name string, results []any, d time.Duration)
+	OnNodeError(name string, err error)
+}
+
+func (g *Graph) AddObserver(o Observer) *Graph {
+	if o == nil {
+		return g
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.observers = append(g.observers, o)
+	return g
+}
+
+func (g *Graph) SetProgressHandler(handler ProgressHandler) *Graph {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.progressHandler = handler
+	return g
+}
+
+func (g *Graph) SetResultInterceptor(interceptor ResultInterceptor) *Graph {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.resultInterceptor = interceptor
+	return g
+}
+
+func (g *Graph) interceptResults(nodeName string, results []any) []any {
+	g.mu.RLock()
+	interceptor := g.resultInterceptor
+	g.mu.RUnlock()
+	if interceptor == nil {
+		return results
+	}
+	return interceptor(nodeName, results)
+}
+
+func (g *Graph) SetInputInterceptor(interceptor InputInterceptor) *Graph {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.inputInterceptor = interceptor
+	return g
+}
+
+func (g *Graph) interceptInputs(nodeName string, inputs []any) []any {
+	g.mu.RLock()
+	interceptor := g.inputInterceptor
+	g.mu.RUnlock()
+	if interceptor == nil {
+		return inputs
+	}
+	if intercepted := interceptor(nodeName, inputs); intercepted != nil {
+		return intercepted
+	}
+	return inputs
+}
+
+func (g *Graph) interceptInputsSafely(nodeName string, inputs []any) (_ []any, err error) {
+	defer g.recoverNodePanic(nodeName, &err)
+	return g.interceptInputs(nodeName, inputs), nil
+}
+
+func (g *Graph) reportProgress() {
+	g.mu.RLock()
+	handler := g.progressHandler
+	g.mu.RUnlock()
+	if handler == nil {
+		return
+	}
+
+	g.progressMu.Lock()
+	defer g.progressMu.Unlock()
+
+	g.mu.RLock()
+	total := len(g.nodes)
+	completed := 0
+	for _, node := range g.nodes {
+		node.mu.RLock()
+		if node.status == NodeStatusCompleted || node.status == NodeStatusSkipped {
+			completed++
+		}
+		node.mu.RUnlock()
+	}
+	g.mu.RUnlock()
+
+	notifyObserver(func() { handler(completed, total) })
+}
+
+func (g *Graph) executeNodeObserved(ctx context.Context, nodeName string, inputs []any) ([]any, error) {
+	release, err := g.acquireConcurrencyKey(ctx, nodeName)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	g.inflight.enter(nodeName)
+	defer g.inflight.leave(nodeName)
+
+	inputs, err = g.interceptInputsSafely(nodeName, inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	g.mu.RLock()
+	observers := g.observers
+	g.mu.RUnlock()
+
+	ctx, endSpan := g.startNodeSpan(ctx, nodeName)
+	if len(observers) == 0 && endSpan == nil {
+		return g.executeNodeRecorded(ctx, nodeName, inputs)
+	}
+
+	for _, o := range observers {
+		notifyObserver(func() { o.OnNodeStart(nodeName) })
+	}
+
+	start := time.Now()
+	results, err := g.executeNodeRecorded(ctx, nodeName, inputs)
+	elapsed := time.Since(start)
+	if endSpan != nil {
+		endSpan(err, elapsed)
+	}
+
+	for _, o := range observers {
+		if err != nil {
+			notifyObserver(func() { o.OnNodeError(nodeName, err) })
+		} else {
+			notifyObserver(func() { o.OnNodeComplete(nodeName, results, elapsed) })
+		}
+	}
+
+	return results, err
+}
+
+func notifyObserver(fn func()) {
+	defer func() {
+		_ = recover()
+	}()
+	fn()
+}
@@ -0,0 +1,164 @@
+package flow
+
+import (
+	"context"
+	"fmt"
+)
+
+func (g *Graph) RunUntil(ctx context.Context, target string) error {
+	if g.err != nil {
+		return g.err
+	}
+	if _, ok := g.nodes[target]; !ok {
+		return &FlowError{Kind: ErrNodeNotFoundErr, Message: fmt.Sprintf("%s: %s", ErrNodeNotFound, target)}
+	}
+
+	plan, err := g.buildExecutionPlan()
+	if err != nil {
+		return err
+	}
+	g.buildExecInEdges()
+
+	needed := g.ancestors(target)
+	needed[target] = true
+
+	prefix := make([]string, 0, len(needed))
+	for _, name := range plan {
+		if needed[name] {
+			prefix = append(prefix, name)
+		}
+	}
+
+	ctx, done := g.beginRun(ctx)
+	defer done()
+
+	return g.executeSequential(ctx, prefix)
+}
+
+func (g *Graph) RunFrom(ctx context.Context, start string, inputs []any) error {
+	if g.err != nil {
+		return g.err
+	}
+	node, ok := g.nodes[start]
+	if !ok {
+		return &FlowError{Kind: ErrNodeNotFoundErr, Message: fmt.Sprintf("%s: %s", ErrNodeNotFound, start)}
+	}
+	if inputs != nil {
+		if err := node.checkInputCount(inputs); err != nil {
+			return err
+		}
+	}
+
+	plan, err := g.buildExecutionPlan()
+	if err != nil {
+		return err
+	}
+	g.buildExecInEdges()
+
+	rerun := g.descendants(start)
+	rerun[start] = true
+
+	// Nodes outside the rerun set feed it only through their stored results.
+	needed := make(map[string]bool, len(rerun))
+	for name := range rerun {
+		needed[name] = true
+		if name == start && inputs != nil {
+			continue
+		}
+		for _, edge := range g.execInEdges[name] {
+			if edge.edgeType == EdgeTypeLoop || rerun[edge.from] || needed[edge.from] {
+				continue
+			}
+			n := g.nodes[edge.from]
+			n.mu.RLock()
+			completed := n.status == NodeStatusCompleted || n.status == NodeStatusSkipped
+			n.mu.RUnlock()
+			if !completed {
+				return &FlowError{Kind: ErrNodeNotCompletedErr, Message: fmt.Sprintf("%s: %s (input of %s)", ErrNodeNotCompleted, edge.from, name)}
+			}
+			needed[edge.from] = true
+		}
+	}
+
+	for name := range rerun {
+		n := g.nodes[name]
+		n.mu.Lock()
+		n.status = NodeStatusPending
+		n.result = nil
+		n.err = nil
+		n.mu.Unlock()
+	}
+
+	subPlan := make([]string, 0, len(needed))
+	for _, name := range plan {
+		if needed[name] {
+			subPlan = append(subPlan, name)
+		}
+	}
+
+	if inputs != nil {
+		g.mu.Lock()
+		g.inputOverrides = map[string][]any{start: inputs}
+		g.mu.Unlock()
+		defer func() {
+			g.mu.Lock()
+			g.inputOverrides = nil
+			g.mu.Unlock()
+		}()
+	}
+
+	ctx, done := g.beginRun(ctx)
+	defer done()
+
+	return g.executeSequential(ctx, subPlan)
+}
+
+func (n *Node) checkInputCount(inputs []any) error {
+	if n.fnType == nil || n.sliceArg {
+		return nil
+	}
+	if n.variadic {
+		if len(inputs) < n.argCount-1 {
+			return &FlowError{Kind: ErrArgCountMismatchErr, Message: fmt.Sprintf("%s: %s expects at least %d inputs, got %d", ErrArgCountMismatch, n.name, n.argCount-1, len(inputs))}
+		}
+		return nil
+	}
+	if len(inputs) != n.argCount {
+		return &FlowError{Kind: ErrArgCountMismatchErr, Message: fmt.Sprintf("%s: %s expects %d inputs, got %d", ErrArgCountMismatch, n.name, n.argCount, len(inputs))}
+	}
+	return nil
+}
+
+func (g *Graph) descendants(name string) map[string]bool {
+	seen := make(map[string]bool)
+	stack := []string{name}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, edge := range g.edges[current] {
+			if edge.edgeType == EdgeTypeLoop || seen[edge.to] {
+				continue
+			}
+			seen[edge.to] = true
+			stack = append(stack, edge.to)
+		}
+	}
+	return seen
+}
+
+func (g *Graph) ancestors(name string) map[string]bool {
+	seen := make(map[string]bool)
+	stack := []string{name}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, edge := range g.execInEdges[current] {
+			if edge.edgeType == EdgeTypeLoop || seen[edge.from] {
+				continue
+			}
+			seen[edge.from] = true
+			stack = append(stack, edge.from)
+		}
+	}
+	return seen
+}
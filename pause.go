@@ -3,7 +3,9 @@ package flow
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
+	"time"
 )
 
 type PausableFlow interface {
@@ -108,9 +110,19 @@ func (s *SimplePauseSignal) Reset() {
 	s.paused = false
 }
 
+type ResourceRequirementChecker interface {
+	CheckResource(resource string, units int) bool
+}
+
+type ResourceReserver interface {
+	AcquireResource(resource string, units int) bool
+	ReleaseResource(resource string, units int)
+}
+
 type SimpleResourceChecker struct {
 	available  int
 	perNodeUse int
+	named      map[string]int
 	mu         sync.RWMutex
 }
 
@@ -127,12 +139,57 @@ func (c *SimpleResourceChecker) SetAvailable(available int) {
 	c.available = available
 }
 
+func (c *SimpleResourceChecker) SetResourceAvailable(resource string, available int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.named == nil {
+		c.named = make(map[string]int)
+	}
+	c.named[resource] = available
+}
+
 func (c *SimpleResourceChecker) CheckAvailable(nodeName string) bool {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 	return c.available >= c.perNodeUse
 }
 
+func (c *SimpleResourceChecker) CheckResource(resource string, units int) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if available, ok := c.named[resource]; ok {
+		return available >= units
+	}
+	return c.available >= units
+}
+
+func (c *SimpleResourceChecker) AcquireResource(resource string, units int) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if available, ok := c.named[resource]; ok {
+		if available < units {
+			return false
+		}
+		c.named[resource] = available - units
+		return true
+	}
+	if c.available < units {
+		return false
+	}
+	c.available -= units
+	return true
+}
+
+func (c *SimpleResourceChecker) ReleaseResource(resource string, units int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.named[resource]; ok {
+		c.named[resource] += units
+		return
+	}
+	c.available += units
+}
+
 func (c *SimpleResourceChecker) Consume() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -152,6 +209,50 @@ var (
 	ErrResourceNotAvailable = errors.New("resource not available")
 )
 
+type PauseError struct {
+	Err error
+}
+
+func (e *PauseError) Error() string {
+	return fmt.Sprintf("pause requested: %v", e.Err)
+}
+
+func (e *PauseError) Unwrap() error {
+	return e.Err
+}
+
+type FatalError struct {
+	Err error
+}
+
+func (e *FatalError) Error() string {
+	return fmt.Sprintf("fatal: %v", e.Err)
+}
+
+func (e *FatalError) Unwrap() error {
+	return e.Err
+}
+
+func isPauseError(err error) bool {
+	var pauseErr *PauseError
+	return errors.As(err, &pauseErr)
+}
+
+func isFatalError(err error) bool {
+	var fatalErr *FatalError
+	return errors.As(err, &fatalErr)
+}
+
+func (g *Graph) pauseOnError(err error) bool {
+	if isFatalError(err) {
+		return false
+	}
+	if isPauseError(err) {
+		return true
+	}
+	return g.pauseConfig != nil && g.pauseConfig.OnErrorPause
+}
+
 func (g *Graph) Pause() error {
 	return g.PauseWithConfig(NewPauseConfig())
 }
@@ -175,10 +276,10 @@ func (g *Graph) PauseAtNode(nodeName string) error {
 
 	node, ok := g.nodes[nodeName]
 	if !ok {
-		return &FlowError{Message: ErrNodeNotFound}
+		return &FlowError{Kind: ErrNodeNotFoundErr, Message: ErrNodeNotFound}
 	}
 
-	if node.status == NodeStatusCompleted || node.status == NodeStatusFailed {
+	if node.status == NodeStatusCompleted || node.status == NodeStatusFailed || node.status == NodeStatusSkipped {
 		return ErrNodeNotPausable
 	}
 
@@ -215,10 +316,97 @@ func (g *Graph) ResumeWithConfig(ctx context.Context, config *ResumeConfig) erro
 	return g.RunWithContext(ctx)
 }
 
+func (g *Graph) PausedCh() <-chan string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.pausedCh == nil {
+		g.pausedCh = make(chan string, 1)
+	}
+	return g.pausedCh
+}
+
+func (g *Graph) setPausedAt(nodeName string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.pausedAtNode = nodeName
+	if g.pausedCh == nil {
+		return
+	}
+	for {
+		select {
+		case g.pausedCh <- nodeName:
+			return
+		default:
+		}
+		select {
+		case <-g.pausedCh:
+		default:
+		}
+	}
+}
+
+func (g *Graph) ResumeFrom(ctx context.Context) error {
+	g.mu.Lock()
+	if g.pausedAtNode == "" {
+		g.mu.Unlock()
+		return ErrNoPausePoint
+	}
+	g.resumePast = g.pausedAtNode
+	g.mu.Unlock()
+
+	return g.Resume(ctx)
+}
+
+func (g *Graph) Cancel() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.canceled = true
+	if g.cancelRun != nil {
+		g.cancelRun()
+	}
+}
+
+func (g *Graph) beginRun(ctx context.Context) (context.Context, func()) {
+	runCtx, cancel := context.WithCancel(ctx)
+
+	g.mu.Lock()
+	g.canceled = false
+	g.running = true
+	g.cancelRun = cancel
+	g.restorePruned()
+	g.resetWeightedPicks()
+	g.resetBranchPicks()
+	if g.autoCheckpoint != nil {
+		g.autoCheckpoint.resetCount()
+	}
+	g.runStartedAt = time.Now()
+	g.runFinishedAt = time.Time{}
+	g.mu.Unlock()
+
+	return runCtx, func() {
+		g.mu.Lock()
+		g.running = false
+		g.resumePast = ""
+		g.runFinishedAt = time.Now()
+		g.cancelRun = nil
+		g.mu.Unlock()
+		cancel()
+	}
+}
+
 func (g *Graph) State() FlowState {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
 
+	if g.canceled {
+		return FlowStateCanceled
+	}
+
+	if g.running {
+		return FlowStateRunning
+	}
+
 	if g.err != nil {
 		return FlowStateFailed
 	}
@@ -231,9 +419,11 @@ func (g *Graph) State() FlowState {
 	total := len(g.nodes)
 
 	for _, node := range g.nodes {
-		if node.status == NodeStatusCompleted {
+		node.mu.RLock()
+		if node.status == NodeStatusCompleted || node.status == NodeStatusSkipped {
 			completed++
 		}
+		node.mu.RUnlock()
 	}
 
 	if completed == 0 {
@@ -251,9 +441,11 @@ func (g *Graph) GetNodesByStatus(status NodeStatus) []string {
 
 	result := make([]string, 0)
 	for name, node := range g.nodes {
+		node.mu.RLock()
 		if node.status == status {
 			result = append(result, name)
 		}
+		node.mu.RUnlock()
 	}
 	return result
 }
@@ -3,6 +3,8 @@ package flow
 import (
 	"reflect"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 const (
@@ -10,16 +12,47 @@ const (
 	defaultSlicePoolMin = 32
 )
 
+type PoolStats struct {
+	Gets            uint64
+	Puts            uint64
+	Misses          uint64
+	Discards        uint64
+	DefaultCapacity int
+	MinCapacity     int
+}
+
+type poolCounters struct {
+	gets     atomic.Uint64
+	puts     atomic.Uint64
+	misses   atomic.Uint64
+	discards atomic.Uint64
+}
+
+func (c *poolCounters) snapshot() PoolStats {
+	if c == nil {
+		return PoolStats{}
+	}
+	return PoolStats{
+		Gets:     c.gets.Load(),
+		Puts:     c.puts.Load(),
+		Misses:   c.misses.Load(),
+		Discards: c.discards.Load(),
+	}
+}
+
 type ObjectPool[T any] struct {
 	pool  sync.Pool
 	reset func(T)
+	stats *poolCounters
 }
 
 func NewObjectPool[T any](creator func() T, opts ...PoolOption[T]) *ObjectPool[T] {
-	p := &ObjectPool[T]{
-		pool: sync.Pool{
-			New: func() any { return creator() },
-		},
+	p := &ObjectPool[T]{}
+	p.pool.New = func() any {
+		if p.stats != nil {
+			p.stats.misses.Add(1)
+		}
+		return creator()
 	}
 	for _, opt := range opts {
 		opt(p)
@@ -35,46 +68,82 @@ func WithReset[T any](reset func(T)) PoolOption[T] {
 	}
 }
 
+func WithStats[T any]() PoolOption[T] {
+	return func(p *ObjectPool[T]) {
+		p.stats = &poolCounters{}
+	}
+}
+
 func (p *ObjectPool[T]) Get() T {
+	if p.stats != nil {
+		p.stats.gets.Add(1)
+	}
 	return p.pool.Get().(T)
 }
 
 func (p *ObjectPool[T]) Put(x T) {
+	if p.stats != nil {
+		p.stats.puts.Add(1)
+	}
 	if p.reset != nil {
 		p.reset(x)
 	}
 	p.pool.Put(x)
 }
 
+func (p *ObjectPool[T]) Stats() PoolStats {
+	return p.stats.snapshot()
+}
+
 type SlicePool[T any] struct {
 	pool        sync.Pool
 	defaultCap  int
 	minCapacity int
+	stats       *poolCounters
+}
+
+type SlicePoolOption[T any] func(*SlicePool[T])
+
+func WithSliceStats[T any]() SlicePoolOption[T] {
+	return func(p *SlicePool[T]) {
+		p.stats = &poolCounters{}
+	}
 }
 
-func NewSlicePool[T any](defaultCap, minCapacity int) *SlicePool[T] {
+func NewSlicePool[T any](defaultCap, minCapacity int, opts ...SlicePoolOption[T]) *SlicePool[T] {
 	if defaultCap <= 0 {
 		defaultCap = 8
 	}
 	if minCapacity <= 0 {
 		minCapacity = defaultCap
 	}
-	return &SlicePool[T]{
-		pool: sync.Pool{
-			New: func() any {
-				s := make([]T, 0, defaultCap)
-				return &s
-			},
-		},
+	p := &SlicePool[T]{
 		defaultCap:  defaultCap,
 		minCapacity: minCapacity,
 	}
+	p.pool.New = func() any {
+		if p.stats != nil {
+			p.stats.misses.Add(1)
+		}
+		s := make([]T, 0, defaultCap)
+		return &s
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
 }
 
 func (p *SlicePool[T]) Get(minCap int) []T {
+	if p.stats != nil {
+		p.stats.gets.Add(1)
+	}
 	sp := p.pool.Get().(*[]T)
 	s := (*sp)[:0]
 	if cap(s) < minCap {
+		if p.stats != nil {
+			p.stats.misses.Add(1)
+		}
 		if minCap > p.defaultCap {
 			return make([]T, 0, minCap)
 		}
@@ -84,12 +153,24 @@ func (p *SlicePool[T]) Get(minCap int) []T {
 }
 
 func (p *SlicePool[T]) Put(s []T) {
+	if p.stats != nil {
+		p.stats.puts.Add(1)
+	}
 	if cap(s) >= p.minCapacity {
 		sp := s[:0]
 		p.pool.Put(&sp)
+	} else if p.stats != nil {
+		p.stats.discards.Add(1)
 	}
 }
 
+func (p *SlicePool[T]) Stats() PoolStats {
+	stats := p.stats.snapshot()
+	stats.DefaultCapacity = p.defaultCap
+	stats.MinCapacity = p.minCapacity
+	return stats
+}
+
 var (
 	anySlicePool          = NewSlicePool[any](defaultSlicePoolCap, defaultSlicePoolMin)
 	stringSlicePool       = NewSlicePool[string](defaultSlicePoolCap, defaultSlicePoolMin)
@@ -112,9 +193,27 @@ var (
 			n.err = nil
 			n.result = nil
 			n.callFn = nil
+			n.hasContext = false
+			n.hasLoopInfo = false
 			n.argCount = 0
 			n.sliceArg = false
+			n.sliceAsSingle = false
+			n.variadic = false
+			n.tags = nil
+			n.skip = false
+			n.skipIf = nil
+			n.validator = nil
 			n.sliceElemType = nil
+			n.retry = nil
+			n.meta = nil
+			n.startedAt = time.Time{}
+			n.finishedAt = time.Time{}
+			n.interrupted = false
+			n.pruned = false
+			n.compensate = nil
+			n.lastInputs = nil
+			n.cache = nil
+			n.subGraph = nil
 		}),
 	)
 
@@ -125,9 +224,20 @@ var (
 			e.to = ""
 			e.cond = nil
 			e.condFunc = nil
+			e.stateCond = nil
 			e.condComp = nil
 			e.weight = 0
 			e.edgeType = EdgeTypeNormal
+			e.carryError = false
+			e.onError = false
+			e.optional = false
+			e.errorOnMax = false
+			e.label = ""
+			e.isDefault = false
+			e.seq = 0
+			e.priority = 0
+			e.stopCtx = nil
+			e.stopChan = nil
 		}),
 	)
 
@@ -136,6 +246,7 @@ var (
 		WithReset(func(s *nodeState) {
 			s.results = nil
 			s.err = nil
+			s.bypassed = false
 			s.done = 0
 			s.finished = 0
 			s.doneSig = nil
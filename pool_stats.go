@@ -0,0 +1,25 @@
+//go:build poolstats
+
+package flow
+
+func init() {
+	anySlicePool.stats = &poolCounters{}
+	stringSlicePool.stats = &poolCounters{}
+	reflectValueSlicePool.stats = &poolCounters{}
+	nodePool.stats = &poolCounters{}
+	edgePool.stats = &poolCounters{}
+	nodeStatePool.stats = &poolCounters{}
+	condCompilerPool.stats = &poolCounters{}
+}
+
+func InternalPoolStats() map[string]PoolStats {
+	return map[string]PoolStats{
+		"anySlice":          anySlicePool.Stats(),
+		"stringSlice":       stringSlicePool.Stats(),
+		"reflectValueSlice": reflectValueSlicePool.Stats(),
+		"node":              nodePool.Stats(),
+		"edge":              edgePool.Stats(),
+		"nodeState":         nodeStatePool.Stats(),
+		"condCompiler":      condCompilerPool.Stats(),
+	}
+}
@@ -0,0 +1,21 @@
+//go:build poolstats
+
+package flow
+
+import "testing"
+
+func TestInternalPoolStats(t *testing.T) {
+	graph := NewGraph()
+	graph.AddNode("a", func() int { return 1 })
+	graph.AddNode("b", func(n int) int { return n })
+	graph.AddEdge("a", "b")
+	assertNoError(t, graph.Run())
+
+	stats := InternalPoolStats()
+	if stats["node"].Gets < 2 || stats["reflectValueSlice"].Gets == 0 {
+		t.Fatalf("expected internal pools to be instrumented, got %+v", stats)
+	}
+	if stats["anySlice"].DefaultCapacity != defaultSlicePoolCap {
+		t.Fatalf("unexpected anySlice sizing: %+v", stats["anySlice"])
+	}
+}
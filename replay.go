@@ -0,0 +1,231 @@
+package flow
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+)
+
+const ErrInvalidReplayLog = "invalid replay log"
+
+type ReplayEntry struct {
+	Node        string            `json:"node"`
+	Inputs      []json.RawMessage `json:"inputs,omitempty"`
+	Outputs     []json.RawMessage `json:"outputs,omitempty"`
+	Error       string            `json:"error,omitempty"`
+	Unencodable bool              `json:"unencodable,omitempty"`
+}
+
+type replayLog struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+type replayRecord struct {
+	outputs []any
+	err     error
+}
+
+func (g *Graph) EnableReplayLog(w io.Writer) *Graph {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if w == nil {
+		g.replayLog = nil
+		return g
+	}
+	g.replayLog = &replayLog{enc: json.NewEncoder(w)}
+	return g
+}
+
+func (g *Graph) ReplayLogError() error {
+	g.mu.RLock()
+	log := g.replayLog
+	g.mu.RUnlock()
+	if log == nil {
+		return nil
+	}
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	return log.err
+}
+
+func (l *replayLog) write(nodeName string, inputs, outputs []any, err error) {
+	entry := ReplayEntry{Node: nodeName}
+	var encErr error
+	entry.Inputs, encErr = encodeReplayValues(inputs)
+	if encErr == nil {
+		entry.Outputs, encErr = encodeReplayValues(outputs)
+	}
+	if encErr != nil {
+		entry.Inputs, entry.Outputs, entry.Unencodable = nil, nil, true
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if writeErr := l.enc.Encode(entry); writeErr != nil && l.err == nil {
+		l.err = writeErr
+	}
+}
+
+var errLossyReplayValue = errors.New("value cannot be encoded as JSON without loss")
+
+func encodeReplayValues(values []any) ([]json.RawMessage, error) {
+	encoded := make([]json.RawMessage, len(values))
+	for i, v := range values {
+		if !losslessJSON(reflect.ValueOf(v), make(map[uintptr]bool)) {
+			return nil, errLossyReplayValue
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		encoded[i] = data
+	}
+	return encoded, nil
+}
+
+func (g *Graph) ReplayFrom(r io.Reader, rerun ...string) error {
+	if g.err != nil {
+		return g.err
+	}
+
+	g.mu.RLock()
+	records, inputs, err := g.readReplayLog(r, rerun)
+	g.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	g.replaying = records
+	g.mu.Unlock()
+	defer func() {
+		g.mu.Lock()
+		g.replaying = nil
+		g.mu.Unlock()
+	}()
+
+	if len(inputs) > 0 {
+		return g.RunWithInputs(context.Background(), inputs)
+	}
+	return g.RunWithContext(context.Background())
+}
+
+func (g *Graph) readReplayLog(r io.Reader, rerun []string) (map[string]*replayRecord, map[string][]any, error) {
+	records := make(map[string]*replayRecord)
+	inputs := make(map[string][]any)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry ReplayEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, nil, &FlowError{Kind: ErrInvalidReplayLogErr, Message: fmt.Sprintf("%s: line %d: %v", ErrInvalidReplayLog, line, err), Cause: err}
+		}
+		node, ok := g.nodes[entry.Node]
+		if !ok {
+			return nil, nil, &FlowError{Kind: ErrInvalidReplayLogErr, Message: fmt.Sprintf("%s: line %d: %s: %s", ErrInvalidReplayLog, line, ErrNodeNotFound, entry.Node)}
+		}
+		if entry.Unencodable {
+			delete(records, entry.Node)
+			continue
+		}
+
+		if slices.Contains(rerun, entry.Node) {
+			if g.inDegree[entry.Node] == 0 && len(entry.Inputs) > 0 {
+				values, err := decodeReplayValues(entry.Inputs, node.argTypes)
+				if err != nil {
+					return nil, nil, &FlowError{Kind: ErrInvalidReplayLogErr, Message: fmt.Sprintf("%s: line %d: inputs of %s: %v", ErrInvalidReplayLog, line, entry.Node, err), Cause: err}
+				}
+				inputs[entry.Node] = values
+			}
+			continue
+		}
+
+		record := &replayRecord{}
+		if entry.Error != "" {
+			record.err = errors.New(entry.Error)
+		} else {
+			outputs, err := decodeReplayValues(entry.Outputs, nodeOutputTypes(node))
+			if err != nil {
+				return nil, nil, &FlowError{Kind: ErrInvalidReplayLogErr, Message: fmt.Sprintf("%s: line %d: results of %s: %v", ErrInvalidReplayLog, line, entry.Node, err), Cause: err}
+			}
+			record.outputs = outputs
+		}
+		records[entry.Node] = record
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, &FlowError{Kind: ErrInvalidReplayLogErr, Message: fmt.Sprintf("%s: %v", ErrInvalidReplayLog, err), Cause: err}
+	}
+	return records, inputs, nil
+}
+
+func decodeReplayValues(raw []json.RawMessage, types []reflect.Type) ([]any, error) {
+	values := make([]any, len(raw))
+	for i, data := range raw {
+		if len(raw) != len(types) {
+			if err := json.Unmarshal(data, &values[i]); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		target := reflect.New(types[i])
+		if err := json.Unmarshal(data, target.Interface()); err != nil {
+			return nil, err
+		}
+		values[i] = target.Elem().Interface()
+	}
+	return values, nil
+}
+
+func (g *Graph) executeNodeRecorded(ctx context.Context, nodeName string, inputs []any) ([]any, error) {
+	g.mu.RLock()
+	log := g.replayLog
+	record := g.replaying[nodeName]
+	g.mu.RUnlock()
+
+	var results []any
+	var err error
+	if record != nil {
+		results, err = g.replayNode(nodeName, inputs, record)
+	} else {
+		results, err = g.executeNodeWithLoop(ctx, nodeName, inputs)
+	}
+	if log != nil {
+		log.write(nodeName, inputs, results, err)
+	}
+	return results, err
+}
+
+func (g *Graph) replayNode(nodeName string, inputs []any, record *replayRecord) ([]any, error) {
+	node := g.nodes[nodeName]
+	node.recordInputs(inputs)
+
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	now := time.Now()
+	node.startedAt = now
+	node.finishedAt = now
+	if record.err != nil {
+		node.err = record.err
+		node.status = NodeStatusFailed
+		return nil, record.err
+	}
+	node.err = nil
+	node.result = record.outputs
+	node.status = NodeStatusCompleted
+	return slices.Clone(record.outputs), nil
+}
@@ -0,0 +1,72 @@
+package flow
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     time.Duration
+	Multiplier  float64
+}
+
+type RetryError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("failed after %d attempts: %v", e.Attempts, e.Err)
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+func (g *Graph) AddNodeWithRetry(name string, fn any, policy RetryPolicy) *Graph {
+	if !g.tryAddNode(name, fn) {
+		return g
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.nodes[name].retry = &policy
+	return g
+}
+
+func (g *Graph) callNodeWithRetry(ctx context.Context, node *Node, inputs []any) ([]any, error) {
+	policy := node.retry
+	if policy == nil || policy.MaxAttempts <= 1 {
+		return node.callFn(ctx, inputs)
+	}
+
+	multiplier := policy.Multiplier
+	if multiplier <= 0 {
+		multiplier = 1
+	}
+	backoff := policy.Backoff
+
+	for attempt := 1; ; attempt++ {
+		results, err := node.callFn(ctx, inputs)
+		if err == nil {
+			return results, nil
+		}
+		if isFatalError(err) || isPauseError(err) {
+			return nil, err
+		}
+		if attempt >= policy.MaxAttempts {
+			return nil, &RetryError{Attempts: attempt, Err: err}
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, &RetryError{Attempts: attempt, Err: canceledError(ctx)}
+		case <-timer.C:
+		}
+		backoff = time.Duration(float64(backoff) * multiplier)
+	}
+}
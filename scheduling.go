@@ -0,0 +1,83 @@
+package flow
+
+func WithFairScheduling() GraphOption {
+	return func(g *Graph) {
+		g.fairScheduling = true
+	}
+}
+
+type fairScheduler struct {
+	graph    *Graph
+	branchOf map[string]string
+	forked   map[string]bool
+	served   map[string]int
+	seq      int
+}
+
+func (g *Graph) newFairScheduler() *fairScheduler {
+	if !g.fairScheduling {
+		return nil
+	}
+	return &fairScheduler{
+		graph:    g,
+		branchOf: make(map[string]string, len(g.nodes)),
+		forked:   make(map[string]bool, len(g.nodes)),
+		served:   make(map[string]int),
+	}
+}
+
+func (f *fairScheduler) branch(name string) string {
+	if branch, ok := f.branchOf[name]; ok {
+		return branch
+	}
+	return name
+}
+
+func (f *fairScheduler) visit(current string) {
+	branch := f.branch(current)
+	fanOut := f.graph.outDegree[current] > 1
+	for _, edge := range f.graph.edges[current] {
+		if edge.edgeType == EdgeTypeLoop {
+			continue
+		}
+		if _, ok := f.branchOf[edge.to]; ok {
+			continue
+		}
+		switch {
+		case f.forked[current]:
+			f.branchOf[edge.to] = branch
+			f.forked[edge.to] = true
+		case fanOut:
+			f.branchOf[edge.to] = edge.to
+			f.forked[edge.to] = true
+		default:
+			f.branchOf[edge.to] = branch
+		}
+	}
+}
+
+func (f *fairScheduler) pick(ready []string, priorities map[string]int) int {
+	best := 0
+	for i := 1; i < len(ready); i++ {
+		a, b := ready[i], ready[best]
+		if pa, pb := priorities[a], priorities[b]; pa != pb {
+			if pa > pb {
+				best = i
+			}
+			continue
+		}
+		if f.served[f.branch(a)] < f.served[f.branch(b)] {
+			best = i
+		}
+	}
+	f.seq++
+	f.served[f.branch(ready[best])] = f.seq
+	return best
+}
+
+func (f *fairScheduler) next(queue []string, head int, priorities map[string]int) {
+	best := head + f.pick(queue[head:], priorities)
+	picked := queue[best]
+	copy(queue[head+1:best+1], queue[head:best])
+	queue[head] = picked
+}
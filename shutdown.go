@@ -0,0 +1,82 @@
+package flow
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+type ShutdownError struct {
+	Running []string
+	Err     error
+}
+
+func (e *ShutdownError) Error() string {
+	return fmt.Sprintf("shutdown: %v with nodes still running: %s", e.Err, strings.Join(e.Running, ", "))
+}
+
+func (e *ShutdownError) Unwrap() error {
+	return e.Err
+}
+
+type inflightNodes struct {
+	mu      sync.Mutex
+	running map[string]int
+	count   int
+	drained chan struct{}
+}
+
+func (f *inflightNodes) enter(name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.running == nil {
+		f.running = make(map[string]int)
+	}
+	if f.count == 0 {
+		f.drained = make(chan struct{})
+	}
+	f.running[name]++
+	f.count++
+}
+
+func (f *inflightNodes) leave(name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.running[name]--; f.running[name] == 0 {
+		delete(f.running, name)
+	}
+	if f.count--; f.count == 0 {
+		close(f.drained)
+	}
+}
+
+func (f *inflightNodes) wait() <-chan struct{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.count == 0 {
+		drained := make(chan struct{})
+		close(drained)
+		return drained
+	}
+	return f.drained
+}
+
+func (f *inflightNodes) names() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Sorted(maps.Keys(f.running))
+}
+
+func (g *Graph) Shutdown(ctx context.Context) error {
+	g.Cancel()
+
+	select {
+	case <-g.inflight.wait():
+		return nil
+	case <-ctx.Done():
+		return &ShutdownError{Running: g.inflight.names(), Err: ctx.Err()}
+	}
+}
@@ -0,0 +1,102 @@
+package flow
+
+import "fmt"
+
+func WithSkipIf(pred func(inputs []any) bool) NodeOption {
+	return func(n *Node) {
+		n.skipIf = pred
+	}
+}
+
+func (g *Graph) SkipNode(name string) error {
+	g.mu.RLock()
+	node, ok := g.nodes[name]
+	g.mu.RUnlock()
+	if !ok {
+		return &FlowError{Kind: ErrNodeNotFoundErr, Message: fmt.Sprintf("%s: %s", ErrNodeNotFound, name)}
+	}
+
+	node.mu.Lock()
+	node.skip = true
+	node.mu.Unlock()
+	return nil
+}
+
+func (n *Node) skipWith(inputs []any) bool {
+	n.mu.RLock()
+	skip, skipIf := n.skip, n.skipIf
+	n.mu.RUnlock()
+	if !skip && (skipIf == nil || !skipIf(inputs)) {
+		return false
+	}
+
+	n.mu.Lock()
+	n.status = NodeStatusSkipped
+	n.err = nil
+	n.result = append([]any(nil), inputs...)
+	n.mu.Unlock()
+	return true
+}
+
+func (g *Graph) PruneUnreached() []string {
+	order, err := g.TopologicalOrder()
+	if err != nil {
+		return nil
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.running || g.pausedAtNode != "" {
+		return nil
+	}
+
+	incoming := make(map[string][]*Edge, len(g.nodes))
+	for _, edges := range g.edges {
+		for _, edge := range edges {
+			if edge.edgeType != EdgeTypeLoop {
+				incoming[edge.to] = append(incoming[edge.to], edge)
+			}
+		}
+	}
+
+	blocked := make(map[string]bool)
+	pruned := make([]string, 0)
+	for _, name := range order {
+		node := g.nodes[name]
+		node.mu.Lock()
+		if node.status == NodeStatusPending {
+			for _, edge := range incoming[name] {
+				if blocked[edge.from] {
+					blocked[name] = true
+					break
+				}
+				from := g.nodes[edge.from]
+				from.mu.RLock()
+				failed := from.status == NodeStatusFailed && !edge.carryError
+				from.mu.RUnlock()
+				if failed {
+					blocked[name] = true
+					break
+				}
+			}
+			if !blocked[name] {
+				node.status = NodeStatusSkipped
+				node.pruned = true
+				pruned = append(pruned, name)
+			}
+		}
+		node.mu.Unlock()
+	}
+	return pruned
+}
+
+func (g *Graph) restorePruned() {
+	for _, node := range g.nodes {
+		node.mu.Lock()
+		if node.pruned {
+			node.status = NodeStatusPending
+			node.pruned = false
+		}
+		node.mu.Unlock()
+	}
+}
@@ -0,0 +1,294 @@
+package flow
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+const (
+	ErrInvalidStreamSource = "stream source must be a slice, array or receive channel"
+	ErrInvalidStreamStage  = "stream stage must be func(<-chan T) <-chan R, func(T) R, func(T) (R, error), func(T) or func(T) error"
+	ErrStreamAfterSink     = "stream stage added after a sink"
+)
+
+type streamStage struct {
+	name     string
+	fnValue  reflect.Value
+	inType   reflect.Type
+	outType  reflect.Type
+	channel  bool
+	hasError bool
+}
+
+type StreamChain struct {
+	source     reflect.Value
+	sourceType reflect.Type
+	stages     []*streamStage
+	bufferSize int
+	results    []any
+	err        error
+	once       sync.Once
+}
+
+func NewStreamChain(source any) *StreamChain {
+	c := &StreamChain{bufferSize: defaultInputBufferSize}
+	value := reflect.ValueOf(source)
+	switch {
+	case !value.IsValid():
+		c.err = &FlowError{Kind: ErrInvalidStreamSourceErr, Message: ErrInvalidStreamSource}
+	case value.Kind() == reflect.Slice || value.Kind() == reflect.Array:
+		c.source = value
+		c.sourceType = value.Type().Elem()
+	case value.Kind() == reflect.Chan && value.Type().ChanDir()&reflect.RecvDir != 0:
+		c.source = value
+		c.sourceType = value.Type().Elem()
+	default:
+		c.err = &FlowError{Kind: ErrInvalidStreamSourceErr, Message: ErrInvalidStreamSource}
+	}
+	return c
+}
+
+func (c *StreamChain) SetBufferSize(n int) *StreamChain {
+	if n < 0 {
+		n = 0
+	}
+	c.bufferSize = n
+	return c
+}
+
+func (c *StreamChain) Add(name string, fn any) *StreamChain {
+	if c.err != nil {
+		return c
+	}
+
+	prevType := c.sourceType
+	if n := len(c.stages); n > 0 {
+		if c.stages[n-1].outType == nil {
+			c.err = &FlowError{Kind: ErrStreamAfterSinkErr, Message: fmt.Sprintf("%s: %s", ErrStreamAfterSink, name)}
+			return c
+		}
+		prevType = c.stages[n-1].outType
+	}
+
+	stage, ok := newStreamStage(name, fn)
+	if !ok {
+		c.err = &FlowError{Kind: ErrInvalidStreamStageErr, Message: fmt.Sprintf("%s: %s", ErrInvalidStreamStage, name)}
+		return c
+	}
+	if !prevType.AssignableTo(stage.inType) && !prevType.ConvertibleTo(stage.inType) {
+		c.err = &FlowError{Kind: ErrArgTypeMismatchErr, Message: fmt.Sprintf("%s: %s expects %v, got %v", ErrArgTypeMismatch, name, stage.inType, prevType)}
+		return c
+	}
+	c.stages = append(c.stages, stage)
+	return c
+}
+
+func newStreamStage(name string, fn any) (*streamStage, bool) {
+	fnValue := reflect.ValueOf(fn)
+	if !fnValue.IsValid() || fnValue.Kind() != reflect.Func || fnValue.Type().NumIn() != 1 {
+		return nil, false
+	}
+	fnType := fnValue.Type()
+	stage := &streamStage{name: name, fnValue: fnValue, inType: fnType.In(0)}
+
+	if in := fnType.In(0); in.Kind() == reflect.Chan && in.ChanDir()&reflect.RecvDir != 0 {
+		if fnType.NumOut() != 1 {
+			return nil, false
+		}
+		out := fnType.Out(0)
+		if out.Kind() != reflect.Chan || out.ChanDir()&reflect.RecvDir == 0 {
+			return nil, false
+		}
+		stage.channel = true
+		stage.inType = in.Elem()
+		stage.outType = out.Elem()
+		return stage, true
+	}
+
+	switch fnType.NumOut() {
+	case 0:
+	case 1:
+		if fnType.Out(0).Implements(errorType) {
+			stage.hasError = true
+		} else {
+			stage.outType = fnType.Out(0)
+		}
+	case 2:
+		if !fnType.Out(1).Implements(errorType) {
+			return nil, false
+		}
+		stage.outType = fnType.Out(0)
+		stage.hasError = true
+	default:
+		return nil, false
+	}
+	return stage, true
+}
+
+func (c *StreamChain) Run() error {
+	return c.RunWithContext(context.Background())
+}
+
+func (c *StreamChain) RunWithContext(ctx context.Context) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.results = nil
+	c.once = sync.Once{}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	fail := func(err error) {
+		c.once.Do(func() { c.err = err })
+		cancel()
+	}
+
+	var wg sync.WaitGroup
+	prev := c.source
+	if c.source.Kind() != reflect.Chan {
+		prev = reflect.MakeChan(reflect.ChanOf(reflect.BothDir, c.sourceType), c.bufferSize)
+		wg.Add(1)
+		go func(out reflect.Value) {
+			defer wg.Done()
+			defer out.Close()
+			for i := range c.source.Len() {
+				if !streamSend(ctx, out, c.source.Index(i)) {
+					return
+				}
+			}
+		}(prev)
+	}
+
+	for _, stage := range c.stages {
+		in := reflect.MakeChan(reflect.ChanOf(reflect.BothDir, stage.inType), c.bufferSize)
+		wg.Add(1)
+		go func(src, dst reflect.Value) {
+			defer wg.Done()
+			streamForward(ctx, src, dst)
+		}(prev, in)
+
+		if stage.channel {
+			prev = stage.fnValue.Call([]reflect.Value{in})[0]
+			continue
+		}
+
+		var out reflect.Value
+		if stage.outType != nil {
+			out = reflect.MakeChan(reflect.ChanOf(reflect.BothDir, stage.outType), c.bufferSize)
+		}
+		wg.Add(1)
+		go func(stage *streamStage, in, out reflect.Value) {
+			defer wg.Done()
+			if out.IsValid() {
+				defer out.Close()
+			}
+			if err := stage.process(ctx, in, out); err != nil {
+				fail(err)
+				streamDrain(in)
+			}
+		}(stage, in, out)
+		prev = out
+	}
+
+	if prev.IsValid() {
+		for {
+			value, ok := streamRecv(ctx, prev)
+			if !ok {
+				break
+			}
+			c.results = append(c.results, value.Interface())
+		}
+		if ctx.Err() != nil {
+			streamDrain(prev)
+		}
+	}
+	wg.Wait()
+
+	if c.err == nil && ctx.Err() != nil {
+		c.err = canceledError(ctx)
+	}
+	return c.err
+}
+
+func (s *streamStage) process(ctx context.Context, in, out reflect.Value) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &FlowError{Kind: ErrFunctionPanickedErr, Message: fmt.Sprintf("%s: %s: %v", ErrFunctionPanicked, s.name, r)}
+		}
+	}()
+
+	for {
+		item, ok := streamRecv(ctx, in)
+		if !ok {
+			return nil
+		}
+		results := s.fnValue.Call([]reflect.Value{item})
+		if s.hasError {
+			if errValue := results[len(results)-1]; !errValue.IsNil() {
+				err := errValue.Interface().(error)
+				return &FlowError{Message: fmt.Sprintf("stage %s failed: %v", s.name, err), Cause: err}
+			}
+		}
+		if out.IsValid() && !streamSend(ctx, out, results[0]) {
+			return nil
+		}
+	}
+}
+
+func streamForward(ctx context.Context, src, dst reflect.Value) {
+	defer dst.Close()
+	elemType := dst.Type().Elem()
+	for {
+		value, ok := streamRecv(ctx, src)
+		if !ok {
+			break
+		}
+		if !value.Type().AssignableTo(elemType) {
+			value = value.Convert(elemType)
+		}
+		if !streamSend(ctx, dst, value) {
+			break
+		}
+	}
+	if ctx.Err() != nil {
+		go streamDrain(src)
+	}
+}
+
+func streamRecv(ctx context.Context, ch reflect.Value) (reflect.Value, bool) {
+	chosen, value, ok := reflect.Select([]reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+		{Dir: reflect.SelectRecv, Chan: ch},
+	})
+	if chosen == 0 || !ok {
+		return reflect.Value{}, false
+	}
+	return value, true
+}
+
+func streamSend(ctx context.Context, ch, value reflect.Value) bool {
+	chosen, _, _ := reflect.Select([]reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+		{Dir: reflect.SelectSend, Chan: ch, Send: value},
+	})
+	return chosen == 1
+}
+
+func streamDrain(ch reflect.Value) {
+	for {
+		if _, ok := ch.Recv(); !ok {
+			return
+		}
+	}
+}
+
+func (c *StreamChain) Results() []any {
+	return c.results
+}
+
+func (c *StreamChain) Error() error {
+	return c.err
+}
@@ -0,0 +1,89 @@
+package flow
+
+import (
+	"context"
+	"errors"
+)
+
+const ErrNilSubGraph = "sub-graph is nil"
+
+func (g *Graph) AddSubGraph(name string, sub *Graph) *Graph {
+	if g.err != nil {
+		return g
+	}
+	if sub == nil {
+		g.err = &FlowError{Kind: ErrNilSubGraphErr, Message: ErrNilSubGraph}
+		return g
+	}
+
+	if !g.tryAddNode(name, nil) {
+		return g
+	}
+
+	sub.mu.Lock()
+	sub.isSubGraph = true
+	sub.mu.Unlock()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	node := g.nodes[name]
+	node.subGraph = sub
+	node.callFn = sub.runAsNode()
+	return g
+}
+
+// Runs of one sub-graph are serialized, since each starts from a cleared status.
+func (g *Graph) runAsNode() func(context.Context, []any) ([]any, error) {
+	return func(ctx context.Context, inputs []any) ([]any, error) {
+		g.subRunMu.Lock()
+		defer g.subRunMu.Unlock()
+
+		if g.err != nil {
+			return nil, g.err
+		}
+		g.ClearStatus()
+
+		g.mu.Lock()
+		g.startInputs = inputs
+		g.mu.Unlock()
+		defer func() {
+			g.mu.Lock()
+			g.startInputs = nil
+			g.mu.Unlock()
+		}()
+
+		if err := g.RunWithContext(ctx); err != nil {
+			if errors.Is(err, ErrFlowPaused) {
+				return nil, ErrFlowPaused
+			}
+			return nil, err
+		}
+		return g.terminalResults(), nil
+	}
+}
+
+func (g *Graph) terminalResults() []any {
+	g.mu.RLock()
+	plan := g.execPlan
+	if g.layersValid {
+		plan = nil
+		for _, layer := range g.layers {
+			plan = append(plan, layer...)
+		}
+	}
+	g.mu.RUnlock()
+
+	var results []any
+	for _, name := range plan {
+		if g.outDegree[name] != 0 {
+			continue
+		}
+		node := g.nodes[name]
+		node.mu.RLock()
+		if node.status == NodeStatusCompleted || node.status == NodeStatusSkipped {
+			results = append(results, node.result...)
+		}
+		node.mu.RUnlock()
+	}
+	return results
+}
@@ -0,0 +1,73 @@
+package flow
+
+import "maps"
+
+type GraphBuilder struct {
+	graph *Graph
+}
+
+type GraphTemplate struct {
+	graph  *Graph
+	plan   []string
+	layers [][]string
+}
+
+func NewGraphBuilder(opts ...GraphOption) *GraphBuilder {
+	return &GraphBuilder{graph: NewGraph(opts...)}
+}
+
+func (b *GraphBuilder) AddNode(name string, fn any, opts ...NodeOption) *GraphBuilder {
+	b.graph.AddNode(name, fn, opts...)
+	return b
+}
+
+func (b *GraphBuilder) AddEdge(from, to string, opts ...EdgeOption) *GraphBuilder {
+	b.graph.AddEdge(from, to, opts...)
+	return b
+}
+
+func (b *GraphBuilder) Build() (*GraphTemplate, error) {
+	if b.graph.err != nil {
+		return nil, b.graph.err
+	}
+
+	graph := b.graph.Clone()
+	plan, err := graph.buildExecutionPlan()
+	if err != nil {
+		return nil, err
+	}
+	layers, err := graph.buildLayers()
+	if err != nil {
+		return nil, err
+	}
+	return &GraphTemplate{graph: graph, plan: plan, layers: layers}, nil
+}
+
+func (t *GraphTemplate) Instance() *Graph {
+	src := t.graph
+	g := src.cloneSettings()
+	for _, name := range src.nodeOrder {
+		node := src.nodes[name]
+		n := node.cloneConfig()
+		n.retry = node.retry
+		n.meta = node.meta
+		g.nodes[name] = n
+	}
+	g.nodeOrder = append(g.nodeOrder, src.nodeOrder...)
+
+	for from, edges := range src.edges {
+		g.edges[from] = append([]*Edge(nil), edges...)
+	}
+	maps.Copy(g.inDegree, src.inDegree)
+	maps.Copy(g.outDegree, src.outDegree)
+
+	g.execPlan = append([]string(nil), t.plan...)
+	g.branchTargetNodes = maps.Clone(src.branchTargetNodes)
+	g.execPlanValid = true
+	g.layers = make([][]string, len(t.layers))
+	for i, layer := range t.layers {
+		g.layers[i] = append([]string(nil), layer...)
+	}
+	g.layersValid = true
+	return g
+}
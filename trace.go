@@ -0,0 +1,54 @@
+package flow
+
+import (
+	"context"
+	"time"
+)
+
+type Tracer interface {
+	StartSpan(ctx context.Context, name string) (context.Context, Span)
+}
+
+type Span interface {
+	SetAttribute(key string, value any)
+	End()
+}
+
+const (
+	SpanAttrNodeName     = "flow.node.name"
+	SpanAttrNodeStatus   = "flow.node.status"
+	SpanAttrNodeDuration = "flow.node.duration_ns"
+	SpanAttrNodeError    = "flow.node.error"
+)
+
+func (g *Graph) SetTracer(tracer Tracer) *Graph {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.tracer = tracer
+	return g
+}
+
+func (g *Graph) startNodeSpan(ctx context.Context, nodeName string) (context.Context, func(error, time.Duration)) {
+	g.mu.RLock()
+	tracer := g.tracer
+	g.mu.RUnlock()
+	if tracer == nil {
+		return ctx, nil
+	}
+
+	spanCtx, span := tracer.StartSpan(ctx, nodeName)
+	if spanCtx == nil {
+		spanCtx = ctx
+	}
+	span.SetAttribute(SpanAttrNodeName, nodeName)
+	return spanCtx, func(err error, elapsed time.Duration) {
+		status := NodeStatusCompleted
+		if err != nil {
+			status = NodeStatusFailed
+			span.SetAttribute(SpanAttrNodeError, err.Error())
+		}
+		span.SetAttribute(SpanAttrNodeStatus, status.String())
+		span.SetAttribute(SpanAttrNodeDuration, int64(elapsed))
+		span.End()
+	}
+}
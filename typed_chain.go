@@ -0,0 +1,85 @@
+package flow
+
+import (
+	"context"
+	"fmt"
+)
+
+type typedStep[T any] struct {
+	name  string
+	fn    func(T) T
+	value T
+	do    bool
+}
+
+type TypedChain[T any] struct {
+	initial   T
+	err       error
+	stepNames map[string]int
+	steps     []*typedStep[T]
+}
+
+func NewTypedChain[T any](initial T) *TypedChain[T] {
+	return &TypedChain[T]{
+		initial:   initial,
+		stepNames: make(map[string]int, defaultChainCapacity),
+		steps:     make([]*typedStep[T], 0, defaultChainCapacity),
+	}
+}
+
+func (c *TypedChain[T]) Add(name string, fn func(T) T) *TypedChain[T] {
+	if c.err != nil {
+		return c
+	}
+	if fn == nil {
+		c.err = &FlowError{Kind: ErrNotFunctionErr, Message: ErrNotFunction}
+		return c
+	}
+	if _, exists := c.stepNames[name]; exists {
+		c.err = &FlowError{Kind: ErrDuplicateNodeErr, Message: fmt.Sprintf("%s: %s", ErrDuplicateNode, name)}
+		return c
+	}
+	c.stepNames[name] = len(c.steps)
+	c.steps = append(c.steps, &typedStep[T]{name: name, fn: fn})
+	return c
+}
+
+func (c *TypedChain[T]) Run() (T, error) {
+	return c.RunWithContext(context.Background())
+}
+
+func (c *TypedChain[T]) RunWithContext(ctx context.Context) (T, error) {
+	value := c.initial
+	if c.err != nil {
+		return value, c.err
+	}
+	for _, step := range c.steps {
+		select {
+		case <-ctx.Done():
+			c.err = canceledError(ctx)
+			return value, c.err
+		default:
+		}
+		value = step.fn(value)
+		step.value = value
+		step.do = true
+	}
+	return value, nil
+}
+
+func (c *TypedChain[T]) Value(name string) (T, error) {
+	var zero T
+	idx, ok := c.stepNames[name]
+	if !ok {
+		return zero, &FlowError{Kind: ErrStepNotFoundErr, Message: ErrStepNotFound}
+	}
+	step := c.steps[idx]
+	if !step.do {
+		return zero, &FlowError{Kind: ErrNoResultErr, Message: fmt.Sprintf("%s: %s", ErrNoResult, name)}
+	}
+	return step.value, nil
+}
+
+func (c *TypedChain[T]) Error() error {
+	return c.err
+}
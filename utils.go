@@ -1,19 +1,44 @@
 package flow
 
-import "reflect"
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
 
 var (
-	errorType = reflect.TypeOf((*error)(nil)).Elem()
+	errorType    = reflect.TypeOf((*error)(nil)).Elem()
+	contextType  = reflect.TypeOf((*context.Context)(nil)).Elem()
+	loopInfoType = reflect.TypeOf(LoopInfo{})
 )
 
 type FlowError struct {
+	Kind    error
 	Message string
+	Node    string
+	Cause   error
 }
 
 func (e *FlowError) Error() string {
 	return e.Message
 }
 
+func (e *FlowError) Unwrap() error {
+	return e.Cause
+}
+
+func nodeFailure(name string, err error) *FlowError {
+	return &FlowError{Message: fmt.Sprintf("node %s failed: %v", name, err), Node: name, Cause: err}
+}
+
+func canceledError(ctx context.Context) *FlowError {
+	return &FlowError{Kind: ErrExecutionCanceledErr, Message: fmt.Sprintf("%s: %v", ErrExecutionCanceled, ctx.Err()), Cause: ctx.Err()}
+}
+
+func panicFailure(name string, r any) *FlowError {
+	return &FlowError{Kind: ErrFunctionPanickedErr, Message: fmt.Sprintf("%s: %s: %v", ErrFunctionPanicked, name, r), Node: name}
+}
+
 func canConvert(from, to reflect.Type) bool {
 	if from == to {
 		return true
@@ -35,7 +60,7 @@ func addArg(args *[]reflect.Value, val reflect.Value, argType reflect.Type) erro
 	valType := val.Type()
 	if !valType.AssignableTo(argType) {
 		if !canConvert(valType, argType) {
-			return &FlowError{Message: ErrArgTypeMismatch}
+			return &FlowError{Kind: ErrArgTypeMismatchErr, Message: ErrArgTypeMismatch}
 		}
 		*args = append(*args, val.Convert(argType))
 	} else {
@@ -48,7 +73,7 @@ func prepareArgsWithType(values []reflect.Value, argTypes []reflect.Type) ([]ref
 	argCount := len(argTypes)
 	if argCount == 0 {
 		if len(values) > 0 {
-			return nil, &FlowError{Message: ErrArgCountMismatch}
+			return nil, &FlowError{Kind: ErrArgCountMismatchErr, Message: ErrArgCountMismatch}
 		}
 		return nil, nil
 	}
@@ -81,7 +106,7 @@ func prepareArgsWithType(values []reflect.Value, argTypes []reflect.Type) ([]ref
 							valValue = valValue.Convert(elemType)
 						} else {
 							reflectValueSlicePool.Put(args)
-							return nil, &FlowError{Message: ErrArgTypeMismatch}
+							return nil, &FlowError{Kind: ErrArgTypeMismatchErr, Message: ErrArgTypeMismatch}
 						}
 					}
 					sliceValue.Index(i).Set(valValue)
@@ -101,7 +126,7 @@ func prepareArgsWithType(values []reflect.Value, argTypes []reflect.Type) ([]ref
 					elemCount := currentValueValue.Len()
 					if argCount > 0 && elemCount != argCount {
 						reflectValueSlicePool.Put(args)
-						return nil, &FlowError{Message: ErrArgCountMismatch}
+						return nil, &FlowError{Kind: ErrArgCountMismatchErr, Message: ErrArgCountMismatch}
 					}
 
 					for i := range elemCount {
@@ -123,7 +148,7 @@ func prepareArgsWithType(values []reflect.Value, argTypes []reflect.Type) ([]ref
 
 	if len(args) != argCount {
 		reflectValueSlicePool.Put(args)
-		return nil, &FlowError{Message: ErrArgCountMismatch}
+		return nil, &FlowError{Kind: ErrArgCountMismatchErr, Message: ErrArgCountMismatch}
 	}
 
 	return args, nil
@@ -0,0 +1,163 @@
+package flow
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const ErrValidationFailed = "graph validation failed"
+
+type ValidationError struct {
+	Issues []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrValidationFailed, strings.Join(e.Issues, "; "))
+}
+
+func (g *Graph) Validate() error {
+	if g.err != nil {
+		return g.err
+	}
+
+	var issues []string
+	if cycle := g.FindCycle(); cycle != nil {
+		issues = append(issues, fmt.Sprintf("%s: %s", ErrCyclicDependency, strings.Join(cycle, " -> ")))
+	}
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if len(g.nodes) > 0 && g.findStartNode() == "" {
+		issues = append(issues, ErrNoStartNode)
+	}
+
+	for _, from := range slices.Sorted(maps.Keys(g.edges)) {
+		for _, edge := range g.edges[from] {
+			if _, ok := g.nodes[edge.from]; !ok {
+				issues = append(issues, fmt.Sprintf("edge %s -> %s: %s: %s", edge.from, edge.to, ErrNodeNotFound, edge.from))
+			}
+			if _, ok := g.nodes[edge.to]; !ok {
+				issues = append(issues, fmt.Sprintf("edge %s -> %s: %s: %s", edge.from, edge.to, ErrNodeNotFound, edge.to))
+			}
+		}
+	}
+
+	for _, name := range g.unreachableNodes() {
+		issues = append(issues, fmt.Sprintf("node %s is unreachable from any start node", name))
+	}
+
+	incoming := make(map[string][]*Edge, len(g.nodes))
+	branchTargets := make(map[string]bool)
+	for _, edges := range g.edges {
+		for _, edge := range edges {
+			if edge.edgeType == EdgeTypeLoop {
+				continue
+			}
+			incoming[edge.to] = append(incoming[edge.to], edge)
+			if edge.edgeType == EdgeTypeBranch {
+				branchTargets[edge.to] = true
+			}
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(g.nodes)) {
+		if supplied, required, ok := g.inputArity(g.nodes[name], incoming[name], branchTargets); ok && supplied < required {
+			issues = append(issues, fmt.Sprintf("node %s requires %d inputs but incoming edges supply %d", name, required, supplied))
+		}
+	}
+
+	if len(issues) > 0 {
+		return &ValidationError{Issues: issues}
+	}
+	return nil
+}
+
+func (g *Graph) UnreachableNodes() []string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.unreachableNodes()
+}
+
+func (g *Graph) unreachableNodes() []string {
+	inDegree := make(map[string]int, len(g.nodes))
+	for _, edges := range g.edges {
+		for _, edge := range edges {
+			if edge.edgeType != EdgeTypeLoop {
+				inDegree[edge.to]++
+			}
+		}
+	}
+
+	scheduled := make(map[string]bool, len(g.nodes))
+	queue := make([]string, 0, len(g.nodes))
+	for name := range g.nodes {
+		if inDegree[name] == 0 {
+			queue = append(queue, name)
+		}
+	}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		scheduled[current] = true
+		for _, edge := range g.edges[current] {
+			if edge.edgeType == EdgeTypeLoop {
+				continue
+			}
+			inDegree[edge.to]--
+			if inDegree[edge.to] == 0 {
+				queue = append(queue, edge.to)
+			}
+		}
+	}
+
+	var unreachable []string
+	for name := range g.nodes {
+		if !scheduled[name] {
+			unreachable = append(unreachable, name)
+		}
+	}
+	sort.Strings(unreachable)
+	return unreachable
+}
+
+func (g *Graph) inputArity(node *Node, inEdges []*Edge, branchTargets map[string]bool) (int, int, bool) {
+	if node.fnType == nil || node.sliceArg || node.fnType.IsVariadic() || node.argCount == 0 || len(inEdges) == 0 {
+		return 0, 0, false
+	}
+
+	supplied := 0
+	alternative := 0
+	var single reflect.Type
+	for _, edge := range inEdges {
+		from := g.nodes[edge.from]
+		if from == nil || (from.fnType == nil && from.numOut == 0) {
+			return 0, 0, false
+		}
+		count := from.numOut
+		if from.hasErrorReturn {
+			count--
+		}
+		if edge.carryError {
+			count++
+		}
+		if count == 1 && from.fnType != nil && !from.hasErrorReturn {
+			single = from.fnType.Out(0)
+		}
+		if branchTargets[edge.from] {
+			alternative = max(alternative, count)
+		} else {
+			supplied += count
+		}
+	}
+	supplied += alternative
+
+	if supplied == 1 && !node.sliceAsSingle && (single == nil || single.Kind() == reflect.Slice ||
+		single.Kind() == reflect.Array || single.Kind() == reflect.Interface) {
+		return 0, 0, false
+	}
+	return supplied, node.argCount, true
+}
@@ -0,0 +1,47 @@
+package flow
+
+type GraphView struct {
+	graph *Graph
+}
+
+type StateCondFunc func(view *GraphView) bool
+
+func (g *Graph) AddStatefulEdge(from, to string, cond func(view *GraphView) bool, opts ...EdgeOption) *Graph {
+	return g.AddEdge(from, to, append([]EdgeOption{WithCondition(StateCondFunc(cond))}, opts...)...)
+}
+
+func (v *GraphView) Result(name string) ([]any, bool) {
+	v.graph.mu.RLock()
+	node, ok := v.graph.nodes[name]
+	v.graph.mu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+
+	node.mu.RLock()
+	defer node.mu.RUnlock()
+	if node.status != NodeStatusCompleted && node.status != NodeStatusSkipped {
+		return nil, false
+	}
+	return append([]any(nil), node.result...), true
+}
+
+func (v *GraphView) Status(name string) NodeStatus {
+	v.graph.mu.RLock()
+	node, ok := v.graph.nodes[name]
+	v.graph.mu.RUnlock()
+	if !ok {
+		return NodeStatusPending
+	}
+
+	node.mu.RLock()
+	defer node.mu.RUnlock()
+	return node.status
+}
+
+func (g *Graph) condMatches(edge *Edge, results []any) bool {
+	if edge.stateCond != nil {
+		return edge.stateCond(&GraphView{graph: g})
+	}
+	return edge.condFunc == nil || edge.condFunc(results)
+}
@@ -0,0 +1,90 @@
+package flow
+
+import (
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"slices"
+)
+
+const ErrInvalidBranchWeight = "invalid branch weight"
+
+type weightedBranch struct {
+	targets []string
+	weights []int
+	total   int
+}
+
+func (g *Graph) AddWeightedBranch(from string, weights map[string]int) *Graph {
+	if g.err != nil {
+		return g
+	}
+	if len(weights) == 0 {
+		g.err = &FlowError{Kind: ErrInvalidBranchWeightErr, Message: fmt.Sprintf("%s: no targets for %s", ErrInvalidBranchWeight, from)}
+		return g
+	}
+
+	branch := &weightedBranch{targets: slices.Sorted(maps.Keys(weights))}
+	for _, to := range branch.targets {
+		weight := weights[to]
+		if weight <= 0 {
+			g.err = &FlowError{Kind: ErrInvalidBranchWeightErr, Message: fmt.Sprintf("%s: %d for %s -> %s", ErrInvalidBranchWeight, weight, from, to)}
+			return g
+		}
+		branch.weights = append(branch.weights, weight)
+		branch.total += weight
+	}
+
+	for i, to := range branch.targets {
+		cond := StateCondFunc(func(view *GraphView) bool {
+			return view.graph.weightedPick(from, branch) == to
+		})
+		label := fmt.Sprintf("%d/%d", branch.weights[i], branch.total)
+		g.AddEdge(from, to, WithEdgeType(EdgeTypeBranch), WithCondition(cond), WithLabel(label))
+		if g.err != nil {
+			return g
+		}
+	}
+	return g
+}
+
+func (g *Graph) SetRandSeed(seed int64) *Graph {
+	g.randMu.Lock()
+	defer g.randMu.Unlock()
+	g.rng = rand.New(rand.NewPCG(uint64(seed), 0))
+	return g
+}
+
+func (g *Graph) weightedPick(from string, branch *weightedBranch) string {
+	g.randMu.Lock()
+	defer g.randMu.Unlock()
+
+	if to, ok := g.weightedPicks[from]; ok {
+		return to
+	}
+	var n int
+	if g.rng != nil {
+		n = g.rng.IntN(branch.total)
+	} else {
+		n = rand.IntN(branch.total)
+	}
+	to := branch.targets[len(branch.targets)-1]
+	for i, weight := range branch.weights {
+		if n < weight {
+			to = branch.targets[i]
+			break
+		}
+		n -= weight
+	}
+	if g.weightedPicks == nil {
+		g.weightedPicks = make(map[string]string)
+	}
+	g.weightedPicks[from] = to
+	return to
+}
+
+func (g *Graph) resetWeightedPicks() {
+	g.randMu.Lock()
+	defer g.randMu.Unlock()
+	clear(g.weightedPicks)
+}